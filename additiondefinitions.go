@@ -0,0 +1,193 @@
+package igc
+
+import (
+	"sync"
+)
+
+// An AdditionDefinition defines the meaning of the value of a record addition.
+// The value of an addition in Unit is its raw integer value multiplied by
+// Scale. Negative values are recorded with a leading minus sign.
+type AdditionDefinition struct {
+	TLC         string
+	Description string
+	Unit        string // Unit is the unit of the scaled value, or empty if the value is dimensionless.
+	Scale       float64
+}
+
+// Units.
+const (
+	UnitCelsius           = "°C"
+	UnitDegrees           = "°"
+	UnitG                 = "g"
+	UnitHectopascals      = "hPa"
+	UnitKilometersPerHour = "km/h"
+	UnitMeters            = "m"
+	UnitMetersPerSecond   = "m/s"
+	UnitRevsPerMinute     = "rpm"
+)
+
+var (
+	additionDefinitionsMutex sync.RWMutex
+	additionDefinitionsByTLC = make(map[string]*AdditionDefinition)
+)
+
+// Standard addition definitions. See section A7 of the IGC specification.
+func init() {
+	for _, additionDefinition := range []*AdditionDefinition{
+		{TLC: "ACX", Description: "linear acceleration in the X axis", Unit: UnitG, Scale: 0.1},
+		{TLC: "ACY", Description: "linear acceleration in the Y axis", Unit: UnitG, Scale: 0.1},
+		{TLC: "ACZ", Description: "linear acceleration in the Z axis", Unit: UnitG, Scale: 0.1},
+		{TLC: "ATS", Description: "altimeter pressure setting", Unit: UnitHectopascals, Scale: 0.01},
+		{TLC: "CCO", Description: "course over ground", Unit: UnitDegrees, Scale: 1},
+		{TLC: "DAE", Description: "displacement east", Unit: UnitMeters, Scale: 1},
+		{TLC: "DAN", Description: "displacement north", Unit: UnitMeters, Scale: 1},
+		{TLC: "ENL", Description: "engine noise level, from 0 to 999", Scale: 1},
+		{TLC: "FLP", Description: "flap position", Scale: 1},
+		{TLC: "FXA", Description: "fix accuracy, the estimated position error", Unit: UnitMeters, Scale: 1},
+		{TLC: "GSP", Description: "ground speed", Unit: UnitKilometersPerHour, Scale: 1},
+		{TLC: "HDM", Description: "heading magnetic", Unit: UnitDegrees, Scale: 1},
+		{TLC: "HDT", Description: "heading true", Unit: UnitDegrees, Scale: 1},
+		{TLC: "IAS", Description: "indicated airspeed", Unit: UnitKilometersPerHour, Scale: 1},
+		{TLC: "MOP", Description: "means of propulsion monitor, from 0 to 999", Scale: 1},
+		{TLC: "OAT", Description: "outside air temperature", Unit: UnitCelsius, Scale: 0.1},
+		{TLC: "RPM", Description: "engine revolutions per minute", Unit: UnitRevsPerMinute, Scale: 1},
+		{TLC: "SIU", Description: "satellites in use", Scale: 1},
+		{TLC: "TAS", Description: "true airspeed", Unit: UnitKilometersPerHour, Scale: 1},
+		{TLC: "TEN", Description: "total energy altitude", Unit: UnitMeters, Scale: 1},
+		{TLC: "TRM", Description: "track magnetic", Unit: UnitDegrees, Scale: 1},
+		{TLC: "TRT", Description: "track true", Unit: UnitDegrees, Scale: 1},
+		{TLC: "VAR", Description: "uncompensated variometer vertical speed, positive upwards", Unit: UnitMetersPerSecond, Scale: 0.1},
+		{TLC: "VAT", Description: "compensated variometer vertical speed, positive upwards", Unit: UnitMetersPerSecond, Scale: 0.1},
+		{TLC: "VXA", Description: "vertical fix accuracy", Unit: UnitMeters, Scale: 1},
+		{TLC: "WDI", Description: "wind direction, the direction from which the wind blows", Unit: UnitDegrees, Scale: 1},
+		{TLC: "WSP", Description: "wind speed", Unit: UnitKilometersPerHour, Scale: 1},
+	} {
+		additionDefinitionsByTLC[additionDefinition.TLC] = additionDefinition
+	}
+}
+
+// AdditionDefinitionByTLC returns the definition of the addition with
+// three-letter code tlc and whether it exists.
+func AdditionDefinitionByTLC(tlc string) (*AdditionDefinition, bool) {
+	additionDefinitionsMutex.RLock()
+	defer additionDefinitionsMutex.RUnlock()
+	additionDefinition, ok := additionDefinitionsByTLC[tlc]
+	return additionDefinition, ok
+}
+
+// RegisterAdditionDefinition registers additionDefinition, replacing any
+// existing definition with the same three-letter code. It can be used to
+// define non-standard additions or to override the scale of standard additions
+// for flight recorders that do not follow the IGC specification.
+func RegisterAdditionDefinition(additionDefinition *AdditionDefinition) {
+	additionDefinitionsMutex.Lock()
+	defer additionDefinitionsMutex.Unlock()
+	additionDefinitionsByTLC[additionDefinition.TLC] = additionDefinition
+}
+
+// Value returns the scaled value of the addition with three-letter code tlc,
+// in the unit of its definition, and whether it is present, valid, and
+// defined.
+func (a Additions) Value(tlc string) (float64, bool) {
+	value, ok := a.Get(tlc)
+	if !ok {
+		return 0, false
+	}
+	additionDefinition, ok := AdditionDefinitionByTLC(tlc)
+	if !ok {
+		return 0, false
+	}
+	return float64(value) * additionDefinition.Scale, true
+}
+
+// AccelerationZ returns the linear acceleration in the Z axis in g from the ACZ
+// addition.
+func (r *BRecord) AccelerationZ() (float64, bool) { return r.Additions.Value("ACZ") }
+
+// CompensatedVario returns the compensated variometer vertical speed in m/s
+// from the VAT addition.
+func (r *BRecord) CompensatedVario() (float64, bool) { return r.Additions.Value("VAT") }
+
+// EngineNoiseLevel returns the engine noise level from the ENL addition.
+func (r *BRecord) EngineNoiseLevel() (int, bool) { return r.Additions.Get("ENL") }
+
+// EngineRPM returns the engine revolutions per minute from the RPM addition.
+func (r *BRecord) EngineRPM() (int, bool) { return r.Additions.Get("RPM") }
+
+// FixAccuracy returns the fix accuracy in meters from the FXA addition.
+func (r *BRecord) FixAccuracy() (float64, bool) { return r.Additions.Value("FXA") }
+
+// GroundSpeed returns the ground speed in km/h from the GSP addition.
+func (r *BRecord) GroundSpeed() (float64, bool) { return r.Additions.Value("GSP") }
+
+// HeadingTrue returns the true heading in degrees from the HDT addition.
+func (r *BRecord) HeadingTrue() (float64, bool) { return r.Additions.Value("HDT") }
+
+// IndicatedAirspeed returns the indicated airspeed in km/h from the IAS
+// addition.
+func (r *BRecord) IndicatedAirspeed() (float64, bool) { return r.Additions.Value("IAS") }
+
+// MeansOfPropulsion returns the means of propulsion monitor level from the MOP
+// addition.
+func (r *BRecord) MeansOfPropulsion() (int, bool) { return r.Additions.Get("MOP") }
+
+// OutsideAirTemp returns the outside air temperature in °C from the OAT
+// addition.
+func (r *BRecord) OutsideAirTemp() (float64, bool) { return r.Additions.Value("OAT") }
+
+// SatellitesInUse returns the number of satellites in use from the SIU
+// addition.
+func (r *BRecord) SatellitesInUse() (int, bool) { return r.Additions.Get("SIU") }
+
+// TrackTrue returns the true track in degrees from the TRT addition.
+func (r *BRecord) TrackTrue() (float64, bool) { return r.Additions.Value("TRT") }
+
+// TrueAirspeed returns the true airspeed in km/h from the TAS addition.
+func (r *BRecord) TrueAirspeed() (float64, bool) { return r.Additions.Value("TAS") }
+
+// Vario returns the uncompensated variometer vertical speed in m/s from the
+// VAR addition.
+func (r *BRecord) Vario() (float64, bool) { return r.Additions.Value("VAR") }
+
+// VerticalAccuracy returns the vertical fix accuracy in meters from the VXA
+// addition.
+func (r *BRecord) VerticalAccuracy() (float64, bool) { return r.Additions.Value("VXA") }
+
+// WindDirection returns the direction from which the wind blows in degrees
+// from the WDI addition, or, failing that, the WVE addition.
+func (r *KRecord) WindDirection() (float64, bool) {
+	if windDirection, ok := r.Additions.Value("WDI"); ok {
+		return windDirection, true
+	}
+	windDirection, _, ok := r.WindVelocity()
+	return windDirection, ok
+}
+
+// WindSpeed returns the wind speed in km/h from the WSP addition, or, failing
+// that, the WVE addition.
+func (r *KRecord) WindSpeed() (float64, bool) {
+	if windSpeed, ok := r.Additions.Value("WSP"); ok {
+		return windSpeed, true
+	}
+	_, windSpeed, ok := r.WindVelocity()
+	return windSpeed, ok
+}
+
+// WindVelocity returns the wind direction in degrees and wind speed in km/h
+// from the WVE addition, which contains three digits of wind direction
+// followed by the wind speed.
+func (r *KRecord) WindVelocity() (float64, float64, bool) {
+	for i, addition := range r.Additions.layout {
+		if addition.TLC != "WVE" || !r.Additions.values[i].ok {
+			continue
+		}
+		width := addition.FinishColumn - addition.StartColumn + 1
+		if width <= 3 {
+			return 0, 0, false
+		}
+		value := r.Additions.values[i].value
+		speedDiv := intPow(10, width-3)
+		return float64(value / speedDiv), float64(value % speedDiv), true
+	}
+	return 0, 0, false
+}
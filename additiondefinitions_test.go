@@ -0,0 +1,111 @@
+package igc_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-igc"
+)
+
+func TestBRecordAdditionDefinitions(t *testing.T) {
+	igcFile, err := igc.ParseLines([]string{
+		"HFDTE020508",
+		"I093638FXA3940SIU4143ENL4446GSP4749TAS5053TRT5458VAT5962OAT6366ACZ",
+		"B1005364607690N00610358EA000000126501208010101020012100090-021-150",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(igcFile.Errs))
+	bRecord := igcFile.BRecords[0]
+
+	for _, tc := range []struct {
+		name     string
+		f        func() (float64, bool)
+		expected float64
+	}{
+		{name: "FixAccuracy", f: bRecord.FixAccuracy, expected: 12},
+		{name: "GroundSpeed", f: bRecord.GroundSpeed, expected: 101},
+		{name: "TrueAirspeed", f: bRecord.TrueAirspeed, expected: 20},
+		{name: "TrackTrue", f: bRecord.TrackTrue, expected: 121},
+		{name: "CompensatedVario", f: bRecord.CompensatedVario, expected: 9.0},
+		{name: "OutsideAirTemp", f: bRecord.OutsideAirTemp, expected: -2.1},
+		{name: "AccelerationZ", f: bRecord.AccelerationZ, expected: -15.0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, ok := tc.f()
+			assert.True(t, ok)
+			assert.True(t, math.Abs(tc.expected-actual) < 1e-9, "expected %f, got %f", tc.expected, actual)
+		})
+	}
+
+	satellitesInUse, ok := bRecord.SatellitesInUse()
+	assert.True(t, ok)
+	assert.Equal(t, 8, satellitesInUse)
+
+	engineNoiseLevel, ok := bRecord.EngineNoiseLevel()
+	assert.True(t, ok)
+	assert.Equal(t, 10, engineNoiseLevel)
+
+	_, ok = bRecord.VerticalAccuracy()
+	assert.False(t, ok)
+
+	_, ok = bRecord.EngineRPM()
+	assert.False(t, ok)
+}
+
+func TestKRecordAdditionDefinitions(t *testing.T) {
+	for _, tc := range []struct {
+		name                  string
+		lines                 []string
+		expectedWindDirection float64
+		expectedWindSpeed     float64
+	}{
+		{
+			name: "WDI_WSP",
+			lines: []string{
+				"HFDTE020508",
+				"J020810WDI1113WSP",
+				"K053137196017",
+			},
+			expectedWindDirection: 196,
+			expectedWindSpeed:     17,
+		},
+		{
+			name: "WVE",
+			lines: []string{
+				"HFDTE020508",
+				"J010812WVE",
+				"K05313727015",
+			},
+			expectedWindDirection: 270,
+			expectedWindSpeed:     15,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			igcFile, err := igc.ParseLines(tc.lines)
+			assert.NoError(t, err)
+			assert.Equal(t, 0, len(igcFile.Errs))
+			kRecord := igcFile.KRecords[0]
+			windDirection, ok := kRecord.WindDirection()
+			assert.True(t, ok)
+			assert.Equal(t, tc.expectedWindDirection, windDirection)
+			windSpeed, ok := kRecord.WindSpeed()
+			assert.True(t, ok)
+			assert.Equal(t, tc.expectedWindSpeed, windSpeed)
+		})
+	}
+}
+
+func TestRegisterAdditionDefinition(t *testing.T) {
+	_, ok := igc.AdditionDefinitionByTLC("XYZ")
+	assert.False(t, ok)
+	additions := igc.NewAdditions([]igc.RecordAddition{{TLC: "XYZ", StartColumn: 36, FinishColumn: 38}}, map[string]int{"XYZ": 123})
+	_, ok = additions.Value("XYZ")
+	assert.False(t, ok)
+
+	igc.RegisterAdditionDefinition(&igc.AdditionDefinition{TLC: "XYZ", Scale: 0.5})
+	value, ok := additions.Value("XYZ")
+	assert.True(t, ok)
+	assert.Equal(t, 61.5, value)
+}
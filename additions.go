@@ -0,0 +1,135 @@
+package igc
+
+import (
+	"iter"
+)
+
+// Additions are the values of the additions to a B, K, or N record. They are
+// stored in a slice indexed by the layout defined by the preceding I, J, or M
+// record, which is shared between all records with the same layout. Additions
+// with an AdditionDecoder have decoded values instead of integer values.
+type Additions struct {
+	layout  []RecordAddition
+	values  []additionValue
+	decoded []any
+}
+
+type additionValue struct {
+	value int
+	ok    bool
+}
+
+// NewAdditions returns a new Additions with the given layout and values.
+// Additions in layout without a value in values are treated as invalid.
+func NewAdditions(layout []RecordAddition, values map[string]int) Additions {
+	additions := Additions{
+		layout: layout,
+		values: make([]additionValue, len(layout)),
+	}
+	for i, addition := range layout {
+		additions.values[i].value, additions.values[i].ok = values[addition.TLC]
+	}
+	return additions
+}
+
+// All returns an iterator over the three-letter codes and values of all valid
+// additions, in layout order.
+func (a Additions) All() iter.Seq2[string, int] {
+	return func(yield func(string, int) bool) {
+		for i, value := range a.values {
+			if value.ok && !yield(a.layout[i].TLC, value.value) {
+				return
+			}
+		}
+	}
+}
+
+// Decoded returns the value of the addition with three-letter code tlc decoded
+// by its AdditionDecoder and whether it is present and valid.
+func (a Additions) Decoded(tlc string) (any, bool) {
+	for i, value := range a.decoded {
+		if a.layout[i].TLC == tlc && value != nil {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// DecodedAddition returns the value of the addition with three-letter code tlc
+// in a decoded by its AdditionDecoder and whether it is present, valid, and of
+// type T.
+func DecodedAddition[T any](a Additions, tlc string) (T, bool) {
+	value, ok := a.Decoded(tlc)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	t, ok := value.(T)
+	return t, ok
+}
+
+// Get returns the value of the addition with three-letter code tlc and whether
+// it is present and valid.
+func (a Additions) Get(tlc string) (int, bool) {
+	for i, value := range a.values {
+		if a.layout[i].TLC == tlc && value.ok {
+			return value.value, true
+		}
+	}
+	return 0, false
+}
+
+// Layout returns the layout of a.
+func (a Additions) Layout() []RecordAddition {
+	return a.layout
+}
+
+// Len returns the number of valid additions in a.
+func (a Additions) Len() int {
+	n := 0
+	for _, value := range a.values {
+		if value.ok {
+			n++
+		}
+	}
+	return n
+}
+
+// Map returns the valid additions in a as a map.
+func (a Additions) Map() map[string]int {
+	m := make(map[string]int, len(a.values))
+	for tlc, value := range a.All() {
+		m[tlc] = value
+	}
+	return m
+}
+
+// WithDecoded returns a copy of a with the decoded value of the addition with
+// three-letter code tlc set to value.
+func (a Additions) WithDecoded(tlc string, value any) Additions {
+	decoded := make([]any, len(a.layout))
+	copy(decoded, a.decoded)
+	for i, addition := range a.layout {
+		if addition.TLC == tlc {
+			decoded[i] = value
+		}
+	}
+	a.decoded = decoded
+	return a
+}
+
+// An additionsAllocator allocates addition values from shared chunks to reduce
+// the number of allocations per record.
+type additionsAllocator struct {
+	chunk []additionValue
+}
+
+// alloc returns a new slice of n addition values.
+func (aa *additionsAllocator) alloc(n int) []additionValue {
+	if len(aa.chunk) < n {
+		aa.chunk = make([]additionValue, max(1024, n))
+	}
+	values := aa.chunk[:n:n]
+	aa.chunk = aa.chunk[n:]
+	return values
+}
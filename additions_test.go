@@ -0,0 +1,106 @@
+package igc_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-igc"
+)
+
+func TestAdditions(t *testing.T) {
+	layout := []igc.RecordAddition{
+		{StartColumn: 36, FinishColumn: 38, TLC: "FXA"},
+		{StartColumn: 39, FinishColumn: 40, TLC: "SIU"},
+		{StartColumn: 41, FinishColumn: 43, TLC: "ENL"},
+	}
+	additions := igc.NewAdditions(layout, map[string]int{
+		"FXA": 12,
+		"ENL": 0,
+	})
+
+	value, ok := additions.Get("FXA")
+	assert.True(t, ok)
+	assert.Equal(t, 12, value)
+	_, ok = additions.Get("SIU")
+	assert.False(t, ok)
+	value, ok = additions.Get("ENL")
+	assert.True(t, ok)
+	assert.Equal(t, 0, value)
+	_, ok = additions.Get("TAS")
+	assert.False(t, ok)
+
+	assert.Equal(t, 2, additions.Len())
+	assert.Equal(t, layout, additions.Layout())
+	assert.Equal(t, map[string]int{"FXA": 12, "ENL": 0}, additions.Map())
+	assert.Equal(t, map[string]int{"FXA": 12, "ENL": 0}, maps.Collect(additions.All()))
+
+	var zero igc.Additions
+	assert.Equal(t, 0, zero.Len())
+	_, ok = zero.Get("FXA")
+	assert.False(t, ok)
+}
+
+func TestAdditionDecoder(t *testing.T) {
+	hexDecoder := func(data []byte) (any, error) {
+		return hex.DecodeString(string(data))
+	}
+	lines := []string{
+		"HFDTE020508",
+		"I023638FXA3944XHX",
+		"J010810XKX",
+		"B1005364607690N00610358EA0000001265012c0ffee",
+		"B1005374607690N00610358EA0000001265012zzzzzz",
+		"K100536Abc",
+	}
+	igcFile, err := igc.ParseLines(lines,
+		igc.WithAdditionDecoder("XHX", hexDecoder),
+		igc.WithAdditionDecoder("XKX", func(data []byte) (any, error) {
+			return string(data), nil
+		}),
+	)
+	assert.NoError(t, err)
+
+	fxa, ok := igcFile.BRecords[0].Additions.Get("FXA")
+	assert.True(t, ok)
+	assert.Equal(t, 12, fxa)
+	_, ok = igcFile.BRecords[0].Additions.Get("XHX")
+	assert.False(t, ok)
+	xhx, ok := igc.DecodedAddition[[]byte](igcFile.BRecords[0].Additions, "XHX")
+	assert.True(t, ok)
+	assert.Equal(t, []byte{0xc0, 0xff, 0xee}, xhx)
+	_, ok = igc.DecodedAddition[string](igcFile.BRecords[0].Additions, "XHX")
+	assert.False(t, ok)
+
+	_, ok = igcFile.BRecords[1].Additions.Decoded("XHX")
+	assert.False(t, ok)
+	assert.Equal(t, 1, len(igcFile.Errs))
+	var additionDecoderError *igc.AdditionDecoderError
+	assert.True(t, errors.As(igcFile.Errs[0], &additionDecoderError))
+	assert.Equal(t, "XHX", additionDecoderError.Addition.TLC)
+	var igcError *igc.Error
+	assert.True(t, errors.As(igcFile.Errs[0], &igcError))
+	assert.Equal(t, 5, igcError.Line)
+	assert.Equal(t, 39, igcError.Column)
+	assert.Equal(t, igc.ErrorCodeAdditionDecoder, igcError.Code())
+
+	xkx, ok := igc.DecodedAddition[string](igcFile.KRecords[0].Additions, "XKX")
+	assert.True(t, ok)
+	assert.Equal(t, "Abc", xkx)
+
+	// Decoded values that are strings or byte slices are encoded verbatim.
+	bRecord := *igcFile.BRecords[0]
+	bRecord.Additions = bRecord.Additions.WithDecoded("XHX", []byte("c0ffee"))
+	records := []igc.Record{igcFile.Records[1], igcFile.Records[2], &bRecord, igcFile.Records[5]}
+	buffer := &bytes.Buffer{}
+	encoder := igc.NewEncoder(buffer)
+	for _, record := range records {
+		assert.NoError(t, encoder.Encode(record))
+	}
+	assert.Equal(t, strings.Join([]string{lines[1], lines[2], lines[3], lines[5]}, "\r\n")+"\r\n", buffer.String())
+}
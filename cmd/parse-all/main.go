@@ -1,13 +1,13 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
-	"strings"
 
 	"github.com/twpayne/go-igc"
 )
@@ -45,9 +45,11 @@ func run() error {
 			}
 			fmt.Println(filepath.Join(arg, path) + ":")
 			for _, err := range igcFile.Errs {
-				if !strings.HasSuffix(err.Error(), "invalid F record") {
-					fmt.Println("- " + err.Error())
+				var invalidRecordError *igc.InvalidRecordError
+				if errors.As(err, &invalidRecordError) && invalidRecordError.RecordType == 'F' {
+					continue
 				}
+				fmt.Println("- " + err.Error())
 			}
 			return nil
 		}); err != nil {
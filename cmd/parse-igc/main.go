@@ -9,6 +9,7 @@ import (
 	"os"
 
 	"github.com/twpayne/go-igc"
+	"github.com/twpayne/go-igc/spec"
 )
 
 func parseFile(filename string, options []igc.ParseOption) (*igc.IGC, error) {
@@ -20,30 +21,71 @@ func parseFile(filename string, options []igc.ParseOption) (*igc.IGC, error) {
 	return igc.Parse(file, options...)
 }
 
+var strictnesses = map[string]igc.Strictness{
+	"default": igc.StrictnessDefault,
+	"lenient": igc.StrictnessLenient,
+	"strict":  igc.StrictnessStrict,
+}
+
 func run() error {
 	allowInvalidChars := flag.Bool("allow-invalid-chars", true, "allow invalid characters")
+	strictness := flag.String("strictness", "default", "strictness (default, lenient, or strict)")
+	info := flag.Bool("info", false, "also print info diagnostics")
+	validateSpec := flag.Bool("spec", false, "validate against the IGC specification")
 	flag.Parse()
+	strictnessValue, ok := strictnesses[*strictness]
+	if !ok {
+		return fmt.Errorf("%s: invalid strictness", *strictness)
+	}
 	options := []igc.ParseOption{
-		igc.WithAllowInvalidChars(*allowInvalidChars),
+		igc.WithStrictness(strictnessValue),
+	}
+	if strictnessValue == igc.StrictnessDefault {
+		options = append(options, igc.WithAllowInvalidChars(*allowInvalidChars))
+	}
+	if *info {
+		options = append(options, igc.WithMinSeverity(igc.SeverityInfo))
 	}
 	allOK := true
 	for _, arg := range flag.Args() {
-		switch igcResult, err := parseFile(arg, options); {
-		case err != nil:
+		igcResult, err := parseFile(arg, options)
+		if err != nil {
 			return err
-		case len(igcResult.Errs) == 0:
-			fmt.Println(arg + ": ok")
-		default:
-			allOK = false
-			for _, igcErr := range igcResult.Errs {
-				var igcError *igc.Error
-				if errors.As(igcErr, &igcError) {
-					fmt.Printf("%s:%d: %v\n", arg, igcError.Line, igcError.Err)
+		}
+		fileOK := true
+		for _, igcErr := range igcResult.Errs {
+			var igcError *igc.Error
+			switch {
+			case !errors.As(igcErr, &igcError):
+				fileOK = false
+				fmt.Printf("%s: %v\n", arg, igcErr)
+			case igcError.Severity < igc.SeverityError:
+				fmt.Printf("%s:%d: %s: %v\n", arg, igcError.Line, igcError.Severity, igcError.Err)
+			default:
+				fileOK = false
+				fmt.Printf("%s:%d: %v\n", arg, igcError.Line, igcError.Err)
+			}
+		}
+		if *validateSpec {
+			for _, violation := range spec.Validate(igcResult) {
+				switch {
+				case violation.Severity == igc.SeverityError:
+					fileOK = false
+				case violation.Severity < igc.SeverityWarning && !*info:
+					continue
+				}
+				if violation.Line == 0 {
+					fmt.Printf("%s: %s\n", arg, violation)
 				} else {
-					fmt.Printf("%s: %v\n", arg, igcErr)
+					fmt.Printf("%s:%s\n", arg, violation)
 				}
 			}
 		}
+		if fileOK {
+			fmt.Println(arg + ": ok")
+		} else {
+			allOK = false
+		}
 	}
 	if !allOK {
 		os.Exit(1)
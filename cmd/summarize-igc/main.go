@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/twpayne/go-igc"
+	"github.com/twpayne/go-igc/track"
 )
 
 type Range[T any] struct {
@@ -16,15 +17,39 @@ type Range[T any] struct {
 	Max T
 }
 
+type FixSummary struct {
+	Time time.Time
+	Lat  float64
+	Lon  float64
+}
+
+type FlightSummary struct {
+	Duration friendlyDuration
+	Takeoff  FixSummary
+	Landing  FixSummary
+	Landed   bool
+}
+
+type EngineRunSummary struct {
+	Duration  friendlyDuration
+	Start     FixSummary
+	End       FixSummary
+	AltGain   float64
+	Ambiguous bool
+}
+
 type BSummary struct {
-	Duration      friendlyDuration
-	Time          Range[time.Time]
-	TimeDeltas    map[int]int
-	Lat           Range[float64]
-	Lon           Range[float64]
-	AltWGS84      Range[float64]
-	AltBarometric Range[float64]
-	Additions     map[string]*Range[int] `json:",omitempty"`
+	Duration       friendlyDuration
+	FlightDuration friendlyDuration
+	Flights        []FlightSummary
+	EngineRuns     []EngineRunSummary `json:",omitempty"`
+	Time           Range[time.Time]
+	TimeDeltas     map[int]int
+	Lat            Range[float64]
+	Lon            Range[float64]
+	AltWGS84       Range[float64]
+	AltBarometric  Range[float64]
+	Additions      map[string]*Range[int] `json:",omitempty"`
 }
 
 type KSummary struct {
@@ -80,9 +105,10 @@ func summarizeFile(filename string) (*Summary, error) {
 		}
 	}
 
-	hRecordsByTLC := make(map[string]string, len(igc.HRecordsByTLC))
-	for tlc, hRecord := range igc.HRecordsByTLC {
-		hRecordsByTLC[tlc] = hRecord.Value
+	header := igc.Header()
+	hRecordsByTLC := make(map[string]string, len(header.HeaderFieldsByTLC))
+	for tlc, headerField := range header.HeaderFieldsByTLC {
+		hRecordsByTLC[tlc] = headerField.Value
 	}
 
 	var bRecordFreq float64
@@ -93,7 +119,7 @@ func summarizeFile(filename string) (*Summary, error) {
 		lonRange := Range[float64]{Min: math.Inf(1), Max: math.Inf(-1)}
 		altWGS84Range := Range[float64]{Min: math.Inf(1), Max: math.Inf(-1)}
 		altBarometricRange := Range[float64]{Min: math.Inf(1), Max: math.Inf(-1)}
-		bAdditionRanges := make(map[string]*Range[int], len(igc.BRecords[0].Additions))
+		bAdditionRanges := make(map[string]*Range[int], igc.BRecords[0].Additions.Len())
 		for i, bRecord := range igc.BRecords {
 			if i != 0 {
 				bRecordTimeDeltas[int(bRecord.Time.Sub(igc.BRecords[i-1].Time)/time.Second)]++
@@ -106,7 +132,7 @@ func summarizeFile(filename string) (*Summary, error) {
 			altWGS84Range.Max = max(altWGS84Range.Max, bRecord.AltWGS84)
 			altBarometricRange.Min = min(altBarometricRange.Min, bRecord.AltBarometric)
 			altBarometricRange.Max = max(altBarometricRange.Max, bRecord.AltBarometric)
-			for additionKey, additionValue := range bRecord.Additions {
+			for additionKey, additionValue := range bRecord.Additions.All() {
 				if additionRange, ok := bAdditionRanges[additionKey]; ok {
 					additionRange.Min = min(additionRange.Min, additionValue)
 					additionRange.Max = max(additionRange.Max, additionValue)
@@ -115,9 +141,34 @@ func summarizeFile(filename string) (*Summary, error) {
 				}
 			}
 		}
+		flights := track.DetectFlights(igc.BRecords)
+		flightSummaries := make([]FlightSummary, 0, len(flights))
+		var flightDuration time.Duration
+		for _, flight := range flights {
+			flightDuration += flight.Duration()
+			flightSummaries = append(flightSummaries, FlightSummary{
+				Duration: friendlyDuration(flight.Duration()),
+				Takeoff:  FixSummary{Time: flight.Takeoff.Time, Lat: flight.Takeoff.Lat, Lon: flight.Takeoff.Lon},
+				Landing:  FixSummary{Time: flight.Landing.Time, Lat: flight.Landing.Lat, Lon: flight.Landing.Lon},
+				Landed:   flight.Landed,
+			})
+		}
+		var engineRunSummaries []EngineRunSummary
+		for _, engineRun := range track.EngineRuns(igc) {
+			engineRunSummaries = append(engineRunSummaries, EngineRunSummary{
+				Duration:  friendlyDuration(engineRun.Duration()),
+				Start:     FixSummary{Time: engineRun.Start.Time, Lat: engineRun.Start.Lat, Lon: engineRun.Start.Lon},
+				End:       FixSummary{Time: engineRun.End.Time, Lat: engineRun.End.Lat, Lon: engineRun.End.Lon},
+				AltGain:   engineRun.AltGain,
+				Ambiguous: engineRun.Ambiguous,
+			})
+		}
 		bRecordFreq = float64(len(igc.BRecords)-1) * float64(time.Second) / float64(duration)
 		bSummary = &BSummary{
-			Duration: friendlyDuration(duration),
+			Duration:       friendlyDuration(duration),
+			FlightDuration: friendlyDuration(flightDuration),
+			Flights:        flightSummaries,
+			EngineRuns:     engineRunSummaries,
 			Time: Range[time.Time]{
 				Min: igc.BRecords[0].Time,
 				Max: igc.BRecords[len(igc.BRecords)-1].Time,
@@ -135,12 +186,12 @@ func summarizeFile(filename string) (*Summary, error) {
 	var kSummary *KSummary
 	if len(igc.KRecords) > 0 {
 		kRecordTimeDeltas := make(map[int]int)
-		kAdditionRanges := make(map[string]*Range[int], len(igc.BRecords[0].Additions))
+		kAdditionRanges := make(map[string]*Range[int], igc.KRecords[0].Additions.Len())
 		for i, kRecord := range igc.KRecords {
 			if i != 0 {
 				kRecordTimeDeltas[int(kRecord.Time.Sub(igc.KRecords[i-1].Time)/time.Second)]++
 			}
-			for additionKey, additionValue := range kRecord.Additions {
+			for additionKey, additionValue := range kRecord.Additions.All() {
 				if additionRange, ok := kAdditionRanges[additionKey]; ok {
 					additionRange.Min = min(additionRange.Min, additionValue)
 					additionRange.Max = max(additionRange.Max, additionValue)
@@ -0,0 +1,187 @@
+package igc
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+// A ConstellationSnapshot is the satellite constellation recorded in an F
+// record. It is valid from its time until the time of the next snapshot.
+type ConstellationSnapshot struct {
+	Time         time.Time
+	SatelliteIDs []int    // SatelliteIDs are the sorted IDs of the satellites in use.
+	Added        []int    // Added are the IDs of satellites added since the previous snapshot.
+	Removed      []int    // Removed are the IDs of satellites removed since the previous snapshot.
+	FRecord      *FRecord // FRecord is the F record of the snapshot.
+}
+
+// A Constellation is a time series of satellite constellation snapshots built
+// from F records.
+type Constellation struct {
+	Snapshots []*ConstellationSnapshot
+}
+
+// A TimeInterval is an interval of time.
+type TimeInterval struct {
+	Start time.Time
+	Stop  time.Time
+}
+
+// A CoverageSegment is a segment of consecutive B records with poor satellite
+// coverage.
+type CoverageSegment struct {
+	TimeInterval
+	StartIndex int // StartIndex is the index of the first B record in the segment.
+	StopIndex  int // StopIndex is the index of the last B record in the segment.
+}
+
+// A CoverageThreshold defines poor satellite coverage.
+type CoverageThreshold struct {
+	MinSatellites  int     // MinSatellites is the minimum number of satellites in use, or zero to ignore.
+	MaxFixAccuracy float64 // MaxFixAccuracy is the maximum fix accuracy in meters, or zero to ignore.
+}
+
+// NewConstellation returns a new Constellation built from the F records in
+// records.
+func NewConstellation(records []Record) *Constellation {
+	constellation := &Constellation{}
+	var prevSatelliteIDs []int
+	for _, record := range records {
+		fRecord, ok := record.(*FRecord)
+		if !ok || fRecord == nil {
+			continue
+		}
+		satelliteIDs := slices.Clone(fRecord.SatelliteIDs)
+		slices.Sort(satelliteIDs)
+		satelliteIDs = slices.Compact(satelliteIDs)
+		constellation.Snapshots = append(constellation.Snapshots, &ConstellationSnapshot{
+			Time:         fRecord.Time,
+			SatelliteIDs: satelliteIDs,
+			Added:        sortedDifference(satelliteIDs, prevSatelliteIDs),
+			Removed:      sortedDifference(prevSatelliteIDs, satelliteIDs),
+			FRecord:      fRecord,
+		})
+		prevSatelliteIDs = satelliteIDs
+	}
+	return constellation
+}
+
+// Constellation returns the satellite constellation time series built from
+// igc's F records.
+func (igc *IGC) Constellation() *Constellation {
+	return NewConstellation(igc.Records)
+}
+
+// At returns the snapshot valid at t and whether it exists.
+func (c *Constellation) At(t time.Time) (*ConstellationSnapshot, bool) {
+	i := sort.Search(len(c.Snapshots), func(i int) bool {
+		return c.Snapshots[i].Time.After(t)
+	})
+	if i == 0 {
+		return nil, false
+	}
+	return c.Snapshots[i-1], true
+}
+
+// SatellitesAt returns the number of satellites in use at t and whether it is
+// known.
+func (c *Constellation) SatellitesAt(t time.Time) (int, bool) {
+	snapshot, ok := c.At(t)
+	if !ok {
+		return 0, false
+	}
+	return len(snapshot.SatelliteIDs), true
+}
+
+// Changes returns the snapshots whose satellites differ from those of the
+// preceding snapshot, including the first snapshot.
+func (c *Constellation) Changes() []*ConstellationSnapshot {
+	var changes []*ConstellationSnapshot
+	for _, snapshot := range c.Snapshots {
+		if len(snapshot.Added) != 0 || len(snapshot.Removed) != 0 {
+			changes = append(changes, snapshot)
+		}
+	}
+	return changes
+}
+
+// Gaps returns the intervals between consecutive snapshots that are longer
+// than maxInterval.
+func (c *Constellation) Gaps(maxInterval time.Duration) []TimeInterval {
+	var gaps []TimeInterval
+	for i := 1; i < len(c.Snapshots); i++ {
+		start, stop := c.Snapshots[i-1].Time, c.Snapshots[i].Time
+		if stop.Sub(start) > maxInterval {
+			gaps = append(gaps, TimeInterval{
+				Start: start,
+				Stop:  stop,
+			})
+		}
+	}
+	return gaps
+}
+
+// PoorCoverage returns the segments of consecutive B records in bRecords with
+// poor satellite coverage according to threshold. The number of satellites
+// at each fix is taken from its SIU addition, or, failing that, from the
+// snapshot valid at the time of the fix. The fix accuracy is taken from its
+// FXA addition. Fixes where neither is known are not considered poor.
+func (c *Constellation) PoorCoverage(bRecords []*BRecord, threshold CoverageThreshold) []CoverageSegment {
+	var segments []CoverageSegment
+	var current *CoverageSegment
+	for i, bRecord := range bRecords {
+		if !c.isPoorCoverage(bRecord, threshold) {
+			current = nil
+			continue
+		}
+		if current == nil {
+			segments = append(segments, CoverageSegment{
+				TimeInterval: TimeInterval{
+					Start: bRecord.Time,
+				},
+				StartIndex: i,
+			})
+			current = &segments[len(segments)-1]
+		}
+		current.Stop = bRecord.Time
+		current.StopIndex = i
+	}
+	return segments
+}
+
+// isPoorCoverage returns whether bRecord has poor satellite coverage according
+// to threshold.
+func (c *Constellation) isPoorCoverage(bRecord *BRecord, threshold CoverageThreshold) bool {
+	if threshold.MinSatellites > 0 {
+		satellites, ok := bRecord.SatellitesInUse()
+		if !ok {
+			satellites, ok = c.SatellitesAt(bRecord.Time)
+		}
+		if ok && satellites < threshold.MinSatellites {
+			return true
+		}
+	}
+	if threshold.MaxFixAccuracy > 0 {
+		if fixAccuracy, ok := bRecord.FixAccuracy(); ok && fixAccuracy > threshold.MaxFixAccuracy {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedDifference returns the elements of the sorted slice a that are not in
+// the sorted slice b.
+func sortedDifference(a, b []int) []int {
+	var difference []int
+	j := 0
+	for _, x := range a {
+		for j < len(b) && b[j] < x {
+			j++
+		}
+		if j == len(b) || b[j] != x {
+			difference = append(difference, x)
+		}
+	}
+	return difference
+}
@@ -0,0 +1,83 @@
+package igc_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-igc"
+)
+
+func TestConstellation(t *testing.T) {
+	igcFile, err := igc.ParseLines([]string{
+		"HFDTE020508",
+		"I013637SIU",
+		"F100000040711",
+		"B1000004607690N00610358EA000000126508",
+		"F100010071104",
+		"B1000104607690N00610358EA000000126508",
+		"F10002007",
+		"B1000204607690N00610358EA000000126503",
+		"B1000304607690N00610358EA000000126503",
+		"F101000070911",
+		"B1010004607690N00610358EA000000126508",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(igcFile.Errs))
+
+	date := time.Date(2008, time.May, 2, 10, 0, 0, 0, time.UTC)
+	constellation := igcFile.Constellation()
+	assert.Equal(t, 4, len(constellation.Snapshots))
+	for _, snapshot := range constellation.Snapshots {
+		snapshot.FRecord = nil
+	}
+	assert.Equal(t, []*igc.ConstellationSnapshot{
+		{Time: date, SatelliteIDs: []int{4, 7, 11}, Added: []int{4, 7, 11}},
+		{Time: date.Add(20 * time.Second), SatelliteIDs: []int{7}, Removed: []int{4, 11}},
+		{Time: date.Add(10 * time.Minute), SatelliteIDs: []int{7, 9, 11}, Added: []int{9, 11}},
+	}, constellation.Changes())
+
+	_, ok := constellation.At(date.Add(-time.Second))
+	assert.False(t, ok)
+	satellites, ok := constellation.SatellitesAt(date.Add(15 * time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, 3, satellites)
+	satellites, ok = constellation.SatellitesAt(date.Add(time.Hour))
+	assert.True(t, ok)
+	assert.Equal(t, 3, satellites)
+
+	assert.Equal(t, []igc.TimeInterval{
+		{Start: date.Add(20 * time.Second), Stop: date.Add(10 * time.Minute)},
+	}, constellation.Gaps(5*time.Minute))
+
+	assert.Equal(t, []igc.CoverageSegment{
+		{
+			TimeInterval: igc.TimeInterval{Start: date.Add(20 * time.Second), Stop: date.Add(30 * time.Second)},
+			StartIndex:   2,
+			StopIndex:    3,
+		},
+	}, constellation.PoorCoverage(igcFile.BRecords, igc.CoverageThreshold{MinSatellites: 4}))
+}
+
+func TestConstellationPoorCoverageWithoutAdditions(t *testing.T) {
+	igcFile, err := igc.ParseLines([]string{
+		"HFDTE020508",
+		"I013638FXA",
+		"B1000004607690N00610358EA0000001265010",
+		"F10000504",
+		"B1000104607690N00610358EA0000001265010",
+		"B1000204607690N00610358EA0000001265250",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(igcFile.Errs))
+
+	date := time.Date(2008, time.May, 2, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, []igc.CoverageSegment{
+		{
+			TimeInterval: igc.TimeInterval{Start: date.Add(10 * time.Second), Stop: date.Add(20 * time.Second)},
+			StartIndex:   1,
+			StopIndex:    2,
+		},
+	}, igcFile.Constellation().PoorCoverage(igcFile.BRecords, igc.CoverageThreshold{MinSatellites: 4, MaxFixAccuracy: 50}))
+}
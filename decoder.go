@@ -0,0 +1,138 @@
+package igc
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"iter"
+)
+
+// Raw line buffers are allocated from chunks which grow from
+// minDecoderChunkSize to maxDecoderChunkSize.
+const (
+	minDecoderChunkSize = 4 << 10
+	maxDecoderChunkSize = 64 << 10
+)
+
+// A Decoder decodes lines from an input stream one at a time, without buffering
+// the whole input. It maintains the same state as Parse, including the
+// additions defined by I, J, and M records, the current date, and UTC midnight
+// rollover. Each line and record that it returns is allocated separately, so
+// retaining one does not retain the others.
+type Decoder struct {
+	p         *parser
+	r         *bufio.Reader
+	number    int
+	offset    int
+	chunk     []byte
+	chunkSize int
+	lines     []Line
+	err       error
+}
+
+// NewDecoder returns a new Decoder that reads from r.
+func NewDecoder(r io.Reader, options ...ParseOption) *Decoder {
+	return &Decoder{
+		p: newParser(options...),
+		r: bufio.NewReader(r),
+	}
+}
+
+// Decode reads and parses the next line. Errors parsing the line are returned
+// in the line's Err field. The returned error is non-nil only if the underlying
+// reader returns an error, and is io.EOF at the end of the input.
+func (d *Decoder) Decode() (*Line, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	raw, err := d.readLine()
+	switch {
+	case errors.Is(err, io.EOF) && len(raw) == 0:
+		d.err = io.EOF
+		return nil, d.err
+	case err != nil && !errors.Is(err, io.EOF):
+		d.err = err
+		return nil, d.err
+	}
+	d.number++
+	line := d.newLine()
+	line.Number = d.number
+	line.Offset = d.offset
+	line.Raw = raw
+	d.offset += len(raw)
+	_, data, _ := scanLines(raw, true)
+	d.p.parseLine(line, data)
+	return line, nil
+}
+
+// Records returns an iterator over the records in the input. Each non-empty
+// line yields its record and any error parsing it. If the underlying reader
+// returns an error other than io.EOF then it is yielded with a nil record and
+// iteration stops.
+func (d *Decoder) Records() iter.Seq2[Record, error] {
+	return func(yield func(Record, error) bool) {
+		for {
+			line, err := d.Decode()
+			switch {
+			case errors.Is(err, io.EOF):
+				return
+			case err != nil:
+				yield(nil, err)
+				return
+			case line.Record == nil && line.Err == nil:
+				continue
+			case !yield(line.Record, line.Err):
+				return
+			}
+		}
+	}
+}
+
+// readLine reads the next line, including its terminator, into memory owned by
+// the returned slice.
+func (d *Decoder) readLine() ([]byte, error) {
+	data, err := d.r.ReadSlice('\n')
+	if !errors.Is(err, bufio.ErrBufferFull) {
+		return d.copy(data), err
+	}
+	// The line is longer than the reader's buffer, so accumulate it.
+	raw := append([]byte(nil), data...)
+	for errors.Is(err, bufio.ErrBufferFull) {
+		data, err = d.r.ReadSlice('\n')
+		raw = append(raw, data...)
+	}
+	return raw, err
+}
+
+// newLine returns a new Line. To reduce allocations, Lines are allocated in
+// batches if the parser allocates records in batches.
+func (d *Decoder) newLine() *Line {
+	if !d.p.batch {
+		return &Line{}
+	}
+	if len(d.lines) == 0 {
+		d.lines = make([]Line, 256)
+	}
+	line := &d.lines[0]
+	d.lines = d.lines[1:]
+	return line
+}
+
+// copy returns a copy of data. To reduce allocations, small copies are
+// allocated from a shared chunk if the parser allocates records in batches.
+func (d *Decoder) copy(data []byte) []byte {
+	if len(data) == 0 {
+		return nil
+	}
+	if !d.p.batch || len(data) > minDecoderChunkSize/4 {
+		return append([]byte(nil), data...)
+	}
+	if len(d.chunk) < len(data) {
+		d.chunkSize = min(max(2*d.chunkSize, minDecoderChunkSize), maxDecoderChunkSize)
+		d.chunk = make([]byte, d.chunkSize)
+	}
+	result := d.chunk[:len(data):len(data)]
+	copy(result, data)
+	d.chunk = d.chunk[len(data):]
+	return result
+}
@@ -0,0 +1,76 @@
+package igc_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-igc"
+)
+
+func TestDecoder(t *testing.T) {
+	longText := strings.Repeat("x", 10000)
+	data := strings.Join([]string{
+		"HFDTE020508",
+		"I023636LAD3737LOD",
+		"",
+		"B2359594607690N00610358EA000000126512",
+		"X",
+		"B0000014607690N00610358EA000000126534",
+		"LXXX" + longText,
+	}, "\r\n")
+	decoder := igc.NewDecoder(strings.NewReader(data))
+
+	var lines []*igc.Line
+	for {
+		line, err := decoder.Decode()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		assert.NoError(t, err)
+		lines = append(lines, line)
+	}
+	assert.Equal(t, 7, len(lines))
+	assert.Equal(t, "I023636LAD3737LOD\r\n", string(lines[1].Raw))
+	assert.Equal(t, 32, lines[2].Offset)
+	assert.Equal(t, nil, lines[2].Record)
+	assert.Equal(t, time.Date(2008, time.May, 2, 23, 59, 59, 0, time.UTC), lines[3].Record.(*igc.BRecord).Time) //nolint:forcetypeassert
+	assert.Equal(t, time.Date(2008, time.May, 3, 0, 0, 1, 0, time.UTC), lines[5].Record.(*igc.BRecord).Time)    //nolint:forcetypeassert
+	assert.EqualError(t, lines[4].Err, "5: X: unknown record type")
+	assert.Equal(t, igc.Record(&igc.LRecord{Input: "XXX", Text: longText}), lines[6].Record)
+
+	_, err := decoder.Decode()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestDecoderRecords(t *testing.T) {
+	data := "HFDTE020508\nX\n\nB1005364607690N00610358EA0000001265\n"
+	var records []igc.Record
+	var errs []string
+	for record, err := range igc.NewDecoder(strings.NewReader(data)).Records() {
+		records = append(records, record)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	assert.Equal(t, 3, len(records))
+	assert.Equal(t, []string{"2: X: unknown record type"}, errs)
+}
+
+func TestDecoderReadError(t *testing.T) {
+	errRead := errors.New("read error")
+	r := io.MultiReader(strings.NewReader("HFDTE020508\n"), iotest.ErrReader(errRead))
+	var errs []error
+	for _, err := range igc.NewDecoder(r).Records() {
+		errs = append(errs, err)
+	}
+	assert.Equal(t, []error{nil, errRead}, errs)
+
+	_, err := igc.Parse(io.MultiReader(strings.NewReader("HFDTE020508\n"), iotest.ErrReader(errRead)))
+	assert.Equal(t, errRead, err)
+}
@@ -0,0 +1,443 @@
+package igc
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+var (
+	errMissingValidity = errors.New("missing validity")
+	errNilRecord       = errors.New("nil record")
+)
+
+type fieldRangeError struct {
+	recordType byte
+	field      string
+	value      int
+}
+
+func (e *fieldRangeError) Error() string {
+	return fmt.Sprintf("%c record: %s: %d: out of range", e.recordType, e.field, e.value)
+}
+
+type unsupportedRecordError struct {
+	record Record
+}
+
+func (e *unsupportedRecordError) Error() string {
+	return fmt.Sprintf("%T: unsupported record", e.record)
+}
+
+// An Encoder writes records as IGC lines.
+//
+// An Encoder tracks the additions defined by I, J, and M records in the same
+// way as the parser, so B, K, and N records are laid out according to the most
+// recently encoded I, J, and M records.
+type Encoder struct {
+	w                      io.Writer
+	buf                    []byte
+	bRecordAdditions       []RecordAddition
+	bRecordsAdditionsByTLC map[string]*RecordAddition
+	latMinMul              int
+	lonMinMul              int
+	fracSecondDiv          int
+	kRecordAdditions       []RecordAddition
+	nRecordAdditions       []RecordAddition
+}
+
+// NewEncoder returns a new Encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		w:                      w,
+		bRecordsAdditionsByTLC: make(map[string]*RecordAddition),
+		latMinMul:              1,
+		lonMinMul:              1,
+		fracSecondDiv:          1e9,
+	}
+}
+
+// Encode writes record as a single line terminated by CRLF.
+func (e *Encoder) Encode(record Record) error {
+	buf, err := e.appendRecord(e.buf[:0], record)
+	if err != nil {
+		return err
+	}
+	buf = append(buf, '\r', '\n')
+	e.buf = buf
+	if _, err := e.w.Write(buf); err != nil {
+		return err
+	}
+	switch record := record.(type) {
+	case *IRecord:
+		e.bRecordAdditions = append(e.bRecordAdditions, record.Additions...)
+		for i, bRecordAddition := range record.Additions {
+			e.bRecordsAdditionsByTLC[bRecordAddition.TLC] = &record.Additions[i]
+		}
+		if ladBRecordAddition, ok := e.bRecordsAdditionsByTLC["LAD"]; ok {
+			e.latMinMul = intPow(10, ladBRecordAddition.FinishColumn-ladBRecordAddition.StartColumn+1)
+		}
+		if lodBRecordAddition, ok := e.bRecordsAdditionsByTLC["LOD"]; ok {
+			e.lonMinMul = intPow(10, lodBRecordAddition.FinishColumn-lodBRecordAddition.StartColumn+1)
+		}
+		if tdsBRecordAddition, ok := e.bRecordsAdditionsByTLC["TDS"]; ok {
+			e.fracSecondDiv = intPow(10, 9-(tdsBRecordAddition.FinishColumn-tdsBRecordAddition.StartColumn+1))
+		}
+	case *JRecord:
+		e.kRecordAdditions = record.Additions
+	case *MRecord:
+		e.nRecordAdditions = record.Additions
+	}
+	return nil
+}
+
+// Write writes all non-nil records in igc to w.
+func (igc *IGC) Write(w io.Writer) error {
+	e := NewEncoder(w)
+	for _, record := range igc.Records {
+		if record == nil {
+			continue
+		}
+		if err := e.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Encoder) appendRecord(buf []byte, record Record) ([]byte, error) {
+	switch record := record.(type) {
+	case nil:
+		return nil, errNilRecord
+	case *ARecord:
+		buf = append(buf, 'A')
+		buf = append(buf, record.ManufacturerID...)
+		buf = append(buf, record.UniqueFlightRecorderID...)
+		if record.AdditionalData != "" {
+			buf = append(buf, '-')
+			buf = append(buf, record.AdditionalData...)
+		}
+		return buf, nil
+	case *BRecord:
+		return e.appendBRecord(buf, record)
+	case *CRecordDeclaration:
+		return appendCRecordDeclaration(buf, record)
+	case *CRecordWaypoint:
+		buf = append(buf, 'C')
+		buf, err := appendLatLon(buf, 'C', record.Lat, record.Lon, 1, 1)
+		if err != nil {
+			return nil, err
+		}
+		return append(buf, record.Text...), nil
+	case *DRecord:
+		buf = append(buf, 'D', byte(record.GPSQualifier))
+		return appendInt(buf, 'D', "DGPS station ID", record.DGPSStationID, 4)
+	case *ERecord:
+		buf = append(buf, 'E')
+		buf = appendTime(buf, record.Time)
+		buf = append(buf, record.TLC...)
+		return append(buf, record.Text...), nil
+	case *ERecordWithoutTLC:
+		buf = append(buf, 'E')
+		buf = appendTime(buf, record.Time)
+		return append(buf, record.Text...), nil
+	case *FRecord:
+		buf = append(buf, 'F')
+		buf = appendTime(buf, record.Time)
+		for _, satelliteID := range record.SatelliteIDs {
+			var err error
+			buf, err = appendInt(buf, 'F', "satellite ID", satelliteID, 2)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case *GRecord:
+		buf = append(buf, 'G')
+		return append(buf, record.Text...), nil
+	case *HFDTERecord:
+		return appendHRecord(buf, string(record.Source), &record.HRecord), nil
+	case *HRecord:
+		return appendHRecord(buf, string(record.Source), record), nil
+	case *HRecordWithInvalidSource:
+		return appendHRecord(buf, record.Source, &HRecord{
+			TLC:      record.TLC,
+			LongName: record.LongName,
+			Value:    record.Value,
+		}), nil
+	case *IRecord:
+		return appendRecordAdditions(append(buf, 'I'), 'I', record.Additions)
+	case *JRecord:
+		return appendRecordAdditions(append(buf, 'J'), 'J', record.Additions)
+	case *KRecord:
+		buf = append(buf, 'K')
+		buf = appendTime(buf, record.Time)
+		return appendAdditionValues(buf, 'K', e.kRecordAdditions, record.Additions, record.Additions.Get)
+	case *LRecord:
+		buf = append(buf, 'L')
+		buf = append(buf, record.Input...)
+		return append(buf, record.Text...), nil
+	case *LRecordWithoutTLC:
+		buf = append(buf, 'L')
+		return append(buf, record.Text...), nil
+	case *MRecord:
+		return appendRecordAdditions(append(buf, 'M'), 'M', record.Additions)
+	case *NRecord:
+		buf = append(buf, 'N')
+		buf = appendTime(buf, record.Time)
+		return appendAdditionValues(buf, 'N', e.nRecordAdditions, record.Additions, record.Additions.Get)
+	default:
+		return nil, &unsupportedRecordError{
+			record: record,
+		}
+	}
+}
+
+func (e *Encoder) appendBRecord(buf []byte, bRecord *BRecord) ([]byte, error) {
+	if bRecord.Validity == 0 {
+		return nil, errMissingValidity
+	}
+	buf = append(buf, 'B')
+	buf = appendTime(buf, bRecord.Time)
+	buf, err := appendLatLon(buf, 'B', bRecord.Lat, bRecord.Lon, e.latMinMul, e.lonMinMul)
+	if err != nil {
+		return nil, err
+	}
+	buf = append(buf, byte(bRecord.Validity))
+	if buf, err = appendInt(buf, 'B', "barometric altitude", int(math.Round(bRecord.AltBarometric)), 5); err != nil {
+		return nil, err
+	}
+	if buf, err = appendInt(buf, 'B', "WGS84 altitude", int(math.Round(bRecord.AltWGS84)), 5); err != nil {
+		return nil, err
+	}
+	if len(e.bRecordAdditions) == 0 {
+		return buf, nil
+	}
+
+	// LAD, LOD, and TDS are derived from the latitude, longitude, and time so
+	// that they are always consistent with them.
+	return appendAdditionValues(buf, 'B', e.bRecordAdditions, bRecord.Additions, func(tlc string) (int, bool) {
+		switch tlc {
+		case "LAD":
+			return splitMinutes(bRecord.Lat, e.latMinMul).extra, true
+		case "LOD":
+			return splitMinutes(bRecord.Lon, e.lonMinMul).extra, true
+		case "TDS":
+			return bRecord.Time.Nanosecond() / e.fracSecondDiv, true
+		default:
+			return bRecord.Additions.Get(tlc)
+		}
+	})
+}
+
+func appendCRecordDeclaration(buf []byte, cRecordDeclaration *CRecordDeclaration) ([]byte, error) {
+	buf = append(buf, 'C')
+	t := cRecordDeclaration.DeclarationTime
+	buf = appendDigits(buf, t.Day(), 2)
+	buf = appendDigits(buf, int(t.Month()), 2)
+	buf = appendDigits(buf, t.Year()%100, 2)
+	buf = appendTime(buf, t)
+	for _, field := range []struct {
+		name  string
+		value int
+		width int
+	}{
+		{name: "flight day", value: cRecordDeclaration.FlightDay, width: 2},
+		{name: "flight month", value: cRecordDeclaration.FlightMonth, width: 2},
+		{name: "flight year", value: cRecordDeclaration.FlightYear, width: 2},
+		{name: "task number", value: cRecordDeclaration.TaskNumber, width: 4},
+		{name: "number of turnpoints", value: cRecordDeclaration.NumberOfTurnpoints, width: 2},
+	} {
+		var err error
+		buf, err = appendInt(buf, 'C', field.name, field.value, field.width)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return append(buf, cRecordDeclaration.Text...), nil
+}
+
+func appendHRecord(buf []byte, source string, hRecord *HRecord) []byte {
+	buf = append(buf, 'H')
+	buf = append(buf, source...)
+	buf = append(buf, hRecord.TLC...)
+	switch {
+	case hRecord.LongName == "" && (hRecord.TLC == "DTE" || hRecord.TLC == "FXA"):
+		// Old-style HFDTE and HFFXA records have no long name.
+		buf = append(buf, hRecord.Value...)
+	case hRecord.Value == "":
+		buf = append(buf, hRecord.LongName...)
+	default:
+		buf = append(buf, hRecord.LongName...)
+		buf = append(buf, ':')
+		buf = append(buf, hRecord.Value...)
+	}
+	return buf
+}
+
+func appendRecordAdditions(buf []byte, recordType byte, additions []RecordAddition) ([]byte, error) {
+	buf, err := appendInt(buf, recordType, "number of additions", len(additions), 2)
+	if err != nil {
+		return nil, err
+	}
+	for _, addition := range additions {
+		if buf, err = appendInt(buf, recordType, addition.TLC+" start column", addition.StartColumn, 2); err != nil {
+			return nil, err
+		}
+		if buf, err = appendInt(buf, recordType, addition.TLC+" finish column", addition.FinishColumn, 2); err != nil {
+			return nil, err
+		}
+		buf = append(buf, addition.TLC...)
+	}
+	return buf, nil
+}
+
+// appendAdditionValues appends the values of additions to buf. Decoded values
+// in recordAdditions take precedence over the integer values returned by get.
+func appendAdditionValues(buf []byte, recordType byte, additions []RecordAddition, recordAdditions Additions, get func(string) (int, bool)) ([]byte, error) {
+	for _, addition := range additions {
+		width := addition.FinishColumn - addition.StartColumn + 1
+		appendValue := func(buf []byte) ([]byte, error) {
+			if decoded, ok := recordAdditions.Decoded(addition.TLC); ok {
+				return appendDecodedAdditionValue(buf, recordType, addition, decoded)
+			}
+			value, ok := get(addition.TLC)
+			if !ok {
+				return nil, &MissingAdditionError{
+					RecordType: recordType,
+					Addition:   addition,
+				}
+			}
+			return appendInt(buf, recordType, addition.TLC, value, width)
+		}
+		var err error
+		switch {
+		case len(buf) == addition.StartColumn-1:
+			buf, err = appendValue(buf)
+		case len(buf) >= addition.FinishColumn:
+			// Repeated I records redefine additions that have already been
+			// written, so overwrite them in place.
+			_, err = appendValue(buf[:addition.StartColumn-1])
+		default:
+			err = &InvalidAdditionError{
+				RecordType: recordType,
+				Addition:   addition,
+				Message:    "invalid start column",
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+// A minutes is an absolute coordinate split into whole degrees, thousandths of
+// minutes, and extra digits of precision.
+type minutes struct {
+	deg   int
+	min   int
+	extra int
+}
+
+// appendDecodedAdditionValue appends the decoded value of addition to buf.
+// Decoded values must be strings, byte slices, or implement
+// encoding.TextMarshaler, and must exactly fill the addition's columns.
+func appendDecodedAdditionValue(buf []byte, recordType byte, addition RecordAddition, decoded any) ([]byte, error) {
+	var data []byte
+	switch decoded := decoded.(type) {
+	case []byte:
+		data = decoded
+	case string:
+		data = []byte(decoded)
+	case encoding.TextMarshaler:
+		var err error
+		if data, err = decoded.MarshalText(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, &InvalidAdditionError{
+			RecordType: recordType,
+			Addition:   addition,
+			Message:    fmt.Sprintf("%T: unsupported decoded value type", decoded),
+		}
+	}
+	if len(data) != addition.FinishColumn-addition.StartColumn+1 {
+		return nil, &InvalidAdditionError{
+			RecordType: recordType,
+			Addition:   addition,
+			Message:    fmt.Sprintf("%q: invalid width", data),
+		}
+	}
+	return append(buf, data...), nil
+}
+
+// splitMinutes splits the absolute value of coord into degrees, thousandths of
+// minutes, and extra digits with precision mul.
+func splitMinutes(coord float64, mul int) minutes {
+	totalMin := int(math.Round(math.Abs(coord) * 6e4 * float64(mul)))
+	return minutes{
+		deg:   totalMin / (6e4 * mul),
+		min:   totalMin % (6e4 * mul) / mul,
+		extra: totalMin % mul,
+	}
+}
+
+func appendLatLon(buf []byte, recordType byte, lat, lon float64, latMinMul, lonMinMul int) ([]byte, error) {
+	latMinutes := splitMinutes(lat, latMinMul)
+	if latMinutes.deg > 90 {
+		return nil, &fieldRangeError{recordType: recordType, field: "latitude", value: latMinutes.deg}
+	}
+	buf = appendDigits(buf, latMinutes.deg, 2)
+	buf = appendDigits(buf, latMinutes.min, 5)
+	if lat < 0 {
+		buf = append(buf, 'S')
+	} else {
+		buf = append(buf, 'N')
+	}
+	lonMinutes := splitMinutes(lon, lonMinMul)
+	if lonMinutes.deg > 180 {
+		return nil, &fieldRangeError{recordType: recordType, field: "longitude", value: lonMinutes.deg}
+	}
+	buf = appendDigits(buf, lonMinutes.deg, 3)
+	buf = appendDigits(buf, lonMinutes.min, 5)
+	if lon < 0 {
+		buf = append(buf, 'W')
+	} else {
+		buf = append(buf, 'E')
+	}
+	return buf, nil
+}
+
+// appendTime appends the UTC time of day of t as HHMMSS.
+func appendTime(buf []byte, t time.Time) []byte {
+	t = t.UTC()
+	buf = appendDigits(buf, t.Hour(), 2)
+	buf = appendDigits(buf, t.Minute(), 2)
+	return appendDigits(buf, t.Second(), 2)
+}
+
+// appendInt appends value as exactly width characters, using a leading minus
+// sign for negative values, and returns an error if value does not fit.
+func appendInt(buf []byte, recordType byte, field string, value, width int) ([]byte, error) {
+	switch {
+	case value >= 0 && value < intPow(10, width):
+		return appendDigits(buf, value, width), nil
+	case value < 0 && width > 1 && -value < intPow(10, width-1):
+		return appendDigits(append(buf, '-'), -value, width-1), nil
+	default:
+		return nil, &fieldRangeError{recordType: recordType, field: field, value: value}
+	}
+}
+
+// appendDigits appends the width least significant decimal digits of the
+// non-negative value.
+func appendDigits(buf []byte, value, width int) []byte {
+	for i := width - 1; i >= 0; i-- {
+		buf = append(buf, byte('0'+value/intPow(10, i)%10))
+	}
+	return buf
+}
@@ -0,0 +1,354 @@
+package igc_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-igc"
+)
+
+func TestEncoder(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		records       []igc.Record
+		expectedLines []string
+		expectedErr   string
+	}{
+		{
+			name: "a_record",
+			records: []igc.Record{
+				&igc.ARecord{
+					ManufacturerID:         "FLY",
+					UniqueFlightRecorderID: "05094",
+					AdditionalData:         "extra",
+				},
+			},
+			expectedLines: []string{
+				"AFLY05094-extra",
+			},
+		},
+		{
+			name: "b_record",
+			records: []igc.Record{
+				&igc.BRecord{
+					Time:          time.Date(2008, time.May, 2, 10, 5, 36, 0, time.UTC),
+					Lat:           46 + 7690/6e4,
+					Lon:           -(6 + 10358/6e4),
+					Validity:      igc.Validity3D,
+					AltBarometric: -12,
+					AltWGS84:      1265,
+				},
+			},
+			expectedLines: []string{
+				"B1005364607690N00610358WA-001201265",
+			},
+		},
+		{
+			name: "b_record_with_additions",
+			records: []igc.Record{
+				&igc.IRecord{
+					Additions: []igc.RecordAddition{
+						{StartColumn: 36, FinishColumn: 38, TLC: "FXA"},
+						{StartColumn: 39, FinishColumn: 40, TLC: "SIU"},
+					},
+				},
+				&igc.BRecord{
+					Time:     time.Date(2024, time.June, 4, 12, 0, 1, 0, time.UTC),
+					Lat:      -(45 + 1234/6e4),
+					Lon:      123 + 45678/6e4,
+					Validity: igc.Validity2D,
+					AltWGS84: 850,
+					Additions: igc.NewAdditions(
+						[]igc.RecordAddition{
+							{StartColumn: 36, FinishColumn: 38, TLC: "FXA"},
+							{StartColumn: 39, FinishColumn: 40, TLC: "SIU"},
+						},
+						map[string]int{
+							"FXA": 5,
+							"SIU": 12,
+						},
+					),
+				},
+			},
+			expectedLines: []string{
+				"I023638FXA3940SIU",
+				"B1200014501234S12345678EV000000085000512",
+			},
+		},
+		{
+			name: "b_record_with_lad_lod_tds",
+			records: []igc.Record{
+				&igc.IRecord{
+					Additions: []igc.RecordAddition{
+						{StartColumn: 36, FinishColumn: 36, TLC: "LAD"},
+						{StartColumn: 37, FinishColumn: 37, TLC: "LOD"},
+						{StartColumn: 38, FinishColumn: 38, TLC: "TDS"},
+					},
+				},
+				&igc.BRecord{
+					Time:     time.Date(2024, time.June, 4, 12, 0, 1, 700e6, time.UTC),
+					Lat:      46 + 76903/6e5,
+					Lon:      6 + 103581/6e5,
+					Validity: igc.Validity3D,
+				},
+			},
+			expectedLines: []string{
+				"I033636LAD3737LOD3838TDS",
+				"B1200014607690N00610358EA0000000000317",
+			},
+		},
+		{
+			name: "b_record_missing_addition",
+			records: []igc.Record{
+				&igc.IRecord{
+					Additions: []igc.RecordAddition{
+						{StartColumn: 36, FinishColumn: 38, TLC: "FXA"},
+					},
+				},
+				&igc.BRecord{
+					Validity: igc.Validity3D,
+				},
+			},
+			expectedErr: "missing FXA addition",
+		},
+		{
+			name: "b_record_addition_out_of_range",
+			records: []igc.Record{
+				&igc.IRecord{
+					Additions: []igc.RecordAddition{
+						{StartColumn: 36, FinishColumn: 37, TLC: "SIU"},
+					},
+				},
+				&igc.BRecord{
+					Validity: igc.Validity3D,
+					Additions: igc.NewAdditions(
+						[]igc.RecordAddition{
+							{StartColumn: 36, FinishColumn: 37, TLC: "SIU"},
+						},
+						map[string]int{
+							"SIU": 100,
+						},
+					),
+				},
+			},
+			expectedErr: "B record: SIU: 100: out of range",
+		},
+		{
+			name: "c_records",
+			records: []igc.Record{
+				&igc.CRecordDeclaration{
+					DeclarationTime:    time.Date(2023, time.October, 1, 12, 0, 37, 0, time.UTC),
+					NumberOfTurnpoints: -1,
+					Text:               " Competition task",
+				},
+				&igc.CRecordWaypoint{
+					Lat:  -(44 + 15173/6e4),
+					Lon:  -(6 + 4205/6e4),
+					Text: "T-296-Trainon",
+				},
+			},
+			expectedLines: []string{
+				"C0110231200370000000000-1 Competition task",
+				"C4415173S00604205WT-296-Trainon",
+			},
+		},
+		{
+			name: "d_record",
+			records: []igc.Record{
+				&igc.DRecord{
+					GPSQualifier:  igc.GPSQualifierDGPS,
+					DGPSStationID: 1234,
+				},
+			},
+			expectedLines: []string{
+				"D21234",
+			},
+		},
+		{
+			name: "e_f_g_records",
+			records: []igc.Record{
+				&igc.ERecord{
+					Time: time.Date(2024, time.June, 4, 10, 1, 53, 0, time.UTC),
+					TLC:  "PEV",
+				},
+				&igc.FRecord{
+					Time:         time.Date(2024, time.June, 4, 15, 3, 14, 0, time.UTC),
+					SatelliteIDs: []int{2, 28, 32},
+				},
+				&igc.GRecord{
+					Text: "7EEED180BADCA6F828AE4B69B0891F91",
+				},
+			},
+			expectedLines: []string{
+				"E100153PEV",
+				"F150314022832",
+				"G7EEED180BADCA6F828AE4B69B0891F91",
+			},
+		},
+		{
+			name: "h_records",
+			records: []igc.Record{
+				&igc.HFDTERecord{
+					HRecord: igc.HRecord{
+						Source:   igc.SourceFlightRecorder,
+						TLC:      "DTE",
+						LongName: "DATE",
+						Value:    "040624,01",
+					},
+				},
+				&igc.HRecord{
+					Source: igc.SourceFlightRecorder,
+					TLC:    "FXA",
+					Value:  "100",
+				},
+				&igc.HRecord{
+					Source:   igc.SourcePilot,
+					TLC:      "PLT",
+					LongName: "PILOTINCHARGE",
+					Value:    "Tom Payne",
+				},
+				&igc.HRecord{
+					Source:   igc.SourceFlightRecorder,
+					TLC:      "FRS",
+					LongName: "SECURITYOK",
+				},
+				&igc.HRecordWithInvalidSource{
+					Source:   "S",
+					TLC:      "CCL",
+					LongName: "COMPETITION CLASS",
+					Value:    "FAI-3 (PG)",
+				},
+			},
+			expectedLines: []string{
+				"HFDTEDATE:040624,01",
+				"HFFXA100",
+				"HPPLTPILOTINCHARGE:Tom Payne",
+				"HFFRSSECURITYOK",
+				"HSCCLCOMPETITION CLASS:FAI-3 (PG)",
+			},
+		},
+		{
+			name: "k_record",
+			records: []igc.Record{
+				&igc.JRecord{
+					Additions: []igc.RecordAddition{
+						{StartColumn: 8, FinishColumn: 10, TLC: "WDI"},
+						{StartColumn: 11, FinishColumn: 13, TLC: "WSP"},
+					},
+				},
+				&igc.KRecord{
+					Time: time.Date(2024, time.June, 4, 15, 4, 52, 0, time.UTC),
+					Additions: igc.NewAdditions(
+						[]igc.RecordAddition{
+							{StartColumn: 8, FinishColumn: 10, TLC: "WDI"},
+							{StartColumn: 11, FinishColumn: 13, TLC: "WSP"},
+						},
+						map[string]int{
+							"WDI": 276,
+							"WSP": 6,
+						},
+					),
+				},
+			},
+			expectedLines: []string{
+				"J020810WDI1113WSP",
+				"K150452276006",
+			},
+		},
+		{
+			name: "l_records",
+			records: []igc.Record{
+				&igc.LRecord{
+					Input: "XNA",
+					Text:  "::VEHICLE:1",
+				},
+				&igc.LRecordWithoutTLC{
+					Text: "CU::HPGTYGLIDERTYPE:SZD 55",
+				},
+			},
+			expectedLines: []string{
+				"LXNA::VEHICLE:1",
+				"LCU::HPGTYGLIDERTYPE:SZD 55",
+			},
+		},
+		{
+			name: "n_record",
+			records: []igc.Record{
+				&igc.MRecord{
+					Additions: []igc.RecordAddition{
+						{StartColumn: 8, FinishColumn: 10, TLC: "OAT"},
+					},
+				},
+				&igc.NRecord{
+					Time: time.Date(2024, time.June, 4, 15, 4, 52, 0, time.UTC),
+					Additions: igc.NewAdditions(
+						[]igc.RecordAddition{
+							{StartColumn: 8, FinishColumn: 10, TLC: "OAT"},
+						},
+						map[string]int{
+							"OAT": -5,
+						},
+					),
+				},
+			},
+			expectedLines: []string{
+				"M010810OAT",
+				"N150452-05",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buffer bytes.Buffer
+			encoder := igc.NewEncoder(&buffer)
+			var err error
+			for _, record := range tc.records {
+				if err = encoder.Encode(record); err != nil {
+					break
+				}
+			}
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, strings.Join(tc.expectedLines, "\r\n")+"\r\n", buffer.String())
+		})
+	}
+}
+
+func TestEncodeTestData(t *testing.T) {
+	t.Parallel()
+	dirEntries, err := os.ReadDir("testdata")
+	assert.NoError(t, err)
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		if strings.ToLower(filepath.Ext(dirEntry.Name())) != ".igc" {
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			data, err := os.ReadFile(filepath.Join("testdata", name))
+			assert.NoError(t, err)
+			expected, err := igc.Parse(bytes.NewReader(data), igc.WithAllowInvalidChars(true))
+			assert.NoError(t, err)
+			if len(expected.Errs) != 0 {
+				t.Skip("file contains errors")
+			}
+
+			var buffer bytes.Buffer
+			assert.NoError(t, expected.Write(&buffer))
+			actual, err := igc.Parse(&buffer, igc.WithAllowInvalidChars(true))
+			assert.NoError(t, err)
+			assert.Equal(t, 0, len(actual.Errs))
+			// Use reflect.DeepEqual as assert.Equal is slow for large values.
+			assert.True(t, reflect.DeepEqual(expected.Records, actual.Records))
+		})
+	}
+}
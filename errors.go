@@ -0,0 +1,290 @@
+package igc
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+)
+
+// An ErrorCode is a stable, machine-readable code identifying a class of parse
+// error.
+type ErrorCode string
+
+// Error codes.
+const (
+	ErrorCodeAdditionDecoder          ErrorCode = "addition-decoder"
+	ErrorCodeERecordWithoutTLC        ErrorCode = "e-record-without-tlc"
+	ErrorCodeHRecordWithInvalidSource ErrorCode = "h-record-with-invalid-source"
+	ErrorCodeInvalidAddition          ErrorCode = "invalid-addition"
+	ErrorCodeInvalidChar              ErrorCode = "invalid-char"
+	ErrorCodeInvalidRecord            ErrorCode = "invalid-record"
+	ErrorCodeInvalidUTF8              ErrorCode = "invalid-utf8"
+	ErrorCodeLRecordWithoutTLC        ErrorCode = "l-record-without-tlc"
+	ErrorCodeMissingAddition          ErrorCode = "missing-addition"
+	ErrorCodeNoDate                   ErrorCode = "no-date"
+	ErrorCodeSyntax                   ErrorCode = "syntax"
+	ErrorCodeTurnpointCount           ErrorCode = "turnpoint-count"
+	ErrorCodeUnknownRecordType        ErrorCode = "unknown-record-type"
+	ErrorCodeUnknown                  ErrorCode = "unknown"
+)
+
+// A Severity is the severity of a diagnostic.
+type Severity int
+
+// Severities.
+const (
+	SeverityIgnore  Severity = iota // Ignored diagnostics are never reported.
+	SeverityInfo                    // Info diagnostics are deviations from the IGC specification that do not affect the parsed data.
+	SeverityWarning                 // Warning diagnostics are cosmetic deviations from the IGC specification.
+	SeverityError                   // Error diagnostics are failures to parse data.
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityIgnore:
+		return "ignore"
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return "invalid severity"
+	}
+}
+
+// A Strictness is a preset of severities for all diagnostics.
+type Strictness int
+
+// Strictnesses.
+const (
+	StrictnessDefault Strictness = iota // Default treats invalid characters as warnings and non-standard records as info.
+	StrictnessLenient                   // Lenient ignores invalid characters and non-standard records and treats addition errors as warnings.
+	StrictnessStrict                    // Strict treats all diagnostics as errors.
+)
+
+// severities returns the severities of diagnostics for s.
+func (s Strictness) severities() map[ErrorCode]Severity {
+	switch s {
+	case StrictnessLenient:
+		return map[ErrorCode]Severity{
+			ErrorCodeERecordWithoutTLC:        SeverityIgnore,
+			ErrorCodeHRecordWithInvalidSource: SeverityIgnore,
+			ErrorCodeInvalidAddition:          SeverityWarning,
+			ErrorCodeInvalidChar:              SeverityIgnore,
+			ErrorCodeLRecordWithoutTLC:        SeverityIgnore,
+			ErrorCodeMissingAddition:          SeverityWarning,
+			ErrorCodeSyntax:                   SeverityWarning,
+		}
+	case StrictnessStrict:
+		return map[ErrorCode]Severity{}
+	default:
+		return map[ErrorCode]Severity{
+			ErrorCodeERecordWithoutTLC:        SeverityInfo,
+			ErrorCodeHRecordWithInvalidSource: SeverityInfo,
+			ErrorCodeInvalidChar:              SeverityWarning,
+			ErrorCodeLRecordWithoutTLC:        SeverityInfo,
+		}
+	}
+}
+
+// A sentinelError is an error with a fixed message and code.
+type sentinelError struct {
+	code    ErrorCode
+	message string
+}
+
+func (e *sentinelError) Code() ErrorCode { return e.code }
+func (e *sentinelError) Error() string   { return e.message }
+
+// Sentinel errors.
+var (
+	ErrERecordWithoutTLC        error = &sentinelError{code: ErrorCodeERecordWithoutTLC, message: "E record without TLC"}
+	ErrHRecordWithInvalidSource error = &sentinelError{code: ErrorCodeHRecordWithInvalidSource, message: "H record with invalid source"}
+	ErrInvalidUTF8Sequence      error = &sentinelError{code: ErrorCodeInvalidUTF8, message: "invalid UTF-8 sequence"}
+	ErrLRecordWithoutTLC        error = &sentinelError{code: ErrorCodeLRecordWithoutTLC, message: "L record without TLC"}
+	ErrNoDate                   error = &sentinelError{code: ErrorCodeNoDate, message: "no date"}
+)
+
+// An AdditionDecoderError is an error returned by an AdditionDecoder.
+type AdditionDecoderError struct {
+	RecordType byte
+	Addition   RecordAddition
+	Err        error
+}
+
+func (e *AdditionDecoderError) Code() ErrorCode { return ErrorCodeAdditionDecoder }
+
+func (e *AdditionDecoderError) Error() string {
+	return e.Addition.TLC + ": " + e.Err.Error()
+}
+
+func (e *AdditionDecoderError) Unwrap() error {
+	return e.Err
+}
+
+// An InvalidAdditionError is an invalid addition in an I, J, or M record.
+type InvalidAdditionError struct {
+	RecordType byte
+	Addition   RecordAddition
+	Message    string
+}
+
+func (e *InvalidAdditionError) Code() ErrorCode { return ErrorCodeInvalidAddition }
+
+func (e *InvalidAdditionError) Error() string {
+	return e.Addition.TLC + ": " + e.Message
+}
+
+// An InvalidCharError is an invalid character. See section A7 of the IGC
+// specification.
+type InvalidCharError struct {
+	Char   byte
+	Column int
+}
+
+func (e *InvalidCharError) Code() ErrorCode { return ErrorCodeInvalidChar }
+
+func (e *InvalidCharError) Error() string {
+	if '\x20' <= e.Char && e.Char <= '\x7f' {
+		return fmt.Sprintf("'%c': invalid character", e.Char)
+	}
+	return fmt.Sprintf("'\\x%02x': invalid character", e.Char)
+}
+
+// An InvalidRecordError is a record that could not be parsed. Err, if not nil,
+// is the underlying error.
+type InvalidRecordError struct {
+	RecordType byte
+	Err        error
+}
+
+func (e *InvalidRecordError) Code() ErrorCode { return ErrorCodeInvalidRecord }
+
+func (e *InvalidRecordError) Error() string {
+	return "invalid " + string(e.RecordType) + " record"
+}
+
+func (e *InvalidRecordError) Unwrap() error {
+	return e.Err
+}
+
+// A MissingAdditionError is an addition that is missing from a B, K, or N
+// record because the record is too short.
+type MissingAdditionError struct {
+	RecordType byte
+	Addition   RecordAddition
+}
+
+func (e *MissingAdditionError) Code() ErrorCode { return ErrorCodeMissingAddition }
+
+func (e *MissingAdditionError) Error() string {
+	return "missing " + e.Addition.TLC + " addition"
+}
+
+// A SyntaxError is a value that could not be parsed as an integer. If the
+// value is an addition to a B, K, or N record then RecordType, TLC,
+// StartColumn, and FinishColumn identify the addition.
+type SyntaxError struct {
+	Value        string
+	RecordType   byte
+	TLC          string
+	StartColumn  int
+	FinishColumn int
+}
+
+func (e *SyntaxError) Code() ErrorCode { return ErrorCodeSyntax }
+
+func (e *SyntaxError) Error() string {
+	return strconv.Quote(e.Value) + ": syntax error"
+}
+
+// A TurnpointCountError is a mismatch between the number of turnpoints in a
+// task declaration and the number of turnpoint C records.
+type TurnpointCountError struct {
+	Declared int
+	Actual   int
+}
+
+func (e *TurnpointCountError) Code() ErrorCode { return ErrorCodeTurnpointCount }
+
+func (e *TurnpointCountError) Error() string {
+	return fmt.Sprintf("declared %d turnpoints, got %d", e.Declared, e.Actual)
+}
+
+// An UnknownRecordTypeError is a record with an unknown type.
+type UnknownRecordTypeError struct {
+	RecordType byte
+}
+
+func (e *UnknownRecordTypeError) Code() ErrorCode { return ErrorCodeUnknownRecordType }
+
+func (e *UnknownRecordTypeError) Error() string {
+	if unicode.IsPrint(rune(e.RecordType)) {
+		return string(e.RecordType) + ": unknown record type"
+	}
+	return fmt.Sprintf(`"\x%02X": unknown record type`, e.RecordType)
+}
+
+// ErrorCodes returns the codes of all errors in err's tree, in depth-first
+// order. Errors in the tree without a code are ignored.
+func ErrorCodes(err error) []ErrorCode {
+	var codes []ErrorCode
+	walkErrors(err, func(err error) bool {
+		if coder, ok := err.(interface{ Code() ErrorCode }); ok { //nolint:errorlint
+			codes = append(codes, coder.Code())
+			return false
+		}
+		return true
+	})
+	return codes
+}
+
+// Code returns the code of the first error in e, or ErrorCodeUnknown if e does
+// not contain any errors with a code.
+func (e *Error) Code() ErrorCode {
+	if codes := ErrorCodes(e.Err); len(codes) > 0 {
+		return codes[0]
+	}
+	return ErrorCodeUnknown
+}
+
+// errorColumn returns the 1-based column of the first error in err's tree that
+// has a column, or zero if there is no such error.
+func errorColumn(err error) int {
+	column := 0
+	walkErrors(err, func(err error) bool {
+		if column != 0 {
+			return false
+		}
+		switch err := err.(type) { //nolint:errorlint
+		case *AdditionDecoderError:
+			column = err.Addition.StartColumn
+		case *InvalidCharError:
+			column = err.Column
+		case *MissingAdditionError:
+			column = err.Addition.StartColumn
+		case *SyntaxError:
+			column = err.StartColumn
+		}
+		return column == 0
+	})
+	return column
+}
+
+// walkErrors calls f for each error in err's tree in depth-first order. If f
+// returns false then the errors wrapped by err are not walked.
+func walkErrors(err error, f func(error) bool) {
+	if err == nil || !f(err) {
+		return
+	}
+	switch err := err.(type) { //nolint:errorlint
+	case interface{ Unwrap() error }:
+		walkErrors(err.Unwrap(), f)
+	case interface{ Unwrap() []error }:
+		for _, err := range err.Unwrap() {
+			walkErrors(err, f)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package igc_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-igc"
+)
+
+func TestErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		lines          []string
+		options        []igc.ParseOption
+		expectedCode   igc.ErrorCode
+		expectedCodes  []igc.ErrorCode
+		expectedColumn int
+		check          func(*testing.T, error)
+	}{
+		{
+			name:          "invalid_record",
+			lines:         []string{"F"},
+			expectedCode:  igc.ErrorCodeInvalidRecord,
+			expectedCodes: []igc.ErrorCode{igc.ErrorCodeInvalidRecord},
+			check: func(t *testing.T, err error) {
+				t.Helper()
+				var invalidRecordError *igc.InvalidRecordError
+				assert.True(t, errors.As(err, &invalidRecordError))
+				assert.Equal(t, 'F', invalidRecordError.RecordType)
+			},
+		},
+		{
+			name:  "invalid_record_with_cause",
+			lines: []string{"HFPLTPILOT:\xff"},
+			options: []igc.ParseOption{
+				igc.WithAllowInvalidChars(true),
+			},
+			expectedCode:  igc.ErrorCodeInvalidRecord,
+			expectedCodes: []igc.ErrorCode{igc.ErrorCodeInvalidRecord},
+			check: func(t *testing.T, err error) {
+				t.Helper()
+				assert.True(t, errors.Is(err, igc.ErrInvalidUTF8Sequence))
+			},
+		},
+		{
+			name:          "unknown_record_type_and_invalid_char",
+			lines:         []string{"X~"},
+			expectedCode:  igc.ErrorCodeUnknownRecordType,
+			expectedCodes: []igc.ErrorCode{igc.ErrorCodeUnknownRecordType, igc.ErrorCodeInvalidChar},
+			check: func(t *testing.T, err error) {
+				t.Helper()
+				var unknownRecordTypeError *igc.UnknownRecordTypeError
+				assert.True(t, errors.As(err, &unknownRecordTypeError))
+				assert.Equal(t, 'X', unknownRecordTypeError.RecordType)
+				var invalidCharError *igc.InvalidCharError
+				assert.True(t, errors.As(err, &invalidCharError))
+				assert.Equal(t, &igc.InvalidCharError{Char: '~', Column: 2}, invalidCharError)
+			},
+			expectedColumn: 2,
+		},
+		{
+			name:          "no_date",
+			lines:         []string{"E100153PEV"},
+			expectedCode:  igc.ErrorCodeNoDate,
+			expectedCodes: []igc.ErrorCode{igc.ErrorCodeNoDate},
+			check: func(t *testing.T, err error) {
+				t.Helper()
+				assert.True(t, errors.Is(err, igc.ErrNoDate))
+			},
+		},
+		{
+			name: "invalid_addition",
+			lines: []string{
+				"I023636AAA3736BBB",
+			},
+			expectedCode:  igc.ErrorCodeInvalidAddition,
+			expectedCodes: []igc.ErrorCode{igc.ErrorCodeInvalidAddition},
+			check: func(t *testing.T, err error) {
+				t.Helper()
+				var invalidAdditionError *igc.InvalidAdditionError
+				assert.True(t, errors.As(err, &invalidAdditionError))
+				assert.Equal(t, &igc.InvalidAdditionError{
+					RecordType: 'I',
+					Addition:   igc.RecordAddition{TLC: "BBB", StartColumn: 37, FinishColumn: 36},
+					Message:    "invalid finish column",
+				}, invalidAdditionError)
+			},
+		},
+		{
+			name: "missing_and_syntax_error_additions",
+			lines: []string{
+				"HFDTE020508",
+				"I033638FXA3940SIU4143ENL",
+				"B1005364607690N00610358EA00000012650X512",
+			},
+			expectedCode:   igc.ErrorCodeSyntax,
+			expectedCodes:  []igc.ErrorCode{igc.ErrorCodeSyntax, igc.ErrorCodeMissingAddition},
+			expectedColumn: 36,
+			check: func(t *testing.T, err error) {
+				t.Helper()
+				var syntaxError *igc.SyntaxError
+				assert.True(t, errors.As(err, &syntaxError))
+				assert.Equal(t, &igc.SyntaxError{
+					Value:        "0X5",
+					RecordType:   'B',
+					TLC:          "FXA",
+					StartColumn:  36,
+					FinishColumn: 38,
+				}, syntaxError)
+				var missingAdditionError *igc.MissingAdditionError
+				assert.True(t, errors.As(err, &missingAdditionError))
+				assert.Equal(t, &igc.MissingAdditionError{
+					RecordType: 'B',
+					Addition:   igc.RecordAddition{TLC: "ENL", StartColumn: 41, FinishColumn: 43},
+				}, missingAdditionError)
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := igc.ParseLines(tc.lines, tc.options...)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, len(actual.Errs))
+			var igcError *igc.Error
+			assert.True(t, errors.As(actual.Errs[0], &igcError))
+			assert.Equal(t, tc.expectedCode, igcError.Code())
+			assert.Equal(t, tc.expectedCodes, igc.ErrorCodes(igcError.Err))
+			assert.Equal(t, tc.expectedColumn, igcError.Column)
+			tc.check(t, actual.Errs[0])
+		})
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	lines := []string{
+		"HFDTE020508",
+		"HXPLTPILOT:Test",
+		"Ltext",
+		"X~",
+		"B1005364607690N00610358EA0000001265",
+		"E100536",
+	}
+	for _, tc := range []struct {
+		name               string
+		options            []igc.ParseOption
+		expectedSeverities map[int]igc.Severity
+		expectedCodes      map[int][]igc.ErrorCode
+	}{
+		{
+			name: "default",
+			expectedSeverities: map[int]igc.Severity{
+				4: igc.SeverityError,
+			},
+			expectedCodes: map[int][]igc.ErrorCode{
+				4: {igc.ErrorCodeUnknownRecordType, igc.ErrorCodeInvalidChar},
+			},
+		},
+		{
+			name: "min_severity_info",
+			options: []igc.ParseOption{
+				igc.WithMinSeverity(igc.SeverityInfo),
+			},
+			expectedSeverities: map[int]igc.Severity{
+				2: igc.SeverityInfo,
+				3: igc.SeverityInfo,
+				4: igc.SeverityError,
+				6: igc.SeverityInfo,
+			},
+			expectedCodes: map[int][]igc.ErrorCode{
+				2: {igc.ErrorCodeHRecordWithInvalidSource},
+				3: {igc.ErrorCodeLRecordWithoutTLC},
+				4: {igc.ErrorCodeUnknownRecordType, igc.ErrorCodeInvalidChar},
+				6: {igc.ErrorCodeERecordWithoutTLC},
+			},
+		},
+		{
+			name: "lenient",
+			options: []igc.ParseOption{
+				igc.WithStrictness(igc.StrictnessLenient),
+				igc.WithSeverity(igc.ErrorCodeUnknownRecordType, igc.SeverityWarning),
+			},
+			expectedSeverities: map[int]igc.Severity{
+				4: igc.SeverityWarning,
+			},
+			expectedCodes: map[int][]igc.ErrorCode{
+				4: {igc.ErrorCodeUnknownRecordType},
+			},
+		},
+		{
+			name: "strict",
+			options: []igc.ParseOption{
+				igc.WithStrictness(igc.StrictnessStrict),
+			},
+			expectedSeverities: map[int]igc.Severity{
+				2: igc.SeverityError,
+				3: igc.SeverityError,
+				4: igc.SeverityError,
+				6: igc.SeverityError,
+			},
+			expectedCodes: map[int][]igc.ErrorCode{
+				2: {igc.ErrorCodeHRecordWithInvalidSource},
+				3: {igc.ErrorCodeLRecordWithoutTLC},
+				4: {igc.ErrorCodeUnknownRecordType, igc.ErrorCodeInvalidChar},
+				6: {igc.ErrorCodeERecordWithoutTLC},
+			},
+		},
+		{
+			name: "ignore_unknown_record_type",
+			options: []igc.ParseOption{
+				igc.WithSeverity(igc.ErrorCodeUnknownRecordType, igc.SeverityIgnore),
+			},
+			expectedSeverities: map[int]igc.Severity{
+				4: igc.SeverityWarning,
+			},
+			expectedCodes: map[int][]igc.ErrorCode{
+				4: {igc.ErrorCodeInvalidChar},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := igc.ParseLines(lines, tc.options...)
+			assert.NoError(t, err)
+			actualSeverities := make(map[int]igc.Severity)
+			actualCodes := make(map[int][]igc.ErrorCode)
+			for _, err := range actual.Errs {
+				var igcError *igc.Error
+				assert.True(t, errors.As(err, &igcError))
+				actualSeverities[igcError.Line] = igcError.Severity
+				actualCodes[igcError.Line] = igc.ErrorCodes(igcError.Err)
+			}
+			assert.Equal(t, tc.expectedSeverities, actualSeverities)
+			assert.Equal(t, tc.expectedCodes, actualCodes)
+			assert.Equal(t, 6, len(actual.Records))
+		})
+	}
+}
+
+func TestSeverityDefault(t *testing.T) {
+	// Non-standard E, H, and L records are parsed and their diagnostics are
+	// info, which are not reported by default.
+	lines := []string{
+		"HFDTE020508",
+		"HXPLTPILOT:Test",
+		"Ltext",
+		"E100536",
+	}
+	actual, err := igc.ParseLines(lines)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(actual.Errs))
+	assert.Equal(t, 4, len(actual.Records))
+	assert.Equal(t, []igc.Record{
+		&igc.HRecordWithInvalidSource{Source: "X", TLC: "PLT", LongName: "PILOT", Value: "Test"},
+		&igc.LRecordWithoutTLC{Text: "text"},
+		&igc.ERecordWithoutTLC{Time: time.Date(2008, 5, 2, 10, 5, 36, 0, time.UTC)},
+	}, actual.Records[1:])
+
+	actual, err = igc.ParseLines(lines, igc.WithMinSeverity(igc.SeverityInfo))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(actual.Errs))
+	for _, err := range actual.Errs {
+		var igcError *igc.Error
+		assert.True(t, errors.As(err, &igcError))
+		assert.Equal(t, igc.SeverityInfo, igcError.Severity)
+	}
+}
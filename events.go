@@ -0,0 +1,204 @@
+package igc
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// An EventCode is the three-letter code of an event in an E record. See
+// section A7 of the IGC specification.
+type EventCode string
+
+// Event codes.
+const (
+	EventCodeNone                  EventCode = ""    // None is the code of E records without a three-letter code.
+	EventCodeAltimeterSetting      EventCode = "ATS" // AltimeterSetting is a change of the altimeter pressure setting (QNH).
+	EventCodeBlindFlyingOn         EventCode = "BFI" // BlindFlyingOn is the start of use of a blind flying instrument.
+	EventCodeBlindFlyingOff        EventCode = "BFO" // BlindFlyingOff is the end of use of a blind flying instrument.
+	EventCodeCameraConnect         EventCode = "CCN" // CameraConnect is a camera being connected.
+	EventCodeCameraDisconnect      EventCode = "CDC" // CameraDisconnect is a camera being disconnected.
+	EventCodeChangeGeodeticDatum   EventCode = "CGD" // ChangeGeodeticDatum is a change of geodetic datum.
+	EventCodeEngineDown            EventCode = "EDN" // EngineDown is the engine being retracted.
+	EventCodeEngineOff             EventCode = "EOF" // EngineOff is the engine being stopped.
+	EventCodeEngineOn              EventCode = "EON" // EngineOn is the engine being started.
+	EventCodeEngineUp              EventCode = "EUP" // EngineUp is the engine being extended.
+	EventCodeFinish                EventCode = "FIN" // Finish is crossing the finish line.
+	EventCodeLowVoltage            EventCode = "LOV" // LowVoltage is the flight recorder's supply voltage being low.
+	EventCodeMacCready             EventCode = "MAC" // MacCready is a change of MacCready setting.
+	EventCodeOff                   EventCode = "OFF" // Off is the engine being stopped, written by some flight recorders instead of EOF.
+	EventCodeOnTask                EventCode = "ONT" // OnTask is the start of an attempt at the task.
+	EventCodePilotEvent            EventCode = "PEV" // PilotEvent is an event marked by the pilot.
+	EventCodePostFlightClaim       EventCode = "PFC" // PostFlightClaim is a post-flight claim.
+	EventCodeStart                 EventCode = "STA" // Start is crossing the start line.
+	EventCodeTurnpointConfirmation EventCode = "TPC" // TurnpointConfirmation is the confirmation of a turnpoint.
+	EventCodeUndercarriage         EventCode = "UND" // Undercarriage is a change of undercarriage position.
+)
+
+// An Event is an event from an E record, with a typed value parsed from its
+// text where the event code defines one.
+type Event struct {
+	Line   int // Line is the 1-based line number, or zero if unknown.
+	Time   time.Time
+	Code   EventCode
+	Text   string
+	Value  any    // Value is the typed value of the event, or nil if there is none.
+	Record Record // Record is the *ERecord or *ERecordWithoutTLC.
+}
+
+// An AltimeterSetting is the value of an ATS event.
+type AltimeterSetting struct {
+	QNH float64 // QNH is the altimeter pressure setting in hPa.
+}
+
+// A BlindFlying is the value of BFI and BFO events.
+type BlindFlying struct {
+	On bool
+}
+
+// A GeodeticDatumChange is the value of a CGD event.
+type GeodeticDatumChange struct {
+	Datum string
+}
+
+// An Engine is the value of EON, EOF, and OFF events.
+type Engine struct {
+	On bool
+}
+
+// A TurnpointConfirmation is the value of a TPC event.
+type TurnpointConfirmation struct {
+	Name string // Name is the name of the turnpoint, if any.
+}
+
+// NewEvent returns a new Event from record, or nil if record is not an E
+// record.
+//
+// E records without a three-letter code are common in real files. Those of
+// the form "Waypoint NAME reached" are returned as turnpoint confirmations.
+// Others are returned with EventCodeNone.
+func NewEvent(record Record) *Event {
+	var event *Event
+	switch record := record.(type) {
+	case *ERecord:
+		if record == nil {
+			return nil
+		}
+		event = &Event{
+			Time:   record.Time,
+			Code:   EventCode(record.TLC),
+			Text:   record.Text,
+			Record: record,
+		}
+	case *ERecordWithoutTLC:
+		if record == nil {
+			return nil
+		}
+		event = &Event{
+			Time:   record.Time,
+			Text:   record.Text,
+			Record: record,
+		}
+		if name, ok := waypointReached(record.Text); ok {
+			event.Code = EventCodeTurnpointConfirmation
+			event.Text = name
+		}
+	default:
+		return nil
+	}
+	event.Value = parseEventValue(event.Code, event.Text)
+	return event
+}
+
+// Events returns the events in igc, in order.
+func (igc *IGC) Events() []*Event {
+	var events []*Event
+	for _, line := range igc.Lines {
+		if event := NewEvent(line.Record); event != nil {
+			event.Line = line.Number
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// EventsByCode returns the events in igc with any of codes, in order.
+func (igc *IGC) EventsByCode(codes ...EventCode) []*Event {
+	var events []*Event
+	for _, event := range igc.Events() {
+		for _, code := range codes {
+			if event.Code == code {
+				events = append(events, event)
+				break
+			}
+		}
+	}
+	return events
+}
+
+// parseEventValue returns the typed value of an event with code and text, or
+// nil if there is none or text cannot be parsed.
+func parseEventValue(code EventCode, text string) any {
+	switch code {
+	case EventCodeAltimeterSetting:
+		qnh, ok := parseAltimeterSetting(text)
+		if !ok {
+			return nil
+		}
+		return AltimeterSetting{QNH: qnh}
+	case EventCodeBlindFlyingOn, EventCodeBlindFlyingOff:
+		// Some flight recorders write BFI events with the state of the
+		// instrument, for example "BFIOFF AH".
+		switch fields := strings.Fields(text); {
+		case len(fields) > 0 && fields[0] == "ON":
+			return BlindFlying{On: true}
+		case len(fields) > 0 && fields[0] == "OFF":
+			return BlindFlying{On: false}
+		default:
+			return BlindFlying{On: code == EventCodeBlindFlyingOn}
+		}
+	case EventCodeChangeGeodeticDatum:
+		return GeodeticDatumChange{Datum: strings.TrimSpace(text)}
+	case EventCodeEngineOn:
+		return Engine{On: true}
+	case EventCodeEngineOff, EventCodeOff:
+		return Engine{On: false}
+	case EventCodeTurnpointConfirmation:
+		if name, ok := waypointReached(text); ok {
+			return TurnpointConfirmation{Name: name}
+		}
+		return TurnpointConfirmation{Name: strings.TrimSpace(text)}
+	default:
+		return nil
+	}
+}
+
+// waypointReached returns the name of the turnpoint in text of the form
+// "Waypoint NAME reached", and whether text has that form.
+func waypointReached(text string) (string, bool) {
+	name, ok := strings.CutPrefix(strings.TrimSpace(text), "Waypoint ")
+	if !ok {
+		return "", false
+	}
+	return strings.CutSuffix(name, " reached")
+}
+
+// parseAltimeterSetting parses an altimeter pressure setting in hPa. The IGC
+// specification records it in hundredths of hPa, for example "101325", but
+// some flight recorders record it in hPa with a decimal point, for example
+// "1013.2".
+func parseAltimeterSetting(text string) (float64, bool) {
+	text = strings.TrimSpace(text)
+	if strings.Contains(text, ".") {
+		qnh, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return 0, false
+		}
+		return qnh, true
+	}
+	value, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, false
+	}
+	return float64(value) / 100, true
+}
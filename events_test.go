@@ -0,0 +1,111 @@
+package igc_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-igc"
+)
+
+func TestEvents(t *testing.T) {
+	igcFile, err := igc.ParseLines([]string{
+		"HFDTE020508",
+		"E100000ATS101325",
+		"E100001ATS1029.8",
+		"E100002PEVBackground",
+		"E100003STA",
+		"E100004BFI",
+		"E100005BFO",
+		"E100006CGD WGS84",
+		"E100007EON",
+		"E100008OFF",
+		"E100009TPC",
+		"E100010Waypoint take off reached",
+		"E100011Switched location to Internal",
+		"E100012ATSNaN",
+		"E100013BFION AH",
+		"E100014BFIOFF AH",
+		"E100015TPCWaypoint S06161 reached",
+	})
+	assert.NoError(t, err)
+
+	date := time.Date(2008, time.May, 2, 10, 0, 0, 0, time.UTC)
+	events := igcFile.Events()
+	for _, event := range events {
+		event.Record = nil
+	}
+	assert.Equal(t, []*igc.Event{
+		{Line: 2, Time: date, Code: igc.EventCodeAltimeterSetting, Text: "101325", Value: igc.AltimeterSetting{QNH: 1013.25}},
+		{Line: 3, Time: date.Add(1 * time.Second), Code: igc.EventCodeAltimeterSetting, Text: "1029.8", Value: igc.AltimeterSetting{QNH: 1029.8}},
+		{Line: 4, Time: date.Add(2 * time.Second), Code: igc.EventCodePilotEvent, Text: "Background"},
+		{Line: 5, Time: date.Add(3 * time.Second), Code: igc.EventCodeStart},
+		{Line: 6, Time: date.Add(4 * time.Second), Code: igc.EventCodeBlindFlyingOn, Value: igc.BlindFlying{On: true}},
+		{Line: 7, Time: date.Add(5 * time.Second), Code: igc.EventCodeBlindFlyingOff, Value: igc.BlindFlying{On: false}},
+		{Line: 8, Time: date.Add(6 * time.Second), Code: igc.EventCodeChangeGeodeticDatum, Text: " WGS84", Value: igc.GeodeticDatumChange{Datum: "WGS84"}},
+		{Line: 9, Time: date.Add(7 * time.Second), Code: igc.EventCodeEngineOn, Value: igc.Engine{On: true}},
+		{Line: 10, Time: date.Add(8 * time.Second), Code: igc.EventCodeOff, Value: igc.Engine{On: false}},
+		{Line: 11, Time: date.Add(9 * time.Second), Code: igc.EventCodeTurnpointConfirmation, Value: igc.TurnpointConfirmation{}},
+		{Line: 12, Time: date.Add(10 * time.Second), Code: igc.EventCodeTurnpointConfirmation, Text: "take off", Value: igc.TurnpointConfirmation{Name: "take off"}},
+		{Line: 13, Time: date.Add(11 * time.Second), Code: igc.EventCodeNone, Text: "Switched location to Internal"},
+		{Line: 14, Time: date.Add(12 * time.Second), Code: igc.EventCodeAltimeterSetting, Text: "NaN"},
+		{Line: 15, Time: date.Add(13 * time.Second), Code: igc.EventCodeBlindFlyingOn, Text: "ON AH", Value: igc.BlindFlying{On: true}},
+		{Line: 16, Time: date.Add(14 * time.Second), Code: igc.EventCodeBlindFlyingOn, Text: "OFF AH", Value: igc.BlindFlying{On: false}},
+		{Line: 17, Time: date.Add(15 * time.Second), Code: igc.EventCodeTurnpointConfirmation, Text: "Waypoint S06161 reached", Value: igc.TurnpointConfirmation{Name: "S06161"}},
+	}, events)
+
+	turnpointConfirmations := igcFile.EventsByCode(igc.EventCodeTurnpointConfirmation)
+	assert.Equal(t, 3, len(turnpointConfirmations))
+	assert.Equal(t, 11, turnpointConfirmations[0].Line)
+	assert.Equal(t, 12, turnpointConfirmations[1].Line)
+
+	engineEvents := igcFile.EventsByCode(igc.EventCodeEngineOn, igc.EventCodeEngineOff, igc.EventCodeOff)
+	assert.Equal(t, 2, len(engineEvents))
+}
+
+func TestEventsFiles(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		code     igc.EventCode
+		expected []any
+	}{
+		{
+			name: "45bvafx1.igc",
+			code: igc.EventCodeBlindFlyingOn,
+			expected: []any{
+				igc.BlindFlying{On: true},
+				igc.BlindFlying{On: false},
+			},
+		},
+		{
+			name: "2025-05-31-XFH-000-01.IGC",
+			code: igc.EventCodeTurnpointConfirmation,
+			expected: []any{
+				igc.TurnpointConfirmation{Name: "S06161"},
+				igc.TurnpointConfirmation{Name: "B43585"},
+				igc.TurnpointConfirmation{Name: "B43585"},
+				igc.TurnpointConfirmation{Name: "B25172"},
+				igc.TurnpointConfirmation{Name: "B05188"},
+				igc.TurnpointConfirmation{Name: "B21156"},
+				igc.TurnpointConfirmation{Name: "B17141"},
+				igc.TurnpointConfirmation{Name: "L02087"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			file, err := os.Open("testdata/" + tc.name)
+			assert.NoError(t, err)
+			defer file.Close()
+			igcFile, err := igc.Parse(file)
+			assert.NoError(t, err)
+
+			var values []any
+			for _, event := range igcFile.EventsByCode(tc.code) {
+				values = append(values, event.Value)
+			}
+			assert.Equal(t, tc.expected, values)
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package geo
+
+import (
+	"math"
+)
+
+const (
+	vincentyMaxIterations = 200
+	vincentyTolerance     = 1e-12
+)
+
+// An Ellipsoid is an ellipsoidal model of the Earth. Distances, bearings, and
+// destinations are calculated with Vincenty's formulae, which are accurate to
+// within 0.5 mm. For nearly antipodal points, where Vincenty's inverse formula
+// does not converge, the calculation falls back to a sphere with the mean
+// radius of the ellipsoid.
+type Ellipsoid struct {
+	A float64 // A is the semi-major axis in meters.
+	F float64 // F is the flattening.
+}
+
+// Distance returns the geodesic distance between p1 and p2.
+func (e Ellipsoid) Distance(p1, p2 Point) float64 {
+	distance, _, _ := e.Inverse(p1, p2)
+	return distance
+}
+
+// InitialBearing returns the initial bearing of the geodesic from p1 to p2.
+func (e Ellipsoid) InitialBearing(p1, p2 Point) float64 {
+	_, initialBearing, _ := e.Inverse(p1, p2)
+	return initialBearing
+}
+
+// FinalBearing returns the final bearing of the geodesic from p1 to p2.
+func (e Ellipsoid) FinalBearing(p1, p2 Point) float64 {
+	_, _, finalBearing := e.Inverse(p1, p2)
+	return finalBearing
+}
+
+// Destination returns the point reached by traveling distance along the
+// geodesic from p with initial bearing.
+func (e Ellipsoid) Destination(p Point, bearing, distance float64) Point {
+	a, f := e.A, e.F
+	b := a * (1 - f)
+	alpha1 := Radians(bearing)
+	sinAlpha1, cosAlpha1 := math.Sincos(alpha1)
+	tanU1 := (1 - f) * math.Tan(Radians(p.Lat))
+	cosU1 := 1 / math.Sqrt(1+tanU1*tanU1)
+	sinU1 := tanU1 * cosU1
+	sigma1 := math.Atan2(tanU1, cosAlpha1)
+	sinAlpha := cosU1 * sinAlpha1
+	cosSqAlpha := 1 - sinAlpha*sinAlpha
+	uSq := cosSqAlpha * (a*a - b*b) / (b * b)
+	bigA := 1 + uSq/16384*(4096+uSq*(-768+uSq*(320-175*uSq)))
+	bigB := uSq / 1024 * (256 + uSq*(-128+uSq*(74-47*uSq)))
+
+	sigma := distance / (b * bigA)
+	var sinSigma, cosSigma, cos2SigmaM float64
+	for range vincentyMaxIterations {
+		cos2SigmaM = math.Cos(2*sigma1 + sigma)
+		sinSigma, cosSigma = math.Sincos(sigma)
+		deltaSigma := bigB * sinSigma * (cos2SigmaM + bigB/4*(cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)-
+			bigB/6*cos2SigmaM*(-3+4*sinSigma*sinSigma)*(-3+4*cos2SigmaM*cos2SigmaM)))
+		prevSigma := sigma
+		sigma = distance/(b*bigA) + deltaSigma
+		if math.Abs(sigma-prevSigma) <= vincentyTolerance {
+			break
+		}
+	}
+	cos2SigmaM = math.Cos(2*sigma1 + sigma)
+	sinSigma, cosSigma = math.Sincos(sigma)
+
+	x := sinU1*sinSigma - cosU1*cosSigma*cosAlpha1
+	lat2 := math.Atan2(sinU1*cosSigma+cosU1*sinSigma*cosAlpha1, (1-f)*math.Sqrt(sinAlpha*sinAlpha+x*x))
+	lambda := math.Atan2(sinSigma*sinAlpha1, cosU1*cosSigma-sinU1*sinSigma*cosAlpha1)
+	c := f / 16 * cosSqAlpha * (4 + f*(4-3*cosSqAlpha))
+	l := lambda - (1-c)*f*sinAlpha*(sigma+c*sinSigma*(cos2SigmaM+c*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+	return Point{
+		Lat: Degrees(lat2),
+		Lon: normalizeLon(p.Lon + Degrees(l)),
+	}
+}
+
+// meanRadius returns the mean radius of e.
+func (e Ellipsoid) meanRadius() float64 {
+	return e.A * (3 - e.F) / 3
+}
+
+// Inverse returns the geodesic distance between p1 and p2 and the initial and
+// final bearings of the geodesic, calculated with Vincenty's inverse formula.
+func (e Ellipsoid) Inverse(p1, p2 Point) (float64, float64, float64) {
+	a, f := e.A, e.F
+	b := a * (1 - f)
+	l := Radians(p2.Lon - p1.Lon)
+	tanU1 := (1 - f) * math.Tan(Radians(p1.Lat))
+	cosU1 := 1 / math.Sqrt(1+tanU1*tanU1)
+	sinU1 := tanU1 * cosU1
+	tanU2 := (1 - f) * math.Tan(Radians(p2.Lat))
+	cosU2 := 1 / math.Sqrt(1+tanU2*tanU2)
+	sinU2 := tanU2 * cosU2
+
+	lambda := l
+	var sinLambda, cosLambda, sinSigma, cosSigma, sigma, cosSqAlpha, cos2SigmaM float64
+	converged := false
+	for range vincentyMaxIterations {
+		sinLambda, cosLambda = math.Sincos(lambda)
+		sinSqSigma := (cosU2*sinLambda)*(cosU2*sinLambda) +
+			(cosU1*sinU2-sinU1*cosU2*cosLambda)*(cosU1*sinU2-sinU1*cosU2*cosLambda)
+		if sinSqSigma == 0 {
+			// The points are coincident.
+			return 0, 0, 0
+		}
+		sinSigma = math.Sqrt(sinSqSigma)
+		cosSigma = sinU1*sinU2 + cosU1*cosU2*cosLambda
+		sigma = math.Atan2(sinSigma, cosSigma)
+		sinAlpha := cosU1 * cosU2 * sinLambda / sinSigma
+		cosSqAlpha = 1 - sinAlpha*sinAlpha
+		if cosSqAlpha != 0 {
+			cos2SigmaM = cosSigma - 2*sinU1*sinU2/cosSqAlpha
+		} else {
+			// The points are on the equator.
+			cos2SigmaM = 0
+		}
+		c := f / 16 * cosSqAlpha * (4 + f*(4-3*cosSqAlpha))
+		prevLambda := lambda
+		lambda = l + (1-c)*f*sinAlpha*(sigma+c*sinSigma*(cos2SigmaM+c*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+		if math.Abs(lambda-prevLambda) <= vincentyTolerance {
+			converged = true
+			break
+		}
+	}
+	if !converged || math.Abs(lambda) > math.Pi {
+		sphere := Sphere{Radius: e.meanRadius()}
+		return sphere.Distance(p1, p2), sphere.InitialBearing(p1, p2), sphere.FinalBearing(p1, p2)
+	}
+
+	uSq := cosSqAlpha * (a*a - b*b) / (b * b)
+	bigA := 1 + uSq/16384*(4096+uSq*(-768+uSq*(320-175*uSq)))
+	bigB := uSq / 1024 * (256 + uSq*(-128+uSq*(74-47*uSq)))
+	deltaSigma := bigB * sinSigma * (cos2SigmaM + bigB/4*(cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)-
+		bigB/6*cos2SigmaM*(-3+4*sinSigma*sinSigma)*(-3+4*cos2SigmaM*cos2SigmaM)))
+	distance := b * bigA * (sigma - deltaSigma)
+	initialBearing := math.Atan2(cosU2*sinLambda, cosU1*sinU2-sinU1*cosU2*cosLambda)
+	finalBearing := math.Atan2(cosU1*sinLambda, -sinU1*cosU2+cosU1*sinU2*cosLambda)
+	return distance, normalizeBearing(Degrees(initialBearing)), normalizeBearing(Degrees(finalBearing))
+}
@@ -0,0 +1,82 @@
+// Package geo implements geodesic calculations on the FAI sphere and the WGS84
+// ellipsoid.
+//
+// All latitudes, longitudes, and bearings are in degrees and all distances are
+// in meters. Bearings are clockwise from true north in the range [0, 360).
+package geo
+
+import (
+	"math"
+)
+
+// A Point is a point on the surface of the Earth.
+type Point struct {
+	Lat float64
+	Lon float64
+}
+
+// An Earth is a model of the Earth.
+type Earth interface {
+	// Distance returns the distance between p1 and p2.
+	Distance(p1, p2 Point) float64
+	// InitialBearing returns the bearing at p1 of the shortest path from p1
+	// to p2.
+	InitialBearing(p1, p2 Point) float64
+	// FinalBearing returns the bearing at p2 of the shortest path from p1 to
+	// p2.
+	FinalBearing(p1, p2 Point) float64
+	// Destination returns the point reached by traveling distance from p
+	// with initial bearing.
+	Destination(p Point, bearing, distance float64) Point
+}
+
+// Models of the Earth.
+var (
+	// FAISphere is the sphere with radius 6371 km defined in section 2.5.2.1
+	// of the FAI Sporting Code General Section.
+	FAISphere = Sphere{Radius: 6371000}
+	// WGS84 is the WGS84 ellipsoid.
+	WGS84 = Ellipsoid{A: 6378137, F: 1 / 298.257223563}
+)
+
+// Radians converts degrees to radians.
+func Radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+// Degrees converts radians to degrees.
+func Degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
+
+// BearingDifference returns the signed difference from bearing1 to bearing2
+// in degrees, in the range [-180, 180). It is positive if bearing2 is
+// clockwise from bearing1.
+func BearingDifference(bearing1, bearing2 float64) float64 {
+	difference := math.Mod(bearing2-bearing1+180, 360)
+	if difference < 0 {
+		difference += 360
+	}
+	return difference - 180
+}
+
+// normalizeBearing normalizes a bearing in degrees to the range [0, 360).
+func normalizeBearing(bearing float64) float64 {
+	bearing = math.Mod(bearing, 360)
+	if bearing < 0 {
+		bearing += 360
+	}
+	if bearing >= 360 {
+		bearing = 0
+	}
+	return bearing
+}
+
+// normalizeLon normalizes a longitude in degrees to the range [-180, 180).
+func normalizeLon(lon float64) float64 {
+	lon = math.Mod(lon+180, 360)
+	if lon < 0 {
+		lon += 360
+	}
+	return lon - 180
+}
@@ -0,0 +1,153 @@
+package geo_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-igc/geo"
+)
+
+var (
+	flindersPeak = geo.Point{Lat: -(37 + 57.0/60 + 3.72030/3600), Lon: 144 + 25.0/60 + 29.52440/3600}
+	buninyong    = geo.Point{Lat: -(37 + 39.0/60 + 10.15610/3600), Lon: 143 + 55.0/60 + 35.38390/3600}
+	jfk          = geo.Point{Lat: 40.6, Lon: -73.8}
+	lhr          = geo.Point{Lat: 51.6, Lon: -0.5}
+)
+
+func TestSphere(t *testing.T) {
+	for _, tc := range []struct {
+		name                   string
+		p1                     geo.Point
+		p2                     geo.Point
+		expectedDistance       float64
+		expectedInitialBearing float64
+		expectedFinalBearing   float64
+	}{
+		{
+			name:                   "one_degree_of_latitude",
+			p1:                     geo.Point{Lat: 45, Lon: 7},
+			p2:                     geo.Point{Lat: 46, Lon: 7},
+			expectedDistance:       111194.93,
+			expectedInitialBearing: 0,
+			expectedFinalBearing:   0,
+		},
+		{
+			name:                   "one_degree_of_longitude_at_equator",
+			p1:                     geo.Point{Lat: 0, Lon: 1},
+			p2:                     geo.Point{Lat: 0, Lon: 0},
+			expectedDistance:       111194.93,
+			expectedInitialBearing: 270,
+			expectedFinalBearing:   270,
+		},
+		{
+			name:                   "jfk_lhr",
+			p1:                     jfk,
+			p2:                     lhr,
+			expectedDistance:       5536884.34,
+			expectedInitialBearing: 51.1693,
+			expectedFinalBearing:   107.7817,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assertClose(t, tc.expectedDistance, geo.FAISphere.Distance(tc.p1, tc.p2), 0.01)
+			assertClose(t, tc.expectedInitialBearing, geo.FAISphere.InitialBearing(tc.p1, tc.p2), 1e-4)
+			assertClose(t, tc.expectedFinalBearing, geo.FAISphere.FinalBearing(tc.p1, tc.p2), 1e-4)
+			destination := geo.FAISphere.Destination(tc.p1, geo.FAISphere.InitialBearing(tc.p1, tc.p2), geo.FAISphere.Distance(tc.p1, tc.p2))
+			assertClose(t, tc.p2.Lat, destination.Lat, 1e-6)
+			assertClose(t, tc.p2.Lon, destination.Lon, 1e-6)
+		})
+	}
+}
+
+func TestSphereCrossTrackDistance(t *testing.T) {
+	start := geo.Point{Lat: 53.3206, Lon: -1.7297}
+	end := geo.Point{Lat: 53.1887, Lon: 0.1334}
+	p := geo.Point{Lat: 53.2611, Lon: -0.7972}
+	assertClose(t, -307.55, geo.FAISphere.CrossTrackDistance(p, start, end), 0.01)
+	assertClose(t, 62331.49, geo.FAISphere.AlongTrackDistance(p, start, end), 0.01)
+}
+
+func TestWGS84(t *testing.T) {
+	for _, tc := range []struct {
+		name                   string
+		p1                     geo.Point
+		p2                     geo.Point
+		expectedDistance       float64
+		expectedInitialBearing float64
+		expectedFinalBearing   float64
+	}{
+		{
+			name:                   "vincenty",
+			p1:                     flindersPeak,
+			p2:                     buninyong,
+			expectedDistance:       54972.271,
+			expectedInitialBearing: 306 + 52.0/60 + 5.37/3600,
+			expectedFinalBearing:   307 + 10.0/60 + 25.07/3600,
+		},
+		{
+			name:                   "jfk_lhr",
+			p1:                     jfk,
+			p2:                     lhr,
+			expectedDistance:       5551759.400,
+			expectedInitialBearing: 51.198883,
+			expectedFinalBearing:   107.821777,
+		},
+		{
+			name:                   "one_degree_of_latitude",
+			p1:                     geo.Point{Lat: 45, Lon: 7},
+			p2:                     geo.Point{Lat: 46, Lon: 7},
+			expectedDistance:       111141.548,
+			expectedInitialBearing: 0,
+			expectedFinalBearing:   0,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			distance, initialBearing, finalBearing := geo.WGS84.Inverse(tc.p1, tc.p2)
+			assertClose(t, tc.expectedDistance, distance, 1e-3)
+			assertClose(t, tc.expectedInitialBearing, initialBearing, 1e-5)
+			assertClose(t, tc.expectedFinalBearing, finalBearing, 1e-5)
+			destination := geo.WGS84.Destination(tc.p1, initialBearing, distance)
+			assertClose(t, tc.p2.Lat, destination.Lat, 1e-8)
+			assertClose(t, tc.p2.Lon, destination.Lon, 1e-8)
+		})
+	}
+}
+
+func TestWGS84Antipodal(t *testing.T) {
+	p1 := geo.Point{Lat: 0, Lon: 0}
+	p2 := geo.Point{Lat: 0.5, Lon: 179.7}
+	distance := geo.WGS84.Distance(p1, p2)
+	assert.False(t, math.IsNaN(distance))
+	assert.True(t, math.Abs(distance-geo.FAISphere.Distance(p1, p2)) < 0.005*distance)
+}
+
+func TestWGS84Coincident(t *testing.T) {
+	assert.Equal(t, 0.0, geo.WGS84.Distance(jfk, jfk))
+}
+
+func assertClose(t *testing.T, expected, actual, tolerance float64) {
+	t.Helper()
+	assert.True(t, math.Abs(expected-actual) <= tolerance, "expected %f, got %f", expected, actual)
+}
+
+func TestBearingDifference(t *testing.T) {
+	for _, tc := range []struct {
+		bearing1 float64
+		bearing2 float64
+		expected float64
+	}{
+		{bearing1: 0, bearing2: 0, expected: 0},
+		{bearing1: 0, bearing2: 90, expected: 90},
+		{bearing1: 90, bearing2: 0, expected: -90},
+		{bearing1: 350, bearing2: 10, expected: 20},
+		{bearing1: 10, bearing2: 350, expected: -20},
+		{bearing1: 0, bearing2: 180, expected: -180},
+		{bearing1: -90, bearing2: 450, expected: -180},
+	} {
+		assert.Equal(t, tc.expected, geo.BearingDifference(tc.bearing1, tc.bearing2))
+	}
+	assert.Equal(t, math.Pi, geo.Radians(180))
+	assert.Equal(t, 180.0, geo.Degrees(math.Pi))
+}
@@ -0,0 +1,81 @@
+package geo
+
+import (
+	"math"
+)
+
+// A Sphere is a spherical model of the Earth.
+type Sphere struct {
+	Radius float64
+}
+
+// Distance returns the great-circle distance between p1 and p2 using the
+// haversine formula.
+func (s Sphere) Distance(p1, p2 Point) float64 {
+	return s.Radius * s.angularDistance(p1, p2)
+}
+
+// InitialBearing returns the initial bearing of the great circle from p1 to
+// p2.
+func (s Sphere) InitialBearing(p1, p2 Point) float64 {
+	lat1, lat2 := Radians(p1.Lat), Radians(p2.Lat)
+	deltaLon := Radians(p2.Lon - p1.Lon)
+	y := math.Sin(deltaLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(deltaLon)
+	return normalizeBearing(Degrees(math.Atan2(y, x)))
+}
+
+// FinalBearing returns the final bearing of the great circle from p1 to p2.
+func (s Sphere) FinalBearing(p1, p2 Point) float64 {
+	return normalizeBearing(s.InitialBearing(p2, p1) + 180)
+}
+
+// Destination returns the point reached by traveling distance along the great
+// circle from p with initial bearing.
+func (s Sphere) Destination(p Point, bearing, distance float64) Point {
+	lat1, lon1 := Radians(p.Lat), Radians(p.Lon)
+	theta := Radians(bearing)
+	delta := distance / s.Radius
+	sinLat2 := math.Sin(lat1)*math.Cos(delta) + math.Cos(lat1)*math.Sin(delta)*math.Cos(theta)
+	lat2 := math.Asin(max(-1, min(sinLat2, 1)))
+	y := math.Sin(theta) * math.Sin(delta) * math.Cos(lat1)
+	x := math.Cos(delta) - math.Sin(lat1)*sinLat2
+	lon2 := lon1 + math.Atan2(y, x)
+	return Point{
+		Lat: Degrees(lat2),
+		Lon: normalizeLon(Degrees(lon2)),
+	}
+}
+
+// CrossTrackDistance returns the distance of p from the great circle through
+// start and end. It is positive if p is to the right of the great circle and
+// negative if p is to the left.
+func (s Sphere) CrossTrackDistance(p, start, end Point) float64 {
+	delta13 := s.angularDistance(start, p)
+	theta13 := Radians(s.InitialBearing(start, p))
+	theta12 := Radians(s.InitialBearing(start, end))
+	return s.Radius * math.Asin(math.Sin(delta13)*math.Sin(theta13-theta12))
+}
+
+// AlongTrackDistance returns the distance from start to the point on the great
+// circle through start and end closest to p.
+func (s Sphere) AlongTrackDistance(p, start, end Point) float64 {
+	delta13 := s.angularDistance(start, p)
+	theta13 := Radians(s.InitialBearing(start, p))
+	theta12 := Radians(s.InitialBearing(start, end))
+	deltaXT := math.Asin(math.Sin(delta13) * math.Sin(theta13-theta12))
+	deltaAT := math.Acos(max(-1, min(math.Cos(delta13)/math.Cos(deltaXT), 1)))
+	if math.Cos(theta13-theta12) < 0 {
+		deltaAT = -deltaAT
+	}
+	return s.Radius * deltaAT
+}
+
+// angularDistance returns the angular distance between p1 and p2 in radians.
+func (s Sphere) angularDistance(p1, p2 Point) float64 {
+	lat1, lat2 := Radians(p1.Lat), Radians(p2.Lat)
+	sinHalfDeltaLat := math.Sin((lat2 - lat1) / 2)
+	sinHalfDeltaLon := math.Sin(Radians(p2.Lon-p1.Lon) / 2)
+	a := sinHalfDeltaLat*sinHalfDeltaLat + math.Cos(lat1)*math.Cos(lat2)*sinHalfDeltaLon*sinHalfDeltaLon
+	return 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
@@ -0,0 +1,199 @@
+package igc
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// An AltitudeDatum is an altitude datum. See the ALG and ALP H records in
+// section A4.2 of the IGC specification.
+type AltitudeDatum string
+
+// Altitude datums.
+const (
+	AltitudeDatumUnknown   AltitudeDatum = ""
+	AltitudeDatumEllipsoid AltitudeDatum = "ELL" // Ellipsoid is GNSS altitude above the WGS84 ellipsoid.
+	AltitudeDatumGeoid     AltitudeDatum = "GEO" // Geoid is GNSS altitude above the WGS84 geoid.
+	AltitudeDatumISA       AltitudeDatum = "ISA" // ISA is pressure altitude using the ICAO ISA.
+	AltitudeDatumMSL       AltitudeDatum = "MSL" // MSL is pressure altitude adjusted to mean sea level.
+	AltitudeDatumNone      AltitudeDatum = "NIL" // None indicates that no altitude is recorded.
+)
+
+// A HeaderField is a header field built from all H records with the same
+// three-letter code.
+type HeaderField struct {
+	Value    string     // Value is the value of the H record with the highest precedence source.
+	Source   Source     // Source is the source of Value, or zero if there are no H records.
+	HRecords []*HRecord // HRecords are all the H records with the three-letter code, in order.
+}
+
+// A Header is a flight header built from H records. Where there are multiple H
+// records with the same three-letter code, the value is taken from the first H
+// record with the highest precedence source, where flight recorder sources take
+// precedence over pilot sources, which take precedence over other sources.
+type Header struct {
+	Date              time.Time
+	FlightNumber      int
+	Pilot             HeaderField // PLT.
+	SecondCrew        HeaderField // CM2.
+	GliderType        HeaderField // GTY.
+	GliderID          HeaderField // GID.
+	CompetitionID     HeaderField // CID.
+	CompetitionClass  HeaderField // CCL.
+	FRType            HeaderField // FTY.
+	FirmwareVersion   HeaderField // RFW.
+	HardwareVersion   HeaderField // RHW.
+	GPSReceiver       HeaderField // GPS.
+	GPSDatum          HeaderField // DTM.
+	PressureSensor    HeaderField // PRS.
+	GNSSAltitude      HeaderField // ALG.
+	PressureAltitude  HeaderField // ALP.
+	TimeZone          HeaderField // TZN.
+	Site              HeaderField // SIT.
+	FixAccuracy       HeaderField // FXA.
+	HeaderFieldsByTLC map[string]HeaderField
+}
+
+// NewHeader returns a new Header built from the H records in records.
+func NewHeader(records []Record) *Header {
+	header := &Header{
+		HeaderFieldsByTLC: make(map[string]HeaderField),
+	}
+	hfdteRecordPrecedence := 0
+	for _, record := range records {
+		switch record := record.(type) {
+		case *HRecord:
+			header.addHRecord(record)
+		case *HFDTERecord:
+			header.addHRecord(&record.HRecord)
+			if precedence := sourcePrecedence(record.Source); precedence > hfdteRecordPrecedence {
+				header.Date = record.Date
+				header.FlightNumber = record.FlightNumber
+				hfdteRecordPrecedence = precedence
+			}
+		}
+	}
+	for _, field := range []struct {
+		headerField *HeaderField
+		tlc         string
+	}{
+		{&header.Pilot, "PLT"},
+		{&header.SecondCrew, "CM2"},
+		{&header.GliderType, "GTY"},
+		{&header.GliderID, "GID"},
+		{&header.CompetitionID, "CID"},
+		{&header.CompetitionClass, "CCL"},
+		{&header.FRType, "FTY"},
+		{&header.FirmwareVersion, "RFW"},
+		{&header.HardwareVersion, "RHW"},
+		{&header.GPSReceiver, "GPS"},
+		{&header.GPSDatum, "DTM"},
+		{&header.PressureSensor, "PRS"},
+		{&header.GNSSAltitude, "ALG"},
+		{&header.PressureAltitude, "ALP"},
+		{&header.TimeZone, "TZN"},
+		{&header.Site, "SIT"},
+		{&header.FixAccuracy, "FXA"},
+	} {
+		*field.headerField = header.HeaderFieldsByTLC[field.tlc]
+	}
+	return header
+}
+
+// Header returns the flight header built from igc's H records.
+func (igc *IGC) Header() *Header {
+	return NewHeader(igc.Records)
+}
+
+// FixAccuracyMeters returns the fix accuracy in meters and whether it is
+// valid.
+func (h *Header) FixAccuracyMeters() (int, bool) {
+	fixAccuracy, err := strconv.Atoi(strings.TrimSpace(h.FixAccuracy.Value))
+	if err != nil {
+		return 0, false
+	}
+	return fixAccuracy, true
+}
+
+// GNSSAltitudeDatum returns the datum of GNSS altitudes.
+func (h *Header) GNSSAltitudeDatum() AltitudeDatum {
+	return parseAltitudeDatum(h.GNSSAltitude.Value)
+}
+
+// PressureAltitudeDatum returns the datum of pressure altitudes.
+func (h *Header) PressureAltitudeDatum() AltitudeDatum {
+	return parseAltitudeDatum(h.PressureAltitude.Value)
+}
+
+// TimeZoneOffset returns the offset of local time from UTC and whether it is
+// valid. It is taken from the TZN H record, or, failing that, the
+// non-standard TZO and UTC H records.
+func (h *Header) TimeZoneOffset() (time.Duration, bool) {
+	if offset, ok := parseTimeZoneHours(h.TimeZone.Value); ok {
+		return offset, true
+	}
+	if offset, ok := parseTimeZoneHours(h.HeaderFieldsByTLC["TZO"].Value); ok {
+		return offset, true
+	}
+	if seconds, err := strconv.Atoi(strings.TrimSpace(h.HeaderFieldsByTLC["UTC"].Value)); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	return 0, false
+}
+
+// addHRecord adds hRecord to h.
+func (h *Header) addHRecord(hRecord *HRecord) {
+	field := h.HeaderFieldsByTLC[hRecord.TLC]
+	if sourcePrecedence(hRecord.Source) > sourcePrecedence(field.Source) {
+		field.Value = hRecord.Value
+		field.Source = hRecord.Source
+	}
+	field.HRecords = append(field.HRecords, hRecord)
+	h.HeaderFieldsByTLC[hRecord.TLC] = field
+}
+
+// parseAltitudeDatum parses an altitude datum from the value of an ALG or ALP
+// H record.
+func parseAltitudeDatum(value string) AltitudeDatum {
+	value = strings.ToUpper(value)
+	switch {
+	case strings.Contains(value, "ELL"):
+		return AltitudeDatumEllipsoid
+	case strings.Contains(value, "GEO"):
+		return AltitudeDatumGeoid
+	case strings.Contains(value, "ISA"):
+		return AltitudeDatumISA
+	case strings.Contains(value, "MSL"):
+		return AltitudeDatumMSL
+	case strings.Contains(value, "NIL"):
+		return AltitudeDatumNone
+	default:
+		return AltitudeDatumUnknown
+	}
+}
+
+// parseTimeZoneHours parses a time zone offset in hours, for example "+2.00",
+// "-7", or "1.00h".
+func parseTimeZoneHours(value string) (time.Duration, bool) {
+	value = strings.TrimSuffix(strings.TrimSpace(value), "h")
+	hours, err := strconv.ParseFloat(value, 64)
+	if err != nil || !(-14 <= hours && hours <= 14) {
+		return 0, false
+	}
+	return time.Duration(hours * float64(time.Hour)).Round(time.Minute), true
+}
+
+// sourcePrecedence returns the precedence of source.
+func sourcePrecedence(source Source) int {
+	switch source {
+	case SourceFlightRecorder:
+		return 3
+	case SourcePilot:
+		return 2
+	case SourceOther:
+		return 1
+	default:
+		return 0
+	}
+}
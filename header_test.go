@@ -0,0 +1,84 @@
+package igc_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-igc"
+)
+
+func TestHeader(t *testing.T) {
+	igcFile, err := igc.ParseLines([]string{
+		"AXXXABC",
+		"HFDTEDATE:020508,01",
+		"HOPLTPILOT:Other Pilot",
+		"HPPLTPILOT:Pilot Pilot",
+		"HFCM2CREW2:NIL",
+		"HPCM2CREW2:Second Crew",
+		"HOGTYGLIDERTYPE:Other Glider",
+		"HPGTYGLIDERTYPE:First Glider",
+		"HPGTYGLIDERTYPE:Second Glider",
+		"HFFXA035",
+		"HFALGALTGPS:WGS84 ELLIPSOID",
+		"HFALPALTPRESSURE:ISA",
+		"HFTZNTIMEZONE:+5.50",
+	})
+	assert.NoError(t, err)
+	header := igcFile.Header()
+
+	assert.Equal(t, time.Date(2008, time.May, 2, 0, 0, 0, 0, time.UTC), header.Date)
+	assert.Equal(t, 1, header.FlightNumber)
+
+	assert.Equal(t, "Pilot Pilot", header.Pilot.Value)
+	assert.Equal(t, igc.SourcePilot, header.Pilot.Source)
+	assert.Equal(t, 2, len(header.Pilot.HRecords))
+	assert.Equal(t, "Other Pilot", header.Pilot.HRecords[0].Value)
+
+	assert.Equal(t, "NIL", header.SecondCrew.Value)
+	assert.Equal(t, igc.SourceFlightRecorder, header.SecondCrew.Source)
+	assert.Equal(t, 2, len(header.SecondCrew.HRecords))
+
+	assert.Equal(t, "First Glider", header.GliderType.Value)
+	assert.Equal(t, 3, len(header.GliderType.HRecords))
+
+	assert.Equal(t, igc.HeaderField{}, header.GliderID)
+	assert.Equal(t, igc.HeaderField{}, header.HeaderFieldsByTLC["GID"])
+
+	fixAccuracy, ok := header.FixAccuracyMeters()
+	assert.True(t, ok)
+	assert.Equal(t, 35, fixAccuracy)
+
+	assert.Equal(t, igc.AltitudeDatumEllipsoid, header.GNSSAltitudeDatum())
+	assert.Equal(t, igc.AltitudeDatumISA, header.PressureAltitudeDatum())
+
+	timeZoneOffset, ok := header.TimeZoneOffset()
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Hour+30*time.Minute, timeZoneOffset)
+}
+
+func TestHeaderTimeZoneOffset(t *testing.T) {
+	for _, tc := range []struct {
+		line       string
+		expected   time.Duration
+		expectedOK bool
+	}{
+		{line: "HFTZNTIMEZONE:2", expected: 2 * time.Hour, expectedOK: true},
+		{line: "HFTZNTIMEZONE:-7", expected: -7 * time.Hour, expectedOK: true},
+		{line: "HFTZNTIMEZONE:0.0", expectedOK: true},
+		{line: "HFTZNUTCOFFSET:  1.00h", expected: time.Hour, expectedOK: true},
+		{line: "HFTZOTimezone:2", expected: 2 * time.Hour, expectedOK: true},
+		{line: "HFUTCOFFSETSEC:7200", expected: 2 * time.Hour, expectedOK: true},
+		{line: "HFTZNTIMEZONE:NaN"},
+		{line: "HFTZNTIMEZONE:"},
+	} {
+		t.Run(tc.line, func(t *testing.T) {
+			igcFile, err := igc.ParseLines([]string{tc.line})
+			assert.NoError(t, err)
+			actual, actualOK := igcFile.Header().TimeZoneOffset()
+			assert.Equal(t, tc.expected, actual)
+			assert.Equal(t, tc.expectedOK, actualOK)
+		})
+	}
+}
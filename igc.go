@@ -4,6 +4,7 @@
 package igc
 
 import (
+	"errors"
 	"io"
 	"strconv"
 	"time"
@@ -11,8 +12,11 @@ import (
 
 // An Error is an error at a line.
 type Error struct {
-	Line int
-	Err  error
+	Line     int      // Line is the 1-based line number.
+	Offset   int      // Offset is the byte offset of the start of the line.
+	Column   int      // Column is the 1-based column of the error, or zero if unknown.
+	Severity Severity // Severity is the maximum severity of the errors in Err.
+	Err      error
 }
 
 func (e *Error) Error() string {
@@ -23,6 +27,15 @@ func (e *Error) Unwrap() error {
 	return e.Err
 }
 
+// A Line is a line in an IGC file.
+type Line struct {
+	Number int    // Number is the 1-based line number.
+	Offset int    // Offset is the byte offset of the start of the line.
+	Raw    []byte // Raw is the raw bytes of the line, including its line terminator if it was read by Parse or a Decoder. Lines parsed by ParseLines have no terminator.
+	Record Record // Record is the parsed record, or nil if the line is empty or invalid.
+	Err    error  // Err is the error parsing the line, if any.
+}
+
 // A Record is a record.
 type Record interface {
 	Type() byte
@@ -82,7 +95,7 @@ type BRecord struct {
 	Validity      Validity
 	AltWGS84      float64
 	AltBarometric float64
-	Additions     map[string]int
+	Additions     Additions
 }
 
 func (r *BRecord) Type() byte  { return 'B' }
@@ -217,7 +230,7 @@ func (r *JRecord) Valid() bool { return r != nil }
 // than fixes.
 type KRecord struct {
 	Time      time.Time
-	Additions map[string]int
+	Additions Additions
 }
 
 func (r *KRecord) Type() byte  { return 'K' }
@@ -253,7 +266,7 @@ func (r *MRecord) Valid() bool { return r != nil }
 // signature.
 type NRecord struct {
 	Time      time.Time
-	Additions map[string]int
+	Additions Additions
 }
 
 func (r *NRecord) Type() byte  { return 'N' }
@@ -261,6 +274,7 @@ func (r *NRecord) Valid() bool { return r != nil }
 
 // An IGC is a parsed IGC file.
 type IGC struct {
+	Lines         []*Line
 	Records       []Record
 	BRecords      []*BRecord
 	HRecordsByTLC map[string]*HRecord
@@ -268,12 +282,41 @@ type IGC struct {
 	Errs          []error
 }
 
-// Parse parses an IGC from r.
+// Parse parses an IGC from r. To parse large inputs without holding every
+// record in memory, use a Decoder instead.
 func Parse(r io.Reader, options ...ParseOption) (*IGC, error) {
-	return newParser(options...).parse(r)
+	var c collector
+	d := NewDecoder(r, options...)
+	// All lines and records are retained, so batches can be used.
+	d.p.batch = true
+	for {
+		switch line, err := d.Decode(); {
+		case errors.Is(err, io.EOF):
+			return c.igc(), nil
+		case err != nil:
+			return nil, err
+		default:
+			c.add(line)
+		}
+	}
 }
 
-// Parse parses an IGC from lines.
+// ParseLines parses an IGC from lines, which must not contain line
+// terminators. Line offsets are calculated as if each line was terminated by a
+// single newline.
 func ParseLines(lines []string, options ...ParseOption) (*IGC, error) {
-	return newParser(options...).parseLines(lines)
+	p := newParser(options...)
+	p.batch = true
+	return p.parseLines(lines)
+}
+
+// WriteRaw writes the raw bytes of every line in igc to w. If igc was returned
+// by Parse, then the output is byte-for-byte identical to the input.
+func (igc *IGC) WriteRaw(w io.Writer) error {
+	for _, line := range igc.Lines {
+		if _, err := w.Write(line.Raw); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -2,9 +2,11 @@ package igc_test
 
 import (
 	"bytes"
+	"errors"
 	"os"
 	"path/filepath"
 	"reflect"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -472,10 +474,16 @@ func TestParseLines(t *testing.T) {
 					Validity:      igc.Validity3D,
 					AltBarometric: 929,
 					AltWGS84:      941,
-					Additions: map[string]int{
-						"FXA": 6,
-						"SIU": 12,
-					},
+					Additions: igc.NewAdditions(
+						[]igc.RecordAddition{
+							{StartColumn: 36, FinishColumn: 38, TLC: "FXA"},
+							{StartColumn: 39, FinishColumn: 40, TLC: "SIU"},
+						},
+						map[string]int{
+							"FXA": 6,
+							"SIU": 12,
+						},
+					),
 				},
 			},
 		},
@@ -510,9 +518,15 @@ func TestParseLines(t *testing.T) {
 					Validity:      igc.Validity3D,
 					AltBarometric: 929,
 					AltWGS84:      941,
-					Additions: map[string]int{
-						"FXA": 6,
-					},
+					Additions: igc.NewAdditions(
+						[]igc.RecordAddition{
+							{StartColumn: 36, FinishColumn: 38, TLC: "FXA"},
+							{StartColumn: 39, FinishColumn: 40, TLC: "SIU"},
+						},
+						map[string]int{
+							"FXA": 6,
+						},
+					),
 				},
 			},
 			expectedErrs: []string{
@@ -549,7 +563,12 @@ func TestParseLines(t *testing.T) {
 					Validity:      igc.Validity3D,
 					AltBarometric: 929,
 					AltWGS84:      941,
-					Additions:     map[string]int{},
+					Additions: igc.NewAdditions(
+						[]igc.RecordAddition{
+							{StartColumn: 36, FinishColumn: 38, TLC: "FXA"},
+						},
+						map[string]int{},
+					),
 				},
 			},
 			expectedErrs: []string{
@@ -588,11 +607,18 @@ func TestParseLines(t *testing.T) {
 					Validity:      igc.Validity3D,
 					AltBarometric: 929,
 					AltWGS84:      941,
-					Additions: map[string]int{
-						"LAD": 5,
-						"LOD": 6,
-						"TDS": 4,
-					},
+					Additions: igc.NewAdditions(
+						[]igc.RecordAddition{
+							{StartColumn: 36, FinishColumn: 36, TLC: "TDS"},
+							{StartColumn: 37, FinishColumn: 37, TLC: "LAD"},
+							{StartColumn: 38, FinishColumn: 38, TLC: "LOD"},
+						},
+						map[string]int{
+							"LAD": 5,
+							"LOD": 6,
+							"TDS": 4,
+						},
+					),
 				},
 			},
 		},
@@ -703,10 +729,16 @@ func TestParseLines(t *testing.T) {
 				},
 				&igc.KRecord{
 					Time: time.Date(2024, time.June, 4, 15, 4, 52, 0, time.UTC),
-					Additions: map[string]int{
-						"WDI": 276,
-						"WSP": 600,
-					},
+					Additions: igc.NewAdditions(
+						[]igc.RecordAddition{
+							{StartColumn: 8, FinishColumn: 10, TLC: "WDI"},
+							{StartColumn: 11, FinishColumn: 13, TLC: "WSP"},
+						},
+						map[string]int{
+							"WDI": 276,
+							"WSP": 600,
+						},
+					),
 				},
 			},
 		},
@@ -761,10 +793,16 @@ func TestParseLines(t *testing.T) {
 				},
 				&igc.NRecord{
 					Time: time.Date(2024, time.June, 4, 12, 34, 56, 0, time.UTC),
-					Additions: map[string]int{
-						"HRT": 112,
-						"OXY": 98,
-					},
+					Additions: igc.NewAdditions(
+						[]igc.RecordAddition{
+							{StartColumn: 8, FinishColumn: 10, TLC: "HRT"},
+							{StartColumn: 11, FinishColumn: 13, TLC: "OXY"},
+						},
+						map[string]int{
+							"HRT": 112,
+							"OXY": 98,
+						},
+					),
 				},
 			},
 		},
@@ -833,11 +871,18 @@ func TestParseLines(t *testing.T) {
 					Validity:      igc.Validity3D,
 					AltBarometric: 179,
 					AltWGS84:      275,
-					Additions: map[string]int{
-						"FXA": 0,
-						"SIU": 10,
-						"TDS": 8,
-					},
+					Additions: igc.NewAdditions(
+						[]igc.RecordAddition{
+							{StartColumn: 36, FinishColumn: 38, TLC: "FXA"},
+							{StartColumn: 39, FinishColumn: 40, TLC: "SIU"},
+							{StartColumn: 41, FinishColumn: 41, TLC: "TDS"},
+						},
+						map[string]int{
+							"FXA": 0,
+							"SIU": 10,
+							"TDS": 8,
+						},
+					),
 				},
 			},
 		},
@@ -876,11 +921,18 @@ func TestParseLines(t *testing.T) {
 					Validity:      igc.Validity3D,
 					AltBarometric: 1004,
 					AltWGS84:      1149,
-					Additions: map[string]int{
-						"LAD": 12,
-						"LOD": 34,
-						"TDS": 0,
-					},
+					Additions: igc.NewAdditions(
+						[]igc.RecordAddition{
+							{StartColumn: 36, FinishColumn: 37, TLC: "LAD"},
+							{StartColumn: 38, FinishColumn: 39, TLC: "LOD"},
+							{StartColumn: 40, FinishColumn: 40, TLC: "TDS"},
+						},
+						map[string]int{
+							"LAD": 12,
+							"LOD": 34,
+							"TDS": 0,
+						},
+					),
 				},
 			},
 		},
@@ -948,10 +1000,16 @@ func TestParseLines(t *testing.T) {
 					Validity:      igc.Validity3D,
 					AltBarometric: 4406,
 					AltWGS84:      4672,
-					Additions: map[string]int{
-						"LAD": 3,
-						"LOD": 6,
-					},
+					Additions: igc.NewAdditions(
+						[]igc.RecordAddition{
+							{StartColumn: 36, FinishColumn: 36, TLC: "LAD"},
+							{StartColumn: 37, FinishColumn: 37, TLC: "LOD"},
+						},
+						map[string]int{
+							"LAD": 3,
+							"LOD": 6,
+						},
+					),
 				},
 				&igc.BRecord{
 					Time:          time.Date(2024, time.June, 6, 0, 0, 1, 0, time.UTC),
@@ -960,10 +1018,16 @@ func TestParseLines(t *testing.T) {
 					Validity:      igc.Validity3D,
 					AltBarometric: 4408,
 					AltWGS84:      4675,
-					Additions: map[string]int{
-						"LAD": 3,
-						"LOD": 0,
-					},
+					Additions: igc.NewAdditions(
+						[]igc.RecordAddition{
+							{StartColumn: 36, FinishColumn: 36, TLC: "LAD"},
+							{StartColumn: 37, FinishColumn: 37, TLC: "LOD"},
+						},
+						map[string]int{
+							"LAD": 3,
+							"LOD": 0,
+						},
+					),
 				},
 			},
 		},
@@ -1000,10 +1064,16 @@ func TestParseLines(t *testing.T) {
 					Validity:      igc.Validity3D,
 					AltBarometric: 4406,
 					AltWGS84:      4672,
-					Additions: map[string]int{
-						"LAD": 3,
-						"LOD": 6,
-					},
+					Additions: igc.NewAdditions(
+						[]igc.RecordAddition{
+							{StartColumn: 36, FinishColumn: 36, TLC: "LAD"},
+							{StartColumn: 37, FinishColumn: 37, TLC: "LOD"},
+						},
+						map[string]int{
+							"LAD": 3,
+							"LOD": 6,
+						},
+					),
 				},
 				&igc.HFDTERecord{
 					HRecord: igc.HRecord{
@@ -1022,10 +1092,16 @@ func TestParseLines(t *testing.T) {
 					Validity:      igc.Validity3D,
 					AltBarometric: 4408,
 					AltWGS84:      4675,
-					Additions: map[string]int{
-						"LAD": 3,
-						"LOD": 0,
-					},
+					Additions: igc.NewAdditions(
+						[]igc.RecordAddition{
+							{StartColumn: 36, FinishColumn: 36, TLC: "LAD"},
+							{StartColumn: 37, FinishColumn: 37, TLC: "LOD"},
+						},
+						map[string]int{
+							"LAD": 3,
+							"LOD": 0,
+						},
+					),
 				},
 			},
 		},
@@ -1297,3 +1373,150 @@ func assertEqualErrors(t *testing.T, expectedErrs []string, errs []error) {
 	}
 	assert.Equal(t, expectedErrs, actualErrs)
 }
+
+func TestParseLineOffsets(t *testing.T) {
+	data := "AXCTabc\r\n\r\nX\nHFDTE020508\r\r\nB1005364607690N00610358EA0000001265~"
+	actual, err := igc.Parse(strings.NewReader(data))
+	assert.NoError(t, err)
+	type line struct {
+		Number int
+		Offset int
+		Raw    string
+		Valid  bool
+	}
+	actualLines := make([]line, 0, len(actual.Lines))
+	for _, l := range actual.Lines {
+		actualLines = append(actualLines, line{
+			Number: l.Number,
+			Offset: l.Offset,
+			Raw:    string(l.Raw),
+			Valid:  l.Record != nil && l.Record.Valid(),
+		})
+	}
+	assert.Equal(t, []line{
+		{Number: 1, Offset: 0, Raw: "AXCTabc\r\n", Valid: true},
+		{Number: 2, Offset: 9, Raw: "\r\n"},
+		{Number: 3, Offset: 11, Raw: "X\n"},
+		{Number: 4, Offset: 13, Raw: "HFDTE020508\r\r\n", Valid: true},
+		{Number: 5, Offset: 27, Raw: "B1005364607690N00610358EA0000001265~", Valid: true},
+	}, actualLines)
+	assert.Equal(t, []error{actual.Lines[2].Err, actual.Lines[4].Err}, actual.Errs)
+	var igcError *igc.Error
+	assert.True(t, errors.As(actual.Lines[4].Err, &igcError))
+	assert.Equal(t, 5, igcError.Line)
+	assert.Equal(t, 27, igcError.Offset)
+	assert.Equal(t, 36, igcError.Column)
+}
+
+func TestWriteRawTestData(t *testing.T) {
+	t.Parallel()
+	dirEntries, err := os.ReadDir("testdata")
+	assert.NoError(t, err)
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			data, err := os.ReadFile(filepath.Join("testdata", name))
+			assert.NoError(t, err)
+			igc, err := igc.Parse(bytes.NewReader(data))
+			assert.NoError(t, err)
+			var buffer bytes.Buffer
+			assert.NoError(t, igc.WriteRaw(&buffer))
+			assert.True(t, bytes.Equal(data, buffer.Bytes()))
+		})
+	}
+}
+
+func BenchmarkParseTestData(b *testing.B) {
+	dirEntries, err := os.ReadDir("testdata")
+	assert.NoError(b, err)
+	var datas [][]byte
+	totalBytes, totalFixes := 0, 0
+	for _, dirEntry := range dirEntries {
+		data, err := os.ReadFile(filepath.Join("testdata", dirEntry.Name()))
+		assert.NoError(b, err)
+		igcFile, err := igc.Parse(bytes.NewReader(data))
+		assert.NoError(b, err)
+		datas = append(datas, data)
+		totalBytes += len(data)
+		totalFixes += len(igcFile.BRecords)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		parse func([]byte) error
+	}{
+		{
+			name: "Parse",
+			parse: func(data []byte) error {
+				_, err := igc.Parse(bytes.NewReader(data))
+				return err
+			},
+		},
+		{
+			name: "Decoder",
+			parse: func(data []byte) error {
+				for _, err := range igc.NewDecoder(bytes.NewReader(data)).Records() {
+					if err != nil {
+						if igcError := (*igc.Error)(nil); !errors.As(err, &igcError) {
+							return err
+						}
+					}
+				}
+				return nil
+			},
+		},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			b.SetBytes(int64(totalBytes))
+			b.ReportAllocs()
+			var memStatsBefore, memStatsAfter runtime.MemStats
+			runtime.ReadMemStats(&memStatsBefore)
+			for b.Loop() {
+				for _, data := range datas {
+					if err := tc.parse(data); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+			runtime.ReadMemStats(&memStatsAfter)
+			fixes := float64(b.N) * float64(totalFixes)
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/fixes, "ns/fix")
+			b.ReportMetric(float64(memStatsAfter.Mallocs-memStatsBefore.Mallocs)/fixes, "allocs/fix")
+		})
+	}
+}
+
+func BenchmarkParseBRecord(b *testing.B) {
+	for _, tc := range []struct {
+		name  string
+		lines []string
+	}{
+		{
+			name: "simple",
+			lines: []string{
+				"HFDTE020508",
+				"B1005364607690N00610358EA0000001265",
+			},
+		},
+		{
+			name: "additions",
+			lines: []string{
+				"HFDTE020508",
+				"I083638FXA3941ENL4246TAS4751GSP5254TRT5559VAT6063OAT6467ACZ",
+				"B1005364607690N00610358EA00000012650050000000000000000000000000000000",
+			},
+		},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			data := []byte(strings.Join(tc.lines, "\r\n") + "\r\n")
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := igc.Parse(bytes.NewReader(data)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
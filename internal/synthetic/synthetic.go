@@ -0,0 +1,48 @@
+// Package synthetic generates synthetic tracks for tests.
+package synthetic
+
+import (
+	"time"
+
+	"github.com/twpayne/go-igc"
+	"github.com/twpayne/go-igc/geo"
+)
+
+// A Segment is a segment of a synthetic track recorded at 1 Hz.
+type Segment struct {
+	Duration      time.Duration
+	GroundSpeed   float64 // GroundSpeed is the ground speed in meters per second.
+	Track         float64 // Track is the track at the start of the segment in degrees.
+	VerticalSpeed float64 // VerticalSpeed is the vertical speed in meters per second.
+	TurnRate      float64 // TurnRate is the turn rate in degrees per second.
+	DriftSpeed    float64 // DriftSpeed is the speed of the wind in meters per second.
+	DriftTrack    float64 // DriftTrack is the direction in which the wind blows in degrees.
+}
+
+// BRecords returns one B record per second flying segments from origin at an
+// altitude of 1000m, starting at start.
+func BRecords(start time.Time, origin geo.Point, segments []Segment) []*igc.BRecord {
+	var bRecords []*igc.BRecord
+	p := origin
+	alt := 1000.0
+	t := start
+	for _, segment := range segments {
+		track := segment.Track
+		for range int(segment.Duration / time.Second) {
+			bRecords = append(bRecords, &igc.BRecord{
+				Time:          t,
+				Lat:           p.Lat,
+				Lon:           p.Lon,
+				Validity:      igc.Validity3D,
+				AltWGS84:      alt,
+				AltBarometric: alt,
+			})
+			p = geo.FAISphere.Destination(p, track, segment.GroundSpeed)
+			p = geo.FAISphere.Destination(p, segment.DriftTrack, segment.DriftSpeed)
+			track += segment.TurnRate
+			alt += segment.VerticalSpeed
+			t = t.Add(time.Second)
+		}
+	}
+	return bRecords
+}
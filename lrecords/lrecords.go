@@ -0,0 +1,164 @@
+// Package lrecords decodes vendor-specific L records.
+//
+// L records contain free-form text whose format depends on the flight
+// recorder that wrote them. Decoders for each dialect are registered by the
+// input of the L record, which is the three-letter manufacturer code following
+// the L.
+//
+// XCTrack L records, which span multiple consecutive L records, are decoded by
+// the xctrack subpackage.
+package lrecords
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/twpayne/go-igc"
+)
+
+// A Decoder decodes the text of an L record into a typed value. It returns nil
+// if the L record contains nothing of interest.
+type Decoder func(text string) (any, error)
+
+// An Event is a typed value decoded from an L record.
+type Event struct {
+	Line  int       // Line is the 1-based line number of the L record.
+	Time  time.Time // Time is the time of the preceding B record, if any.
+	Input string
+	Value any
+}
+
+// A BatteryState is the state of a battery.
+type BatteryState string
+
+// Battery states.
+const (
+	BatteryStateUnknown     BatteryState = "unknown"
+	BatteryStateCharging    BatteryState = "charging"
+	BatteryStateDischarging BatteryState = "discharging"
+)
+
+// A Battery is the state of the flight recorder's battery.
+type Battery struct {
+	State   BatteryState
+	Percent int      // Percent is the charge level, from 0 to 100.
+	Voltage *float64 // Voltage is the voltage in volts, or nil if it is not known.
+	Current *float64 // Current is the current in milliamps, or nil if it is not known.
+}
+
+// A FlightMode is the flight mode detected by the flight recorder, for
+// example onGround, takingOff, or soaring.
+type FlightMode string
+
+// A KeyValue is a generic key-value pair.
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// Settings are recorder settings from a single L record.
+type Settings struct {
+	Section  string // Section is the section of the settings, if any.
+	Settings []KeyValue
+}
+
+// Get returns the value of the setting with key and whether it exists.
+func (s *Settings) Get(key string) (string, bool) {
+	for _, setting := range s.Settings {
+		if setting.Key == key {
+			return setting.Value, true
+		}
+	}
+	return "", false
+}
+
+var (
+	decodersMutex   sync.RWMutex
+	decodersByInput = make(map[string]Decoder)
+)
+
+// Standard decoders.
+func init() {
+	decodersByInput["XCM"] = DecodeNaviter
+	decodersByInput["XNA"] = DecodeNaviter
+	decodersByInput["XSX"] = DecodeSkytraxx
+	decodersByInput["XVV"] = DecodeVectorVario
+}
+
+// DecoderByInput returns the decoder for L records with input and whether it
+// exists.
+func DecoderByInput(input string) (Decoder, bool) {
+	decodersMutex.RLock()
+	defer decodersMutex.RUnlock()
+	decoder, ok := decodersByInput[input]
+	return decoder, ok
+}
+
+// Register registers decoder for L records with input, replacing any existing
+// decoder. If decoder is nil then any existing decoder is removed.
+func Register(input string, decoder Decoder) {
+	decodersMutex.Lock()
+	defer decodersMutex.Unlock()
+	if decoder == nil {
+		delete(decodersByInput, input)
+		return
+	}
+	decodersByInput[input] = decoder
+}
+
+// Decode decodes the L records in igcFile with the registered decoders. L
+// records without a registered decoder are ignored. Errors decoding individual
+// L records are returned in errs.
+func Decode(igcFile *igc.IGC) ([]*Event, []error) {
+	var events []*Event
+	var errs []error
+	var t time.Time
+	for _, line := range igcFile.Lines {
+		switch record := line.Record.(type) {
+		case *igc.BRecord:
+			if record != nil {
+				t = record.Time
+			}
+		case *igc.LRecord:
+			if record == nil {
+				continue
+			}
+			decoder, ok := DecoderByInput(record.Input)
+			if !ok {
+				continue
+			}
+			value, err := decoder(record.Text)
+			if err != nil {
+				errs = append(errs, &igc.Error{
+					Line:     line.Number,
+					Offset:   line.Offset,
+					Severity: igc.SeverityError,
+					Err:      fmt.Errorf("L%s: %w", record.Input, err),
+				})
+				continue
+			}
+			if value == nil {
+				continue
+			}
+			events = append(events, &Event{
+				Line:  line.Number,
+				Time:  t,
+				Input: record.Input,
+				Value: value,
+			})
+		}
+	}
+	return events, errs
+}
+
+// Filter returns the events in events whose value has type T.
+func Filter[T any](events []*Event) []*Event {
+	var filteredEvents []*Event
+	for _, event := range events {
+		if _, ok := event.Value.(T); ok {
+			filteredEvents = append(filteredEvents, event)
+		}
+	}
+	return filteredEvents
+}
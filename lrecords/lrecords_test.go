@@ -0,0 +1,222 @@
+package lrecords_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-igc"
+	"github.com/twpayne/go-igc/lrecords"
+)
+
+func TestDecoders(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		decoder  lrecords.Decoder
+		text     string
+		expected any
+	}{
+		{
+			name:    "naviter_battery",
+			decoder: lrecords.DecodeNaviter,
+			text:    "::BAT:discharging,83%,3.9V,-120mA",
+			expected: &lrecords.Battery{
+				State:   lrecords.BatteryStateDischarging,
+				Percent: 83,
+				Voltage: ptr(3.9),
+				Current: ptr(-120.0),
+			},
+		},
+		{
+			name:    "naviter_battery_unknown_voltage",
+			decoder: lrecords.DecodeNaviter,
+			text:    "::BAT:charging,100%,-V,-mA",
+			expected: &lrecords.Battery{
+				State:   lrecords.BatteryStateCharging,
+				Percent: 100,
+			},
+		},
+		{
+			name:    "naviter_power",
+			decoder: lrecords.DecodeNaviter,
+			text:    "BD::POWER:0,3.73V,,31",
+			expected: &lrecords.Battery{
+				State:   lrecords.BatteryStateDischarging,
+				Percent: 31,
+				Voltage: ptr(3.73),
+			},
+		},
+		{
+			name:     "naviter_phase",
+			decoder:  lrecords.DecodeNaviter,
+			text:     "::PHASE:soaring",
+			expected: lrecords.FlightMode("soaring"),
+		},
+		{
+			name:    "naviter_air_traffic",
+			decoder: lrecords.DecodeNaviter,
+			text:    "::RA:PG,-60,FLR,FLR112CDC,3466,328,418",
+			expected: &lrecords.Traffic{
+				Type:     "PG",
+				Protocol: "FLR",
+				ID:       "FLR112CDC",
+				Fields:   []string{"PG", "-60", "FLR", "FLR112CDC", "3466", "328", "418"},
+			},
+		},
+		{
+			name:    "naviter_ground_traffic",
+			decoder: lrecords.DecodeNaviter,
+			text:    "::RG:WK,,0,FNT,FNT112881,5504,237,",
+			expected: &lrecords.Traffic{
+				Ground:   true,
+				Type:     "WK",
+				Protocol: "FNT",
+				ID:       "FNT112881",
+				Fields:   []string{"WK", "", "0", "FNT", "FNT112881", "5504", "237", ""},
+			},
+		},
+		{
+			name:    "naviter_key_value",
+			decoder: lrecords.DecodeNaviter,
+			text:    "::AVGSATSNR:31",
+			expected: &lrecords.KeyValue{
+				Key:   "AVGSATSNR",
+				Value: "31",
+			},
+		},
+		{
+			name:    "naviter_settings",
+			decoder: lrecords.DecodeNaviter,
+			text:    "TSK,NoStart=10:00:00,Short=true",
+			expected: &lrecords.Settings{
+				Section: "TSK",
+				Settings: []lrecords.KeyValue{
+					{Key: "NoStart", Value: "10:00:00"},
+					{Key: "Short", Value: "true"},
+				},
+			},
+		},
+		{
+			name:     "skytraxx_acceleration",
+			decoder:  lrecords.DecodeSkytraxx,
+			text:     "1.07",
+			expected: lrecords.Acceleration(1.07),
+		},
+		{
+			name:    "skytraxx_settings",
+			decoder: lrecords.DecodeSkytraxx,
+			text:    ";S1;FL:11.5;TG:171;TC:1.0",
+			expected: &lrecords.Settings{
+				Section: "S1",
+				Settings: []lrecords.KeyValue{
+					{Key: "FL", Value: "11.5"},
+					{Key: "TG", Value: "171"},
+					{Key: "TC", Value: "1.0"},
+				},
+			},
+		},
+		{
+			name:    "vector_vario",
+			decoder: lrecords.DecodeVectorVario,
+			text:    "T+256H531S000W180014N+000G100",
+			expected: &lrecords.VectorVarioData{
+				Values: map[string]int{
+					"T": 256,
+					"H": 531,
+					"S": 0,
+					"W": 180014,
+					"N": 0,
+					"G": 100,
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := tc.decoder(tc.text)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	igcFile, err := igc.ParseLines([]string{
+		"HFDTE020508",
+		"B1005364607690N00610358EA0000001265",
+		"LXNA::BAT:discharging,83%,3.9V,-120mA",
+		"LXNA::PHASE:onGround",
+		"B1005374607690N00610358EA0000001265",
+		"LXNA::BAT:discharging,82%,3.9V,-120mA",
+		"LXNA::BAT:discharging,%,3.9V,-120mA",
+		"LABCunknown",
+	})
+	assert.NoError(t, err)
+	events, errs := lrecords.Decode(igcFile)
+
+	assert.Equal(t, 1, len(errs))
+	var igcErr *igc.Error
+	assert.True(t, errors.As(errs[0], &igcErr))
+	assert.Equal(t, 7, igcErr.Line)
+
+	time1 := time.Date(2008, time.May, 2, 10, 5, 36, 0, time.UTC)
+	batteryEvents := lrecords.Filter[*lrecords.Battery](events)
+	assert.Equal(t, 2, len(batteryEvents))
+	assert.Equal(t, 3, batteryEvents[0].Line)
+	assert.Equal(t, time1, batteryEvents[0].Time)
+	assert.Equal(t, "XNA", batteryEvents[0].Input)
+	assert.Equal(t, 83, batteryEvents[0].Value.(*lrecords.Battery).Percent) //nolint:forcetypeassert
+	assert.Equal(t, time1.Add(time.Second), batteryEvents[1].Time)
+	assert.Equal(t, 82, batteryEvents[1].Value.(*lrecords.Battery).Percent) //nolint:forcetypeassert
+
+	assert.Equal(t, []*lrecords.Event{
+		{Line: 4, Time: time1, Input: "XNA", Value: lrecords.FlightMode("onGround")},
+	}, lrecords.Filter[lrecords.FlightMode](events))
+}
+
+func TestRegister(t *testing.T) {
+	igcFile, err := igc.ParseLines([]string{
+		"LABCunknown",
+	})
+	assert.NoError(t, err)
+
+	_, ok := lrecords.DecoderByInput("ABC")
+	assert.False(t, ok)
+	events, errs := lrecords.Decode(igcFile)
+	assert.Equal(t, 0, len(events))
+	assert.Equal(t, 0, len(errs))
+
+	lrecords.Register("ABC", func(text string) (any, error) {
+		return strings.ToUpper(text), nil
+	})
+	defer lrecords.Register("ABC", nil)
+	events, errs = lrecords.Decode(igcFile)
+	assert.Equal(t, []*lrecords.Event{
+		{Line: 1, Input: "ABC", Value: "UNKNOWN"},
+	}, events)
+	assert.Equal(t, 0, len(errs))
+}
+
+func TestDecodeTestdata(t *testing.T) {
+	filenames, err := filepath.Glob("../testdata/*.igc")
+	assert.NoError(t, err)
+	for _, filename := range filenames {
+		t.Run(filepath.Base(filename), func(t *testing.T) {
+			file, err := os.Open(filename)
+			assert.NoError(t, err)
+			defer file.Close()
+			igcFile, err := igc.Parse(file)
+			assert.NoError(t, err)
+			_, errs := lrecords.Decode(igcFile)
+			assert.Equal(t, 0, len(errs))
+		})
+	}
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
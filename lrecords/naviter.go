@@ -0,0 +1,168 @@
+package lrecords
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A Traffic is another aircraft or ground station received by the flight
+// recorder over FLARM or FANET.
+type Traffic struct {
+	Ground   bool   // Ground is true for ground traffic (RG) and false for air traffic (RA).
+	Type     string // Type is the type of the traffic, for example PG for paraglider or WK for walking.
+	Protocol string // Protocol is the protocol, for example FLR for FLARM or FNT for FANET.
+	ID       string
+	Fields   []string // Fields are all the fields of the L record.
+}
+
+var errTooFewFields = errors.New("too few fields")
+
+// DecodeNaviter decodes L records written by Naviter's SeeYou Navigator and
+// compatible flight recorders, for example:
+//
+//	LXNA::BAT:discharging,83%,3.9V,-120mA
+//	LXNA::PHASE:soaring
+//	LXCM::RA:PG,-60,FLR,FLR112CDC,3466,328,418
+//	LXCMBD::POWER:0,3.73V,,31
+//	LXCMOZN=0,Style=3,R1=1.000km,A1=180
+//
+// Battery records are decoded as *Battery, flight phases as FlightMode,
+// traffic records as *Traffic, key=value records as *Settings, and all other
+// key:value records as *KeyValue.
+func DecodeNaviter(text string) (any, error) {
+	prefix, rest, ok := strings.Cut(text, "::")
+	if !ok {
+		return decodeSettings(text, ","), nil
+	}
+	key, value, ok := strings.Cut(rest, ":")
+	if !ok {
+		key, value, _ = strings.Cut(rest, ",")
+	}
+	if prefix != "" {
+		key = prefix + "::" + key
+	}
+	switch key {
+	case "BAT":
+		return decodeNaviterBattery(value)
+	case "BD::POWER":
+		return decodeNaviterPower(value)
+	case "PHASE":
+		return FlightMode(value), nil
+	case "RA", "RG":
+		fields := strings.Split(value, ",")
+		traffic := &Traffic{
+			Ground: key == "RG",
+			Fields: fields,
+		}
+		switch {
+		case traffic.Ground && len(fields) >= 5:
+			traffic.Type, traffic.Protocol, traffic.ID = fields[0], fields[3], fields[4]
+		case !traffic.Ground && len(fields) >= 4:
+			traffic.Type, traffic.Protocol, traffic.ID = fields[0], fields[2], fields[3]
+		default:
+			return nil, fmt.Errorf("%s: %w", key, errTooFewFields)
+		}
+		return traffic, nil
+	default:
+		return &KeyValue{
+			Key:   key,
+			Value: value,
+		}, nil
+	}
+}
+
+// decodeNaviterBattery decodes value of a BAT L record, for example
+// "discharging,83%,3.9V,-120mA" or "charging,100%,-V,-mA".
+func decodeNaviterBattery(value string) (*Battery, error) {
+	fields := strings.Split(value, ",")
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("BAT: %w", errTooFewFields)
+	}
+	percent, err := strconv.Atoi(strings.TrimSuffix(fields[1], "%"))
+	if err != nil {
+		return nil, fmt.Errorf("BAT: %w", err)
+	}
+	battery := &Battery{
+		State:   BatteryState(fields[0]),
+		Percent: percent,
+	}
+	if len(fields) > 2 {
+		if battery.Voltage, err = parseOptionalFloat(fields[2], "V"); err != nil {
+			return nil, fmt.Errorf("BAT: %w", err)
+		}
+	}
+	if len(fields) > 3 {
+		if battery.Current, err = parseOptionalFloat(fields[3], "mA"); err != nil {
+			return nil, fmt.Errorf("BAT: %w", err)
+		}
+	}
+	return battery, nil
+}
+
+// decodeNaviterPower decodes the value of a BD::POWER L record, for example
+// "0,3.73V,,31", where the fields are whether the battery is charging, the
+// voltage, the current, and the charge level.
+func decodeNaviterPower(value string) (*Battery, error) {
+	fields := strings.Split(value, ",")
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("BD::POWER: %w", errTooFewFields)
+	}
+	battery := &Battery{}
+	switch fields[0] {
+	case "0":
+		battery.State = BatteryStateDischarging
+	case "1":
+		battery.State = BatteryStateCharging
+	default:
+		battery.State = BatteryStateUnknown
+	}
+	var err error
+	if battery.Voltage, err = parseOptionalFloat(fields[1], "V"); err != nil {
+		return nil, fmt.Errorf("BD::POWER: %w", err)
+	}
+	if battery.Current, err = parseOptionalFloat(fields[2], "mA"); err != nil {
+		return nil, fmt.Errorf("BD::POWER: %w", err)
+	}
+	if battery.Percent, err = strconv.Atoi(fields[3]); err != nil {
+		return nil, fmt.Errorf("BD::POWER: %w", err)
+	}
+	return battery, nil
+}
+
+// decodeSettings decodes settings separated by sep, for example
+// "OZN=0,Style=3,R1=1.000km". A leading field without a value is the section.
+func decodeSettings(text, sep string) *Settings {
+	settings := &Settings{}
+	for i, field := range strings.Split(text, sep) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			key, value, ok = strings.Cut(field, ":")
+		}
+		switch {
+		case !ok && i == 0:
+			settings.Section = field
+		case field != "":
+			settings.Settings = append(settings.Settings, KeyValue{
+				Key:   key,
+				Value: value,
+			})
+		}
+	}
+	return settings
+}
+
+// parseOptionalFloat parses a float with unit suffix from s. It returns nil if
+// s is empty or contains only a dash.
+func parseOptionalFloat(s, suffix string) (*float64, error) {
+	s = strings.TrimSuffix(s, suffix)
+	if s == "" || s == "-" {
+		return nil, nil //nolint:nilnil
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
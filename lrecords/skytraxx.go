@@ -0,0 +1,29 @@
+package lrecords
+
+import (
+	"strconv"
+	"strings"
+)
+
+// An Acceleration is a total acceleration in g.
+type Acceleration float64
+
+// DecodeSkytraxx decodes L records written by Skytraxx flight recorders, for
+// example:
+//
+//	LXSX1.07
+//	LXSX;S1;XS:2;XD:6.00;SP:42.6;MC:2.4;MS:-2.5;NB:3245
+//	LXSX;MC:2.2;MS:-7.2;MSP:65;Dist:14.9;GF:1.4
+//
+// Bare numbers are decoded as the Acceleration at the preceding fix and
+// semicolon-separated records as *Settings.
+func DecodeSkytraxx(text string) (any, error) {
+	if rest, ok := strings.CutPrefix(text, ";"); ok {
+		return decodeSettings(rest, ";"), nil
+	}
+	acceleration, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, err
+	}
+	return Acceleration(acceleration), nil
+}
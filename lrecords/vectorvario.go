@@ -0,0 +1,56 @@
+package lrecords
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// A VectorVarioData is the sensor data recorded by a Vector Vario at each fix.
+// Values are keyed by their single-letter code, for example T, H, S, W, N, and
+// G, and are the raw integers recorded.
+type VectorVarioData struct {
+	Values map[string]int
+}
+
+var errInvalidVectorVarioData = errors.New("invalid Vector Vario data")
+
+// DecodeVectorVario decodes L records written by Vector Vario flight
+// recorders, for example:
+//
+//	LXVVT+256H531S000W180014N+000G100
+//	LXVVIGC+:1
+//
+// Records of single-letter codes followed by signed integers are decoded as
+// *VectorVarioData and all other key:value records as *KeyValue.
+func DecodeVectorVario(text string) (any, error) {
+	if key, value, ok := strings.Cut(text, ":"); ok {
+		return &KeyValue{
+			Key:   key,
+			Value: value,
+		}, nil
+	}
+	data := &VectorVarioData{
+		Values: make(map[string]int),
+	}
+	for text != "" {
+		code := text[:1]
+		if code[0] < 'A' || 'Z' < code[0] {
+			return nil, errInvalidVectorVarioData
+		}
+		end := 1
+		if end < len(text) && (text[end] == '+' || text[end] == '-') {
+			end++
+		}
+		for end < len(text) && '0' <= text[end] && text[end] <= '9' {
+			end++
+		}
+		value, err := strconv.Atoi(text[1:end])
+		if err != nil {
+			return nil, errInvalidVectorVarioData
+		}
+		data.Values[code] = value
+		text = text[end:]
+	}
+	return data, nil
+}
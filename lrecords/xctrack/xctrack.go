@@ -0,0 +1,429 @@
+// Package xctrack decodes the L records written by XCTrack.
+//
+// XCTrack writes L records with the XCT source. Device information and
+// statistics are base64-encoded JSON, and sensor configurations are plain
+// text, split over multiple consecutive L records. Other L records are plain
+// text on a single L record.
+package xctrack
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/twpayne/go-igc"
+)
+
+// Input is the input of XCTrack L records.
+const Input = "XCT"
+
+// Keys.
+const (
+	KeyActivity         = "ACTIVITY"
+	KeyDevice           = "DEVICE"
+	KeyEarthModel       = "EARTHMODEL"
+	KeyFAICIVLCompliant = "FAICIVLCOMPLIANT"
+	KeyReportedAlt      = "REPORTEDALT"
+	KeySensor           = "SENSOR"
+	KeySensorChange     = "SENSORCHG"
+	KeyStats            = "STATS"
+)
+
+// An ActivityType is an activity type reported by Android's activity
+// recognition.
+type ActivityType string
+
+// Activity types.
+const (
+	ActivityTypeInVehicle ActivityType = "IN_VEHICLE"
+	ActivityTypeOnBicycle ActivityType = "ON_BICYCLE"
+	ActivityTypeOnFoot    ActivityType = "ON_FOOT"
+	ActivityTypeRunning   ActivityType = "RUNNING"
+	ActivityTypeStill     ActivityType = "STILL"
+	ActivityTypeTilting   ActivityType = "TILTING"
+	ActivityTypeUnknown   ActivityType = "UNKNOWN"
+	ActivityTypeWalking   ActivityType = "WALKING"
+)
+
+// An Activity is an activity reported by Android's activity recognition.
+type Activity struct {
+	Line       int       // Line is the 1-based line number of the L record.
+	Time       time.Time // Time is the time of the preceding B record, if any.
+	Type       ActivityType
+	Confidence int // Confidence is the confidence of the activity, from 0 to 100.
+}
+
+// A Device is information about the device running XCTrack.
+type Device struct {
+	Line         int
+	Capabilities DeviceCapabilities `json:"capabilities"`
+	Device       DeviceInfo         `json:"device"`
+	XCTrack      Version            `json:"xctrack"`
+	Raw          json.RawMessage    `json:"-"`
+}
+
+// DeviceCapabilities are the capabilities of a device.
+type DeviceCapabilities struct {
+	MemClass      int  `json:"memClass"`
+	MemLargeClass int  `json:"memLargeClass"`
+	NaN           bool `json:"nan"`
+}
+
+// DeviceInfo is information about a device.
+type DeviceInfo struct {
+	AndroidID     string  `json:"androidId"`
+	Board         string  `json:"board"`
+	BuildID       string  `json:"buildId"`
+	CPUABI        string  `json:"cpuABI"`
+	CPUABI2       string  `json:"cpuABI2"`
+	DevQueueBuild string  `json:"devQueueBuild"`
+	DeviceString  string  `json:"deviceString"`
+	Display       Display `json:"display"`
+	Fingerprint   string  `json:"fingerprint"`
+	Hardware      string  `json:"hardware"`
+	Manufacturer  string  `json:"manufacturer"`
+	Model         string  `json:"model"`
+	Product       string  `json:"product"`
+	SDK           int     `json:"sdk"`
+	Timezone      string  `json:"timezone"`
+}
+
+// A Display is a device's display.
+type Display struct {
+	Height int     `json:"h"`
+	Width  int     `json:"w"`
+	MM     float64 `json:"mm"`
+}
+
+// A Version is the version of XCTrack.
+type Version struct {
+	VersionCode int    `json:"versionCode"`
+	VersionName string `json:"versionName"`
+}
+
+// A ReportedAltitude is an altitude correction reported by XCTrack.
+type ReportedAltitude struct {
+	Line int
+	Time time.Time
+	GPS  int
+	Baro int
+}
+
+// A SensorConfig is the configuration of the sensors used by XCTrack.
+type SensorConfig struct {
+	Line            int
+	Time            time.Time
+	Sensors         []string // Sensors are the external sensors, for example SENSOR_BT.
+	ExternalGPS     bool
+	ExternalBaro    bool
+	InternalBaro    string // InternalBaro is the name of the internal barometer, or false if there is none.
+	InternalGPSNMEA bool
+	Text            string // Text is the reassembled text of the L records.
+}
+
+// A SensorChange is a change of sensors.
+type SensorChange struct {
+	Line int
+	Time time.Time
+	Text string
+}
+
+// Stats are XCTrack's internal statistics.
+type Stats struct {
+	Line  int
+	Time  time.Time
+	Stats struct {
+		TerrainBmpCache struct {
+			Alloc       int `json:"alloc"`
+			AllocFail   int `json:"allocFail"`
+			MaxSize     int `json:"maxsize"`
+			MaxSizeInit int `json:"maxsizeInit"`
+			Reuse       int `json:"reuse"`
+		} `json:"terrainBmpCache"`
+		GPS struct {
+			AltFixGeoidDiff float64 `json:"altFixGeoidDiff"`
+			AltFixMethod    struct {
+				DiffAPIGeoid        int `json:"diffApiGeoid"`
+				DiffAPINoCorrection int `json:"diffApiNoCorrection"`
+			} `json:"altFixMethod"`
+			APICount        int `json:"apiCnt"`
+			BearingComputed int `json:"bearingComputed"`
+			NMEACount       int `json:"nmeaCnt"`
+			SpeedComputed   int `json:"speedComputed"`
+		} `json:"GPS"`
+		OptiStats struct {
+			OptiLCount  int   `json:"optiLCount"`
+			OptiTimeMms int64 `json:"optiTimeMms"`
+		} `json:"optiStats"`
+	} `json:"stats"`
+	Raw json.RawMessage `json:"-"`
+}
+
+// An Unknown is an XCTrack L record with an unknown key.
+type Unknown struct {
+	Line  int
+	Time  time.Time
+	Key   string
+	Value string
+}
+
+// A Flight is the information decoded from the XCTrack L records in an IGC
+// file.
+type Flight struct {
+	Device           *Device // Device is the last device, or nil if there is none.
+	EarthModel       string
+	FAICIVLCompliant *bool // FAICIVLCompliant is nil if it is not reported.
+	Activities       []*Activity
+	ReportedAlts     []*ReportedAltitude
+	SensorConfigs    []*SensorConfig
+	SensorChanges    []*SensorChange
+	Stats            []*Stats
+	Unknowns         []*Unknown
+	Errs             []error
+}
+
+// multiLineKeys are the keys whose values are split over multiple consecutive
+// L records.
+var multiLineKeys = map[string]bool{
+	KeyDevice: true,
+	KeySensor: true,
+	KeyStats:  true,
+}
+
+// A run is a sequence of consecutive L records with the same key whose values
+// are concatenated, or a single L record.
+type run struct {
+	lines  []*igc.Line
+	time   time.Time
+	key    string
+	values []string
+}
+
+// Decode decodes the XCTrack L records in igcFile. Errors decoding individual L
+// records are returned in the Flight's Errs field.
+func Decode(igcFile *igc.IGC) *Flight {
+	flight := &Flight{}
+	var t time.Time
+	var current *run
+	for _, line := range igcFile.Lines {
+		switch record := line.Record.(type) {
+		case nil:
+			if line.Err == nil {
+				// Empty lines do not interrupt runs.
+				continue
+			}
+		case *igc.BRecord:
+			if record != nil {
+				t = record.Time
+			}
+		case *igc.LRecord:
+			if record == nil || record.Input != Input {
+				break
+			}
+			key, value, _ := strings.Cut(record.Text, " ")
+			if current != nil && current.key == key && multiLineKeys[key] {
+				current.lines = append(current.lines, line)
+				current.values = append(current.values, value)
+				continue
+			}
+			flight.decodeRun(current)
+			current = &run{
+				lines:  []*igc.Line{line},
+				time:   t,
+				key:    key,
+				values: []string{value},
+			}
+			continue
+		}
+		flight.decodeRun(current)
+		current = nil
+	}
+	flight.decodeRun(current)
+	return flight
+}
+
+// ActivityTransitions returns the activities whose type differs from the type
+// of the preceding activity.
+func (f *Flight) ActivityTransitions() []*Activity {
+	var transitions []*Activity
+	for i, activity := range f.Activities {
+		if i == 0 || activity.Type != f.Activities[i-1].Type {
+			transitions = append(transitions, activity)
+		}
+	}
+	return transitions
+}
+
+// decodeRun decodes r and adds the result to f.
+func (f *Flight) decodeRun(r *run) {
+	if r == nil {
+		return
+	}
+	if err := f.decodeMultiLineRun(r); err != nil {
+		f.addError(r.lines[0], r.key, err)
+	}
+}
+
+// addError adds an error decoding the L record with key at line to f.
+func (f *Flight) addError(line *igc.Line, key string, err error) {
+	f.Errs = append(f.Errs, &igc.Error{
+		Line:     line.Number,
+		Offset:   line.Offset,
+		Severity: igc.SeverityError,
+		Err:      fmt.Errorf("%s: %w", key, err),
+	})
+}
+
+// decodeMultiLineRun decodes r, whose values are concatenated.
+func (f *Flight) decodeMultiLineRun(r *run) error {
+	text := strings.Join(r.values, "")
+	line := r.lines[0].Number
+	switch r.key {
+	case KeyActivity:
+		activityType, confidenceStr, _ := strings.Cut(text, " ")
+		confidence, err := strconv.Atoi(confidenceStr)
+		if err != nil {
+			return err
+		}
+		f.Activities = append(f.Activities, &Activity{
+			Line:       line,
+			Time:       r.time,
+			Type:       ActivityType(activityType),
+			Confidence: confidence,
+		})
+	case KeyDevice:
+		return decodeJSONPayloads(text, func(raw json.RawMessage) error {
+			device := &Device{
+				Line: line,
+				Raw:  raw,
+			}
+			if err := json.Unmarshal(raw, device); err != nil {
+				return err
+			}
+			f.Device = device
+			return nil
+		})
+	case KeyEarthModel:
+		f.EarthModel = text
+	case KeyFAICIVLCompliant:
+		compliant, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		f.FAICIVLCompliant = &compliant
+	case KeyReportedAlt:
+		reportedAlt := &ReportedAltitude{
+			Line: line,
+			Time: r.time,
+		}
+		if _, err := fmt.Sscanf(text, "GPS:%d, BARO:%d", &reportedAlt.GPS, &reportedAlt.Baro); err != nil {
+			return err
+		}
+		f.ReportedAlts = append(f.ReportedAlts, reportedAlt)
+	case KeySensor:
+		sensorConfig, err := parseSensorConfig(text)
+		if err != nil {
+			return err
+		}
+		sensorConfig.Line = line
+		sensorConfig.Time = r.time
+		f.SensorConfigs = append(f.SensorConfigs, sensorConfig)
+	case KeySensorChange:
+		f.SensorChanges = append(f.SensorChanges, &SensorChange{
+			Line: line,
+			Time: r.time,
+			Text: text,
+		})
+	case KeyStats:
+		return decodeJSONPayloads(text, func(raw json.RawMessage) error {
+			stats := &Stats{
+				Line: line,
+				Time: r.time,
+				Raw:  raw,
+			}
+			if err := json.Unmarshal(raw, stats); err != nil {
+				return err
+			}
+			f.Stats = append(f.Stats, stats)
+			return nil
+		})
+	default:
+		f.Unknowns = append(f.Unknowns, &Unknown{
+			Line:  line,
+			Time:  r.time,
+			Key:   r.key,
+			Value: text,
+		})
+	}
+	return nil
+}
+
+// decodeJSONPayloads decodes the base64-encoded JSON values in text and calls
+// f for each.
+func decodeJSONPayloads(text string, f func(json.RawMessage) error) error {
+	data, err := base64.StdEncoding.DecodeString(text)
+	if err != nil {
+		if data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(text, "=")); err != nil {
+			return err
+		}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var raw json.RawMessage
+		switch err := decoder.Decode(&raw); {
+		case errors.Is(err, io.EOF):
+			return nil
+		case err != nil:
+			return err
+		}
+		if err := f(raw); err != nil {
+			return err
+		}
+	}
+}
+
+// parseSensorConfig parses the text of SENSOR L records, for example
+// "Sensors: SENSOR_BT, SENSOR_USB, external GPS:false, external baro:true,
+// internal baro:false, internal GPS nmea: true". Unknown fields are ignored.
+func parseSensorConfig(text string) (*SensorConfig, error) {
+	sensorConfig := &SensorConfig{
+		Text: text,
+	}
+	for field := range strings.SplitSeq(text, ",") {
+		key, value, ok := strings.Cut(field, ":")
+		if !ok {
+			// Fields without a key are additional sensors.
+			if sensor := strings.TrimSpace(field); sensor != "" {
+				sensorConfig.Sensors = append(sensorConfig.Sensors, sensor)
+			}
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		var err error
+		switch key {
+		case "Sensors":
+			if value != "" {
+				sensorConfig.Sensors = append(sensorConfig.Sensors, value)
+			}
+		case "external GPS":
+			sensorConfig.ExternalGPS, err = strconv.ParseBool(value)
+		case "external baro":
+			sensorConfig.ExternalBaro, err = strconv.ParseBool(value)
+		case "internal baro":
+			sensorConfig.InternalBaro = value
+		case "internal GPS nmea":
+			sensorConfig.InternalGPSNMEA, err = strconv.ParseBool(value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return sensorConfig, nil
+}
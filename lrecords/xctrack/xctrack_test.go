@@ -0,0 +1,127 @@
+package xctrack_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-igc"
+	"github.com/twpayne/go-igc/lrecords/xctrack"
+)
+
+func TestDecode(t *testing.T) {
+	igcFile, err := igc.ParseLines([]string{
+		"HFDTE020508",
+		"LXCTEARTHMODEL WGS84",
+		"LXCTFAICIVLCOMPLIANT true",
+		"LXCTSENSOR Sensors: SENSOR_BT, SENSOR_USB, external GPS:false, external baro:true, internal baro",
+		"LXCTSENSOR :false, internal GPS nmea: true",
+		"B1005364607690N00610358EA0000001265",
+		"LXCTACTIVITY STILL 100",
+		"LXCTACTIVITY ON_FOOT 80",
+		"B1005374607690N00610358EA0000001265",
+		"LXCTACTIVITY ON_FOOT 90",
+		"LXCTREPORTEDALT GPS:-1, BARO:-11",
+		"LXCTREPORTEDALT GPS:-2, BARO:-12",
+		"LXCTFOO bar",
+		"LXCTFOO baz",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(igcFile.Errs))
+
+	flight := xctrack.Decode(igcFile)
+	assert.Equal(t, 0, len(flight.Errs))
+	assert.Equal(t, "WGS84", flight.EarthModel)
+	assert.NotZero(t, flight.FAICIVLCompliant)
+	assert.True(t, *flight.FAICIVLCompliant)
+
+	assert.Equal(t, []*xctrack.SensorConfig{
+		{
+			Line:            4,
+			Sensors:         []string{"SENSOR_BT", "SENSOR_USB"},
+			ExternalBaro:    true,
+			InternalBaro:    "false",
+			InternalGPSNMEA: true,
+			Text:            "Sensors: SENSOR_BT, SENSOR_USB, external GPS:false, external baro:true, internal baro:false, internal GPS nmea: true",
+		},
+	}, flight.SensorConfigs)
+
+	time1 := time.Date(2008, time.May, 2, 10, 5, 36, 0, time.UTC)
+	time2 := time1.Add(time.Second)
+	assert.Equal(t, []*xctrack.Activity{
+		{Line: 7, Time: time1, Type: xctrack.ActivityTypeStill, Confidence: 100},
+		{Line: 8, Time: time1, Type: xctrack.ActivityTypeOnFoot, Confidence: 80},
+		{Line: 10, Time: time2, Type: xctrack.ActivityTypeOnFoot, Confidence: 90},
+	}, flight.Activities)
+	assert.Equal(t, []*xctrack.Activity{
+		flight.Activities[0],
+		flight.Activities[1],
+	}, flight.ActivityTransitions())
+
+	assert.Equal(t, []*xctrack.ReportedAltitude{
+		{Line: 11, Time: time2, GPS: -1, Baro: -11},
+		{Line: 12, Time: time2, GPS: -2, Baro: -12},
+	}, flight.ReportedAlts)
+
+	assert.Equal(t, []*xctrack.Unknown{
+		{Line: 13, Time: time2, Key: "FOO", Value: "bar"},
+		{Line: 14, Time: time2, Key: "FOO", Value: "baz"},
+	}, flight.Unknowns)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	igcFile, err := igc.ParseLines([]string{
+		"LXCTACTIVITY STILL high",
+		"LXCTFAICIVLCOMPLIANT maybe",
+		"LXCTDEVICE !!!",
+	})
+	assert.NoError(t, err)
+	flight := xctrack.Decode(igcFile)
+	assert.Equal(t, 3, len(flight.Errs))
+	for i, err := range flight.Errs {
+		var igcErr *igc.Error
+		assert.True(t, errors.As(err, &igcErr))
+		assert.Equal(t, i+1, igcErr.Line)
+	}
+}
+
+func TestDecodeTestdata(t *testing.T) {
+	filenames, err := filepath.Glob("../../testdata/*.igc")
+	assert.NoError(t, err)
+	devices := 0
+	for _, filename := range filenames {
+		t.Run(filepath.Base(filename), func(t *testing.T) {
+			file, err := os.Open(filename)
+			assert.NoError(t, err)
+			defer file.Close()
+			igcFile, err := igc.Parse(file)
+			assert.NoError(t, err)
+			flight := xctrack.Decode(igcFile)
+			assert.Equal(t, 0, len(flight.Errs))
+			if flight.Device != nil {
+				devices++
+			}
+		})
+	}
+	assert.NotEqual(t, 0, devices)
+}
+
+func TestDecodeDevice(t *testing.T) {
+	file, err := os.Open("../../testdata/0004.igc")
+	assert.NoError(t, err)
+	defer file.Close()
+	igcFile, err := igc.Parse(file)
+	assert.NoError(t, err)
+	flight := xctrack.Decode(igcFile)
+	assert.Equal(t, 0, len(flight.Errs))
+	assert.NotZero(t, flight.Device)
+	assert.Equal(t, "samsung", flight.Device.Device.Manufacturer)
+	assert.Equal(t, "SM-G780G", flight.Device.Device.Model)
+	assert.Equal(t, 2, len(flight.Stats))
+	assert.NotZero(t, flight.FAICIVLCompliant)
+	assert.False(t, *flight.FAICIVLCompliant)
+}
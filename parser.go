@@ -1,73 +1,20 @@
 package igc
 
 import (
-	"bufio"
 	"bytes"
 	"errors"
-	"fmt"
-	"io"
 	"regexp"
-	"strconv"
 	"time"
-	"unicode"
 	"unicode/utf8"
 )
 
-var errNoDate = errors.New("no date")
-
-type atoiSyntaxError struct {
-	num string
-}
-
-func (e *atoiSyntaxError) Error() string {
-	return strconv.Quote(e.num) + ": syntax error"
-}
-
-type invalidAdditionError struct {
-	addition RecordAddition
-	message  string
-}
-
-func (e *invalidAdditionError) Error() string {
-	return e.addition.TLC + ": " + e.message
-}
-
-type invalidRecordError byte
-
-func (e invalidRecordError) Error() string {
-	return "invalid " + string(e) + " record"
-}
-
-var errInvalidUTF8Sequence = errors.New("invalid UTF-8 sequence")
-
-type missingAdditionError struct {
-	addition RecordAddition
-}
-
-func (e *missingAdditionError) Error() string {
-	return "missing " + e.addition.TLC + " addition"
-}
-
-type unknownRecordTypeError byte
-
-func (e unknownRecordTypeError) Error() string {
-	if unicode.IsPrint(rune(e)) {
-		return string(e) + ": unknown record type"
-	}
-	return fmt.Sprintf(`"\x%02X": unknown record type`, byte(e))
-}
-
 var (
-	invalidCharsRx = regexp.MustCompile(`([^\x20\x22-\x23\x25-\x29\x2b-\x5b\x5d\x5f-\x7d])`)
-
 	aRecordRx                  = regexp.MustCompile(`\AA([A-Z]{3})(.*)\z`)
-	bRecordRx                  = regexp.MustCompile(`\AB(\d{2})(\d{2})(\d{2})(\d{2})(\d{5})([NS])(\d{3})(\d{5})([EW])([AV])([0-9\-]\d{4})([0-9\-]\d{4})(.*)\z`)
 	cRecordDeclarationRx       = regexp.MustCompile(`\AC(\d{2})(\d{2})(\d{2})(\d{2})(\d{2})(\d{2})(\d{2})(\d{2})(\d{2})(\d{4})([0-9\-]\d)(.*)\z`)
 	cRecordWaypointRx          = regexp.MustCompile(`\AC(\d{2})(\d{5})([NS])(\d{3})(\d{5})([EW])(.*)\z`)
 	dRecordRx                  = regexp.MustCompile(`\AD([12])(\d{4})\z`)
 	eRecordRx                  = regexp.MustCompile(`\AE(\d{2})(\d{2})(\d{2})([A-Z]{3})(.*)\z`)
 	eRecordWithoutTLCRx        = regexp.MustCompile(`\AE(\d{2})(\d{2})(\d{2})(.*)\z`)
-	fRecordRx                  = regexp.MustCompile(`\AF(\d{2})(\d{2})(\d{2})((?:\d{2})*)\z`)
 	hRecordRx                  = regexp.MustCompile(`\AH([FOP])([0-9A-Z]{3})(.*?)(?::(.*))?\z`)
 	hRecordWithInvalidSourceRx = regexp.MustCompile(`\AH([A-Z])([0-9A-Z]{3})(.*?)(?::(.*))?\z`)
 	hfdteRecordRx              = regexp.MustCompile(`\AHFDTE(\d{2})(\d{2})(\d{2})\z`)
@@ -75,15 +22,20 @@ var (
 	hffxaRecordRx              = regexp.MustCompile(`\AHFFXA(\d+)\z`)
 	gRecordRx                  = regexp.MustCompile(`\AG(.*)\z`)
 	ijmRecordRx                = regexp.MustCompile(`\A[IJM](\d{2})((?:\d{4}[A-Z]{3})*)\z`)
-	knRecordRx                 = regexp.MustCompile(`\A[KN](\d{2})(\d{2})(\d{2})(.*)\z`)
 	lRecordRx                  = regexp.MustCompile(`\AL([A-Z]{3})(.*)\z`)
 	lRecordWithoutTLCRx        = regexp.MustCompile(`\AL(.*)\z`)
 )
 
 type HRecordValueDecoder func([]byte) (string, error)
 
+// An AdditionDecoder decodes the value of a record addition. data is only valid
+// for the duration of the call and must not be retained.
+type AdditionDecoder func(data []byte) (any, error)
+
 type parser struct {
-	allowInvalidChars      bool
+	severities             map[ErrorCode]Severity
+	minSeverity            Severity
+	additionDecoders       map[string]AdditionDecoder
 	allowOutOfOrderRecords time.Duration
 	date                   time.Time
 	hRecordValueDecoder    HRecordValueDecoder
@@ -101,14 +53,33 @@ type parser struct {
 	fracSecondMul          int
 	kRecordAdditions       []RecordAddition
 	nRecordAdditions       []RecordAddition
+	batch                  bool // batch is whether records are allocated in batches.
+	additionsAllocator     additionsAllocator
+	bRecords               []BRecord
 }
 
 type ParseOption func(*parser)
 
-func WithAllowInvalidChars(allowInvalidChars bool) ParseOption {
+// WithAdditionDecoder sets the decoder for B, K, and N record additions with
+// three-letter code tlc. Decoded values are available with Additions.Decoded
+// and replace the default integer values.
+func WithAdditionDecoder(tlc string, decoder AdditionDecoder) ParseOption {
 	return func(p *parser) {
-		p.allowInvalidChars = allowInvalidChars
+		if p.additionDecoders == nil {
+			p.additionDecoders = make(map[string]AdditionDecoder)
+		}
+		p.additionDecoders[tlc] = decoder
+	}
+}
+
+// WithAllowInvalidChars sets whether invalid characters are allowed. It is
+// equivalent to setting the severity of ErrorCodeInvalidChar to SeverityIgnore
+// if allowInvalidChars is true, or SeverityWarning otherwise.
+func WithAllowInvalidChars(allowInvalidChars bool) ParseOption {
+	if allowInvalidChars {
+		return WithSeverity(ErrorCodeInvalidChar, SeverityIgnore)
 	}
+	return WithSeverity(ErrorCodeInvalidChar, SeverityWarning)
 }
 
 func WithAllowOutOfOrderRecords(allowOutOfOrderRecords time.Duration) ParseOption {
@@ -123,8 +94,36 @@ func WithHRecordValueDecoder(decoder HRecordValueDecoder) ParseOption {
 	}
 }
 
+// WithMinSeverity sets the minimum severity of reported diagnostics. The
+// default is SeverityWarning, so info diagnostics, which include E, H, and L
+// records that do not follow the IGC specification, are not reported unless
+// minSeverity is SeverityInfo.
+func WithMinSeverity(minSeverity Severity) ParseOption {
+	return func(p *parser) {
+		p.minSeverity = max(minSeverity, SeverityInfo)
+	}
+}
+
+// WithSeverity sets the severity of diagnostics with the given code.
+// Diagnostics with severity SeverityIgnore are never reported.
+func WithSeverity(code ErrorCode, severity Severity) ParseOption {
+	return func(p *parser) {
+		p.severities[code] = severity
+	}
+}
+
+// WithStrictness sets the severities of all diagnostics to the defaults for
+// strictness, replacing any previously set severities.
+func WithStrictness(strictness Strictness) ParseOption {
+	return func(p *parser) {
+		p.severities = strictness.severities()
+	}
+}
+
 func newParser(options ...ParseOption) *parser {
 	p := &parser{
+		severities:             StrictnessDefault.severities(),
+		minSeverity:            SeverityWarning,
 		bRecordsAdditionsByTLC: make(map[string]*RecordAddition),
 		hRecordValueDecoder:    defaultHRecordValueDecoder,
 		latMinMul:              1,
@@ -139,155 +138,260 @@ func newParser(options ...ParseOption) *parser {
 	return p
 }
 
-func (p *parser) parse(r io.Reader) (*IGC, error) {
-	var lines []string
-	scanner := bufio.NewScanner(r)
-	scanner.Split(scanLines)
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+// newBRecord returns a new BRecord. To reduce allocations, B records are
+// allocated in batches if p.batch is set.
+func (p *parser) newBRecord() *BRecord {
+	if !p.batch {
+		return &BRecord{}
 	}
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	if len(p.bRecords) == 0 {
+		p.bRecords = make([]BRecord, 256)
+	}
+	bRecord := &p.bRecords[0]
+	p.bRecords = p.bRecords[1:]
+	return bRecord
+}
+
+// newAdditionValues returns a new slice of n addition values. To reduce
+// allocations, addition values are allocated in batches if p.batch is set.
+func (p *parser) newAdditionValues(n int) []additionValue {
+	if !p.batch {
+		return make([]additionValue, n)
 	}
-	return p.parseLines(lines)
+	return p.additionsAllocator.alloc(n)
 }
 
 func (p *parser) parseLines(lines []string) (*IGC, error) {
-	records := make([]Record, 0, len(lines))
-	var bRecords []*BRecord
-	hRecordsByTLC := make(map[string]*HRecord)
-	var kRecords []*KRecord
-	var errs []error
+	var c collector
+	offset := 0
 	for i, lineStr := range lines {
-		if len(lineStr) == 0 {
-			continue
+		line := &Line{
+			Number: i + 1,
+			Offset: offset,
+			Raw:    []byte(lineStr),
 		}
-		line := []byte(lineStr)
-
-		var record Record
-		var err error
-		switch line[0] {
-		case 'A':
-			record, err = p.parseARecord(line)
-		case 'B':
-			record, err = p.parseBRecord(line)
-		case 'C':
-			record, err = p.parseCRecord(line)
-		case 'D':
-			record, err = p.parseDRecord(line)
-		case 'E':
-			record, err = p.parseERecord(line)
-		case 'F':
-			record, err = p.parseFRecord(line)
-		case 'G':
-			record, err = p.parseGRecord(line)
-		case 'H':
-			record, err = p.parseHRecord(line)
-		case 'I':
-			record, err = p.parseIRecord(line)
-		case 'J':
-			record, err = p.parseJRecord(line)
-		case 'K':
-			record, err = p.parseKRecord(line)
-		case 'L':
-			record, err = p.parseLRecord(line)
-		case 'M':
-			record, err = p.parseMRecord(line)
-		case 'N':
-			record, err = p.parseNRecord(line)
-		default:
-			err = unknownRecordTypeError(line[0])
-		}
-		if !p.allowInvalidChars {
-			if match := invalidCharsRx.FindStringSubmatch(lineStr); match != nil {
-				invalidChar := match[1][0]
-				var invalidCharErr error
-				if '\x20' <= invalidChar && invalidChar <= '\x7f' {
-					invalidCharErr = fmt.Errorf("'%c': invalid character", invalidChar)
-				} else {
-					invalidCharErr = fmt.Errorf("'\\x%02x': invalid character", invalidChar)
-				}
-				if err == nil {
-					err = invalidCharErr
-				} else {
-					err = errors.Join(err, invalidCharErr)
-				}
-			}
+		p.parseLine(line, line.Raw)
+		c.add(line)
+		offset += len(lineStr) + 1
+	}
+	return c.igc(), nil
+}
+
+// A collector collects parsed lines into an IGC.
+type collector struct {
+	lines         []*Line
+	records       []Record
+	bRecords      []*BRecord
+	hRecordsByTLC map[string]*HRecord
+	kRecords      []*KRecord
+	errs          []error
+}
+
+func (c *collector) add(line *Line) {
+	c.lines = append(c.lines, line)
+	if line.Record == nil && line.Err == nil {
+		// Empty lines have neither a record nor an error.
+		return
+	}
+	c.records = append(c.records, line.Record)
+	if line.Err != nil {
+		c.errs = append(c.errs, line.Err)
+	}
+	switch record := line.Record.(type) {
+	case *BRecord:
+		if record != nil {
+			c.bRecords = append(c.bRecords, record)
 		}
-		records = append(records, record)
-		if err != nil {
-			errs = append(errs, &Error{
-				Line: i + 1,
-				Err:  err,
-			})
+	case *HRecord:
+		if record != nil {
+			c.addHRecord(record)
+		}
+	case *HFDTERecord:
+		if record != nil {
+			c.addHRecord(&record.HRecord)
+		}
+	case *KRecord:
+		if record != nil {
+			c.kRecords = append(c.kRecords, record)
 		}
+	}
+}
 
-		switch record := record.(type) {
-		case *BRecord:
-			if record != nil {
-				bRecords = append(bRecords, record)
+func (c *collector) addHRecord(hRecord *HRecord) {
+	if c.hRecordsByTLC == nil {
+		c.hRecordsByTLC = make(map[string]*HRecord)
+	}
+	c.hRecordsByTLC[hRecord.TLC] = hRecord
+}
+
+func (c *collector) igc() *IGC {
+	hRecordsByTLC := c.hRecordsByTLC
+	if hRecordsByTLC == nil {
+		hRecordsByTLC = make(map[string]*HRecord)
+	}
+	records := c.records
+	if records == nil {
+		records = make([]Record, 0)
+	}
+	return &IGC{
+		Lines:         c.lines,
+		Records:       records,
+		Errs:          c.errs,
+		HRecordsByTLC: hRecordsByTLC,
+		BRecords:      c.bRecords,
+		KRecords:      c.kRecords,
+	}
+}
+
+// parseLine parses data, the contents of line without its terminator, and
+// sets line's Record and Err fields.
+func (p *parser) parseLine(line *Line, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+
+	var record Record
+	var err error
+	switch data[0] {
+	case 'A':
+		record, err = p.parseARecord(data)
+	case 'B':
+		record, err = p.parseBRecord(data)
+	case 'C':
+		record, err = p.parseCRecord(data)
+	case 'D':
+		record, err = p.parseDRecord(data)
+	case 'E':
+		record, err = p.parseERecord(data)
+	case 'F':
+		record, err = p.parseFRecord(data)
+	case 'G':
+		record, err = p.parseGRecord(data)
+	case 'H':
+		record, err = p.parseHRecord(data)
+	case 'I':
+		record, err = p.parseIRecord(data)
+	case 'J':
+		record, err = p.parseJRecord(data)
+	case 'K':
+		record, err = p.parseKRecord(data)
+	case 'L':
+		record, err = p.parseLRecord(data)
+	case 'M':
+		record, err = p.parseMRecord(data)
+	case 'N':
+		record, err = p.parseNRecord(data)
+	default:
+		err = &UnknownRecordTypeError{RecordType: data[0]}
+	}
+	if p.reported(ErrorCodeInvalidChar) {
+		if i := indexInvalidChar(data); i >= 0 {
+			invalidCharErr := &InvalidCharError{
+				Char:   data[i],
+				Column: i + 1,
 			}
-		case *HRecord:
-			if record != nil {
-				hRecordsByTLC[record.TLC] = record
+			if err == nil {
+				err = invalidCharErr
+			} else {
+				err = errors.Join(err, invalidCharErr)
 			}
-		case *HFDTERecord:
-			if record != nil {
-				hRecordsByTLC[record.TLC] = &record.HRecord
-				p.date = record.Date
+		}
+	}
+	line.Record = record
+	if err != nil {
+		var errs []error
+		severity := SeverityIgnore
+		errs, severity = p.filterErrors(err, errs, severity)
+		if err := errors.Join(errs...); err != nil {
+			if len(errs) == 1 {
+				err = errs[0]
 			}
-		case *IRecord:
-			if record != nil {
-				p.bRecordAdditions = append(p.bRecordAdditions, record.Additions...)
-				for i, bRecordAddition := range record.Additions {
-					p.bRecordsAdditionsByTLC[bRecordAddition.TLC] = &record.Additions[i]
-				}
-				if ladBRecordAddition, ok := p.bRecordsAdditionsByTLC["LAD"]; ok {
-					p.ladBRecordAddition = ladBRecordAddition
-					n := ladBRecordAddition.FinishColumn - ladBRecordAddition.StartColumn + 1
-					p.latMinMul = intPow(10, n)
-					p.latMinDiv = float64(6e4 * intPow(10, n))
-				}
-				if lodBRecordAddition, ok := p.bRecordsAdditionsByTLC["LOD"]; ok {
-					p.lodBRecordAddition = lodBRecordAddition
-					n := lodBRecordAddition.FinishColumn - lodBRecordAddition.StartColumn + 1
-					p.lonMinMul = intPow(10, n)
-					p.lonMinDiv = float64(6e4 * intPow(10, n))
-				}
-				if tdsBRecordAddition, ok := p.bRecordsAdditionsByTLC["TDS"]; ok {
-					p.tdsBRecordAddition = tdsBRecordAddition
-					n := tdsBRecordAddition.FinishColumn - tdsBRecordAddition.StartColumn + 1
-					p.fracSecondMul = intPow(10, 9-n)
-				}
+			line.Err = &Error{
+				Line:     line.Number,
+				Offset:   line.Offset,
+				Column:   errorColumn(err),
+				Severity: severity,
+				Err:      err,
+			}
+		}
+	}
+
+	switch record := record.(type) {
+	case *HFDTERecord:
+		if record != nil {
+			p.date = record.Date
+		}
+	case *IRecord:
+		if record != nil {
+			p.bRecordAdditions = append(p.bRecordAdditions, record.Additions...)
+			for i, bRecordAddition := range record.Additions {
+				p.bRecordsAdditionsByTLC[bRecordAddition.TLC] = &record.Additions[i]
 			}
-		case *JRecord:
-			if record != nil {
-				p.kRecordAdditions = record.Additions
+			if ladBRecordAddition, ok := p.bRecordsAdditionsByTLC["LAD"]; ok {
+				p.ladBRecordAddition = ladBRecordAddition
+				n := ladBRecordAddition.FinishColumn - ladBRecordAddition.StartColumn + 1
+				p.latMinMul = intPow(10, n)
+				p.latMinDiv = float64(6e4 * intPow(10, n))
 			}
-		case *KRecord:
-			if record != nil {
-				kRecords = append(kRecords, record)
+			if lodBRecordAddition, ok := p.bRecordsAdditionsByTLC["LOD"]; ok {
+				p.lodBRecordAddition = lodBRecordAddition
+				n := lodBRecordAddition.FinishColumn - lodBRecordAddition.StartColumn + 1
+				p.lonMinMul = intPow(10, n)
+				p.lonMinDiv = float64(6e4 * intPow(10, n))
 			}
-		case *MRecord:
-			if record != nil {
-				p.nRecordAdditions = record.Additions
+			if tdsBRecordAddition, ok := p.bRecordsAdditionsByTLC["TDS"]; ok {
+				p.tdsBRecordAddition = tdsBRecordAddition
+				n := tdsBRecordAddition.FinishColumn - tdsBRecordAddition.StartColumn + 1
+				p.fracSecondMul = intPow(10, 9-n)
 			}
 		}
+	case *JRecord:
+		if record != nil {
+			p.kRecordAdditions = record.Additions
+		}
+	case *MRecord:
+		if record != nil {
+			p.nRecordAdditions = record.Additions
+		}
 	}
+}
 
-	return &IGC{
-		Records:       records,
-		Errs:          errs,
-		HRecordsByTLC: hRecordsByTLC,
-		BRecords:      bRecords,
-		KRecords:      kRecords,
-	}, nil
+// filterErrors appends the errors in err's tree whose severity is at least the
+// minimum severity to errs and returns the maximum severity of errs.
+func (p *parser) filterErrors(err error, errs []error, severity Severity) ([]error, Severity) {
+	if joinErr, ok := err.(interface{ Unwrap() []error }); ok { //nolint:errorlint
+		for _, err := range joinErr.Unwrap() {
+			errs, severity = p.filterErrors(err, errs, severity)
+		}
+		return errs, severity
+	}
+	errSeverity := SeverityError
+	if coder, ok := err.(interface{ Code() ErrorCode }); ok { //nolint:errorlint
+		errSeverity = p.severity(coder.Code())
+	}
+	if errSeverity < p.minSeverity {
+		return errs, severity
+	}
+	return append(errs, err), max(severity, errSeverity)
+}
+
+// reported returns whether diagnostics with code are reported.
+func (p *parser) reported(code ErrorCode) bool {
+	return p.severity(code) >= p.minSeverity
+}
+
+// severity returns the severity of diagnostics with code.
+func (p *parser) severity(code ErrorCode) Severity {
+	if severity, ok := p.severities[code]; ok {
+		return severity
+	}
+	return SeverityError
 }
 
 func (p *parser) parseARecord(line []byte) (*ARecord, error) {
 	m := aRecordRx.FindSubmatch(line)
 	if m == nil {
-		return nil, invalidRecordError('A')
+		return nil, &InvalidRecordError{RecordType: 'A'}
 	}
 	var aRecord ARecord
 	aRecord.ManufacturerID = string(m[1])
@@ -302,12 +406,20 @@ func (p *parser) parseARecord(line []byte) (*ARecord, error) {
 }
 
 func (p *parser) parseBRecord(line []byte) (*BRecord, error) {
+	// The fixed-width fields of B records are parsed by hand as B records are
+	// by far the most common record.
+	if len(line) < 35 ||
+		!isDigits(line[1:14]) ||
+		line[14] != 'N' && line[14] != 'S' ||
+		!isDigits(line[15:23]) ||
+		line[23] != 'E' && line[23] != 'W' ||
+		line[24] != 'A' && line[24] != 'V' ||
+		!isDigitOrMinus(line[25]) || !isDigits(line[26:30]) ||
+		!isDigitOrMinus(line[30]) || !isDigits(line[31:35]) {
+		return nil, &InvalidRecordError{RecordType: 'B'}
+	}
+	bRecord := p.newBRecord()
 	var errs []error
-	m := bRecordRx.FindSubmatch(line)
-	if m == nil {
-		return nil, invalidRecordError('B')
-	}
-	var bRecord BRecord
 	nanosecond := 0
 	if p.tdsBRecordAddition != nil {
 		var fractionalSecond int
@@ -317,9 +429,9 @@ func (p *parser) parseBRecord(line []byte) (*BRecord, error) {
 			nanosecond = p.fracSecondMul * fractionalSecond
 		}
 	}
-	bRecord.Time, errs = p.parseTime(m[1], m[2], m[3], nanosecond, errs)
-	latDeg, _ := atoi(m[4])
-	latMin, _ := atoi(m[5])
+	bRecord.Time, errs = p.makeTime(digitsValue(line[1:3]), digitsValue(line[3:5]), digitsValue(line[5:7]), nanosecond, errs)
+	latDeg := digitsValue(line[7:9])
+	latMin := digitsValue(line[9:14])
 	if p.ladBRecordAddition != nil {
 		var lad int
 		var ok bool
@@ -329,11 +441,11 @@ func (p *parser) parseBRecord(line []byte) (*BRecord, error) {
 		}
 	}
 	bRecord.Lat = float64(latDeg) + float64(latMin)/p.latMinDiv
-	if m[6][0] == 'S' {
+	if line[14] == 'S' {
 		bRecord.Lat = -bRecord.Lat
 	}
-	lonDeg, _ := atoi(m[7])
-	lonMin, _ := atoi(m[8])
+	lonDeg := digitsValue(line[15:18])
+	lonMin := digitsValue(line[18:23])
 	if p.lodBRecordAddition != nil {
 		var lod int
 		var ok bool
@@ -343,27 +455,16 @@ func (p *parser) parseBRecord(line []byte) (*BRecord, error) {
 		}
 	}
 	bRecord.Lon = float64(lonDeg) + float64(lonMin)/p.lonMinDiv
-	if m[9][0] == 'W' {
+	if line[23] == 'W' {
 		bRecord.Lon = -bRecord.Lon
 	}
-	bRecord.Validity = Validity(m[10][0])
-	altBarometric, _ := atoi(m[11])
+	bRecord.Validity = Validity(line[24])
+	altBarometric, _ := atoi(line[25:30])
 	bRecord.AltBarometric = float64(altBarometric)
-	altWGS84, _ := atoi(m[12])
+	altWGS84, _ := atoi(line[30:35])
 	bRecord.AltWGS84 = float64(altWGS84)
-	if len(p.bRecordAdditions) > 0 {
-		additions := make(map[string]int, len(p.bRecordAdditions))
-		for _, addition := range p.bRecordAdditions {
-			var value int
-			var ok bool
-			value, errs, ok = addition.intValue(line, errs)
-			if ok {
-				additions[addition.TLC] = value
-			}
-		}
-		bRecord.Additions = additions
-	}
-	return &bRecord, errors.Join(errs...)
+	bRecord.Additions, errs = p.parseAdditions(p.bRecordAdditions, line, errs)
+	return bRecord, errors.Join(errs...)
 }
 
 func (p *parser) parseCRecord(line []byte) (Record, error) {
@@ -374,7 +475,7 @@ func (p *parser) parseCRecord(line []byte) (Record, error) {
 	}
 	m := cRecordWaypointRx.FindSubmatch(line)
 	if m == nil {
-		return nil, invalidRecordError('C')
+		return nil, &InvalidRecordError{RecordType: 'C'}
 	}
 	var cRecordWaypoint CRecordWaypoint
 	latDeg, _ := atoi(m[1])
@@ -396,7 +497,7 @@ func (p *parser) parseCRecord(line []byte) (Record, error) {
 func (p *parser) parseCRecordDeclaration(line []byte) (*CRecordDeclaration, error) {
 	m := cRecordDeclarationRx.FindSubmatch(line)
 	if m == nil {
-		return nil, invalidRecordError('C')
+		return nil, &InvalidRecordError{RecordType: 'C'}
 	}
 	var cRecordDeclaration CRecordDeclaration
 	declarationDay, _ := atoi(m[1])
@@ -422,7 +523,7 @@ func (p *parser) parseCRecordDeclaration(line []byte) (*CRecordDeclaration, erro
 func (p *parser) parseDRecord(line []byte) (*DRecord, error) {
 	m := dRecordRx.FindSubmatch(line)
 	if m == nil {
-		return nil, invalidRecordError('D')
+		return nil, &InvalidRecordError{RecordType: 'D'}
 	}
 	var dRecord DRecord
 	dRecord.GPSQualifier = GPSQualifier(m[1][0])
@@ -438,9 +539,9 @@ func (p *parser) parseERecord(line []byte) (Record, error) {
 			var invalidERecord ERecordWithoutTLC
 			invalidERecord.Time, errs = p.parseTime(m[1], m[2], m[3], 0, errs)
 			invalidERecord.Text = string(m[4])
-			return &invalidERecord, errors.Join(errs...)
+			return &invalidERecord, errors.Join(append(errs, ErrERecordWithoutTLC)...)
 		}
-		return nil, invalidRecordError('E')
+		return nil, &InvalidRecordError{RecordType: 'E'}
 	}
 	var eRecord ERecord
 	eRecord.Time, errs = p.parseTime(m[1], m[2], m[3], 0, errs)
@@ -450,18 +551,16 @@ func (p *parser) parseERecord(line []byte) (Record, error) {
 }
 
 func (p *parser) parseFRecord(line []byte) (*FRecord, error) {
-	m := fRecordRx.FindSubmatch(line)
-	if m == nil {
-		return nil, invalidRecordError('F')
+	if len(line) < 7 || len(line)%2 != 1 || !isDigits(line[1:]) {
+		return nil, &InvalidRecordError{RecordType: 'F'}
 	}
 	var fRecord FRecord
 	var errs []error
-	fRecord.Time, errs = p.parseTime(m[1], m[2], m[3], 0, errs)
-	n := len(m[4]) / 2
+	fRecord.Time, errs = p.makeTime(digitsValue(line[1:3]), digitsValue(line[3:5]), digitsValue(line[5:7]), 0, errs)
+	n := (len(line) - 7) / 2
 	satelliteIDs := make([]int, 0, n)
 	for i := range n {
-		satelliteID, _ := atoi(m[4][2*i : 2*i+2])
-		satelliteIDs = append(satelliteIDs, satelliteID)
+		satelliteIDs = append(satelliteIDs, digitsValue(line[7+2*i:9+2*i]))
 	}
 	fRecord.SatelliteIDs = satelliteIDs
 	return &fRecord, errors.Join(errs...)
@@ -470,7 +569,7 @@ func (p *parser) parseFRecord(line []byte) (*FRecord, error) {
 func (p *parser) parseGRecord(line []byte) (*GRecord, error) {
 	m := gRecordRx.FindSubmatch(line)
 	if m == nil {
-		return nil, invalidRecordError('G')
+		return nil, &InvalidRecordError{RecordType: 'G'}
 	}
 	var gRecord GRecord
 	gRecord.Text = string(m[1])
@@ -505,9 +604,9 @@ func (p *parser) parseHRecord(line []byte) (Record, error) {
 			hRecordWithInvalidSource.TLC = string(m[2])
 			hRecordWithInvalidSource.LongName = string(m[3])
 			hRecordWithInvalidSource.Value = string(m[4])
-			return &hRecordWithInvalidSource, nil
+			return &hRecordWithInvalidSource, ErrHRecordWithInvalidSource
 		}
-		return nil, invalidRecordError('H')
+		return nil, &InvalidRecordError{RecordType: 'H'}
 	}
 	var hRecord HRecord
 	hRecord.Source = Source(m[1][0])
@@ -516,12 +615,12 @@ func (p *parser) parseHRecord(line []byte) (Record, error) {
 	var err error
 	hRecord.Value, err = p.hRecordValueDecoder(m[4])
 	if err != nil {
-		return nil, invalidRecordError('H') // FIXME include err?
+		return nil, &InvalidRecordError{RecordType: 'H', Err: err}
 	}
 	if hRecord.TLC == "DTE" {
 		m := hfdteRecordValueRx.FindSubmatch(m[4])
 		if m == nil {
-			return &hRecord, invalidRecordError('H')
+			return &hRecord, &InvalidRecordError{RecordType: 'H'}
 		}
 		var hfdteRecord HFDTERecord
 		hfdteRecord.HRecord = hRecord
@@ -561,11 +660,11 @@ func (p *parser) parseJRecord(line []byte) (*JRecord, error) {
 func (p *parser) parseRecordAdditions(line []byte, startColumn int) ([]RecordAddition, error) {
 	m := ijmRecordRx.FindSubmatch(line)
 	if m == nil {
-		return nil, invalidRecordError(line[0])
+		return nil, &InvalidRecordError{RecordType: line[0]}
 	}
 	n, _ := atoi(m[1])
 	if len(m[2]) != 7*n {
-		return nil, invalidRecordError(line[0])
+		return nil, &InvalidRecordError{RecordType: line[0]}
 	}
 	var errs []error
 	additions := make([]RecordAddition, 0, n)
@@ -585,9 +684,10 @@ func (p *parser) parseRecordAdditions(line []byte, startColumn int) ([]RecordAdd
 			additions = append(additions, addition)
 			startColumn = addition.FinishColumn + 1
 		} else {
-			err := &invalidAdditionError{
-				addition: addition,
-				message:  message,
+			err := &InvalidAdditionError{
+				RecordType: line[0],
+				Addition:   addition,
+				Message:    message,
 			}
 			errs = append(errs, err)
 		}
@@ -596,24 +696,13 @@ func (p *parser) parseRecordAdditions(line []byte, startColumn int) ([]RecordAdd
 }
 
 func (p *parser) parseKRecord(line []byte) (*KRecord, error) {
-	m := knRecordRx.FindSubmatch(line)
-	if m == nil {
-		return nil, invalidRecordError('K')
+	if len(line) < 7 || !isDigits(line[1:7]) {
+		return nil, &InvalidRecordError{RecordType: 'K'}
 	}
 	var kRecord KRecord
 	var errs []error
-	kRecord.Time, errs = p.parseTime(m[1], m[2], m[3], 0, errs)
-	if len(p.kRecordAdditions) > 0 {
-		kRecord.Additions = make(map[string]int, len(p.kRecordAdditions))
-		for _, addition := range p.kRecordAdditions {
-			var value int
-			var ok bool
-			value, errs, ok = addition.intValue(line, errs)
-			if ok {
-				kRecord.Additions[addition.TLC] = value
-			}
-		}
-	}
+	kRecord.Time, errs = p.makeTime(digitsValue(line[1:3]), digitsValue(line[3:5]), digitsValue(line[5:7]), 0, errs)
+	kRecord.Additions, errs = p.parseAdditions(p.kRecordAdditions, line, errs)
 	return &kRecord, errors.Join(errs...)
 }
 
@@ -623,9 +712,9 @@ func (p *parser) parseLRecord(line []byte) (Record, error) {
 		if m := lRecordWithoutTLCRx.FindSubmatch(line); m != nil {
 			var lRecord LRecordWithoutTLC
 			lRecord.Text = string(m[1])
-			return &lRecord, nil
+			return &lRecord, ErrLRecordWithoutTLC
 		}
-		return nil, invalidRecordError('L')
+		return nil, &InvalidRecordError{RecordType: 'L'}
 	}
 	var lRecord LRecord
 	lRecord.Input = string(m[1])
@@ -644,34 +733,65 @@ func (p *parser) parseMRecord(line []byte) (*MRecord, error) {
 }
 
 func (p *parser) parseNRecord(line []byte) (*NRecord, error) {
-	m := knRecordRx.FindSubmatch(line)
-	if m == nil {
-		return nil, invalidRecordError('N')
+	if len(line) < 7 || !isDigits(line[1:7]) {
+		return nil, &InvalidRecordError{RecordType: 'N'}
 	}
 	var nRecord NRecord
 	var errs []error
-	nRecord.Time, errs = p.parseTime(m[1], m[2], m[3], 0, errs)
-	if len(p.nRecordAdditions) > 0 {
-		nRecord.Additions = make(map[string]int, len(p.nRecordAdditions))
-		for _, addition := range p.nRecordAdditions {
-			var value int
-			var ok bool
-			value, errs, ok = addition.intValue(line, errs)
-			if ok {
-				nRecord.Additions[addition.TLC] = value
-			}
+	nRecord.Time, errs = p.makeTime(digitsValue(line[1:3]), digitsValue(line[3:5]), digitsValue(line[5:7]), 0, errs)
+	nRecord.Additions, errs = p.parseAdditions(p.nRecordAdditions, line, errs)
+	return &nRecord, errors.Join(errs...)
+}
+
+// parseAdditions parses the additions in line with the given layout.
+func (p *parser) parseAdditions(layout []RecordAddition, line []byte, errs []error) (Additions, []error) {
+	if len(layout) == 0 {
+		return Additions{}, errs
+	}
+	values := p.newAdditionValues(len(layout))
+	var decoded []any
+	for i := range layout {
+		decoder, ok := p.additionDecoders[layout[i].TLC]
+		if !ok {
+			values[i].value, errs, values[i].ok = layout[i].intValue(line, errs)
+			continue
+		}
+		var data []byte
+		if data, errs, ok = layout[i].bytesValue(line, errs); !ok {
+			continue
+		}
+		value, err := decoder(data)
+		if err != nil {
+			errs = append(errs, &AdditionDecoderError{
+				RecordType: line[0],
+				Addition:   layout[i],
+				Err:        err,
+			})
+			continue
+		}
+		if decoded == nil {
+			decoded = make([]any, len(layout))
 		}
+		decoded[i] = value
 	}
-	return &nRecord, errors.Join(errs...)
+	return Additions{
+		layout:  layout,
+		values:  values,
+		decoded: decoded,
+	}, errs
 }
 
 func (p *parser) parseTime(hourData, minuteData, secondData []byte, nanosecond int, errs []error) (time.Time, []error) {
-	if p.date.IsZero() {
-		return time.Time{}, append(errs, errNoDate)
-	}
 	hour, _ := atoi(hourData)
 	minute, _ := atoi(minuteData)
 	second, _ := atoi(secondData)
+	return p.makeTime(hour, minute, second, nanosecond, errs)
+}
+
+func (p *parser) makeTime(hour, minute, second, nanosecond int, errs []error) (time.Time, []error) {
+	if p.date.IsZero() {
+		return time.Time{}, append(errs, ErrNoDate)
+	}
 	durationSinceMidnight := time.Duration(hour)*time.Hour +
 		time.Duration(minute)*time.Minute +
 		time.Duration(second)*time.Second +
@@ -694,8 +814,9 @@ func (p *parser) parseTime(hourData, minuteData, secondData []byte, nanosecond i
 
 func (a *RecordAddition) bytesValue(line []byte, errs []error) ([]byte, []error, bool) {
 	if len(line) < a.FinishColumn {
-		return nil, append(errs, &missingAdditionError{
-			addition: *a,
+		return nil, append(errs, &MissingAdditionError{
+			RecordType: line[0],
+			Addition:   *a,
 		}), false
 	}
 	return line[a.StartColumn-1 : a.FinishColumn], errs, true
@@ -708,7 +829,13 @@ func (a *RecordAddition) intValue(line []byte, errs []error) (int, []error, bool
 	}
 	result, err := atoi(data)
 	if err != nil {
-		return 0, append(errs, err), false
+		return 0, append(errs, &SyntaxError{
+			Value:        string(data),
+			RecordType:   line[0],
+			TLC:          a.TLC,
+			StartColumn:  a.StartColumn,
+			FinishColumn: a.FinishColumn,
+		}), false
 	}
 	return result, errs, true
 }
@@ -721,15 +848,15 @@ func atoi(data []byte) (int, error) {
 		digits = digits[1:]
 	}
 	if len(digits) == 0 {
-		return 0, &atoiSyntaxError{
-			num: string(data),
+		return 0, &SyntaxError{
+			Value: string(data),
 		}
 	}
 	result := 0
 	for _, b := range digits {
 		if b < '0' || '9' < b {
-			return 0, &atoiSyntaxError{
-				num: string(data),
+			return 0, &SyntaxError{
+				Value: string(data),
 			}
 		}
 		result = 10*result + int(b) - '0'
@@ -737,10 +864,65 @@ func atoi(data []byte) (int, error) {
 	return result * sign, nil
 }
 
+// isDigits returns whether data consists only of decimal digits.
+func isDigits(data []byte) bool {
+	for _, b := range data {
+		if b < '0' || '9' < b {
+			return false
+		}
+	}
+	return true
+}
+
+// isDigitOrMinus returns whether b is a decimal digit or a minus sign.
+func isDigitOrMinus(b byte) bool {
+	return '0' <= b && b <= '9' || b == '-'
+}
+
+// digitsValue returns the value of data, which must consist only of decimal
+// digits.
+func digitsValue(data []byte) int {
+	result := 0
+	for _, b := range data {
+		result = 10*result + int(b) - '0'
+	}
+	return result
+}
+
+// validChars is the set of characters permitted in IGC files. See section A7
+// of the IGC specification.
+var validChars = func() [256]bool {
+	var validChars [256]bool
+	for _, r := range [][2]byte{
+		{'\x20', '\x20'},
+		{'\x22', '\x23'},
+		{'\x25', '\x29'},
+		{'\x2b', '\x5b'},
+		{'\x5d', '\x5d'},
+		{'\x5f', '\x7d'},
+	} {
+		for c := r[0]; c <= r[1]; c++ {
+			validChars[c] = true
+		}
+	}
+	return validChars
+}()
+
+// indexInvalidChar returns the index of the first invalid character in data,
+// or -1 if data does not contain any invalid characters.
+func indexInvalidChar(data []byte) int {
+	for i, b := range data {
+		if !validChars[b] {
+			return i
+		}
+	}
+	return -1
+}
+
 // defaultHRecordValueDecoder treats value as a UTF-8 bytes.
 func defaultHRecordValueDecoder(value []byte) (string, error) {
 	if !utf8.Valid(value) {
-		return "", errInvalidUTF8Sequence
+		return "", ErrInvalidUTF8Sequence
 	}
 	return string(value), nil
 }
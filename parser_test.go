@@ -112,7 +112,7 @@ func TestReservedCharacters(t *testing.T) {
 		'\xff': true,
 	} {
 		t.Run(string(c), func(t *testing.T) {
-			assert.Equal(t, expected, invalidCharsRx.MatchString(string(c)))
+			assert.Equal(t, expected, indexInvalidChar([]byte{c}) >= 0)
 		})
 	}
 }
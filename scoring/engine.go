@@ -0,0 +1,53 @@
+package scoring
+
+import (
+	"github.com/twpayne/go-igc"
+	"github.com/twpayne/go-igc/track"
+)
+
+// An engineRun is a run of consecutive B records recorded while the engine is
+// running.
+type engineRun struct {
+	startIndex int
+	endIndex   int
+}
+
+// engineOffSegments returns the runs of consecutive non-nil bRecords outside
+// engine runs.
+func engineOffSegments(bRecords []*igc.BRecord, options *optimizeOptions) [][]*igc.BRecord {
+	nonNilBRecords := make([]*igc.BRecord, 0, len(bRecords))
+	for _, bRecord := range bRecords {
+		if bRecord != nil {
+			nonNilBRecords = append(nonNilBRecords, bRecord)
+		}
+	}
+
+	var segments [][]*igc.BRecord
+	start := 0
+	for _, engineRun := range options.engineRuns(nonNilBRecords) {
+		if engineRun.startIndex > start {
+			segments = append(segments, nonNilBRecords[start:engineRun.startIndex])
+		}
+		start = engineRun.endIndex + 1
+	}
+	if start < len(nonNilBRecords) {
+		segments = append(segments, nonNilBRecords[start:])
+	}
+	return segments
+}
+
+// engineRuns returns the engine runs in bRecords, which must not be nil,
+// detected with o's engine options. Ambiguous engine runs are not included.
+func (o *optimizeOptions) engineRuns(bRecords []*igc.BRecord) []engineRun {
+	var engineRuns []engineRun
+	for _, detected := range track.DetectEngineRuns(bRecords, o.engineOptions...) {
+		if detected.Ambiguous {
+			continue
+		}
+		engineRuns = append(engineRuns, engineRun{
+			startIndex: detected.StartIndex,
+			endIndex:   detected.EndIndex,
+		})
+	}
+	return engineRuns
+}
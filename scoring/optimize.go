@@ -0,0 +1,756 @@
+package scoring
+
+import (
+	"math"
+	"slices"
+
+	"github.com/twpayne/go-igc"
+	"github.com/twpayne/go-igc/geo"
+)
+
+// Numbers of fixes in the thinned tracks used to search for free flights, for
+// free flights with a limited altitude difference, which need several
+// searches, and for triangles. The cost of the search is quadratic in the
+// number of fixes for free flights and cubic for triangles.
+const (
+	freeThinnedLen        = 1000
+	freeLimitedThinnedLen = 500
+	triangleThinnedLen    = 300
+)
+
+// maxRefineRounds is the maximum number of rounds of refinement.
+const maxRefineRounds = 16
+
+// refineWindowLen is the number of fixes on each side of a turnpoint of a free
+// flight that are searched when refining it.
+const refineWindowLen = 32
+
+// maxLimitedSearches is the maximum number of searches for free flights with a
+// limited altitude difference.
+const maxLimitedSearches = 32
+
+// A vector is a unit vector from the center of the Earth.
+type vector [3]float64
+
+// An optimizer finds the best scoring fixes of a track. The search uses
+// central angles on the sphere, which are fast to calculate and are
+// proportional to distances on the FAI sphere. Distances on other models of
+// the Earth are calculated for the final turnpoints only.
+type optimizer struct {
+	bRecords []*igc.BRecord
+	vectors  []vector
+	alts     []float64 // alts are the pressure altitudes of the fixes, or their GNSS altitudes if there are no pressure altitudes.
+}
+
+func newOptimizer(bRecords []*igc.BRecord) *optimizer {
+	o := &optimizer{}
+	for _, bRecord := range bRecords {
+		if bRecord != nil && bRecord.Validity == igc.Validity3D {
+			o.bRecords = append(o.bRecords, bRecord)
+		}
+	}
+	if len(o.bRecords) == 0 {
+		for _, bRecord := range bRecords {
+			if bRecord != nil {
+				o.bRecords = append(o.bRecords, bRecord)
+			}
+		}
+	}
+	o.vectors = make([]vector, len(o.bRecords))
+	for i, bRecord := range o.bRecords {
+		lat := geo.Radians(bRecord.Lat)
+		lon := geo.Radians(bRecord.Lon)
+		cosLat := math.Cos(lat)
+		o.vectors[i] = vector{cosLat * math.Cos(lon), cosLat * math.Sin(lon), math.Sin(lat)}
+	}
+	pressureAlt := slices.ContainsFunc(o.bRecords, func(bRecord *igc.BRecord) bool {
+		return bRecord.AltBarometric != 0
+	})
+	o.alts = make([]float64, len(o.bRecords))
+	for i, bRecord := range o.bRecords {
+		if pressureAlt {
+			o.alts[i] = bRecord.AltBarometric
+		} else {
+			o.alts[i] = bRecord.AltWGS84
+		}
+	}
+	return o
+}
+
+// angle returns the central angle in radians between the fixes with indexes i
+// and j.
+func (o *optimizer) angle(i, j int) float64 {
+	return 2 * math.Asin(min(o.chord(i, j)/2, 1))
+}
+
+// chord returns the length of the chord of the unit sphere between the fixes
+// with indexes i and j. For distances up to several hundred kilometers it
+// differs from the central angle by less than 0.1%, and it is much faster to
+// calculate.
+func (o *optimizer) chord(i, j int) float64 {
+	a, b := &o.vectors[i], &o.vectors[j]
+	dx, dy, dz := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return math.Sqrt(dx*dx + dy*dy + dz*dz)
+}
+
+// matrix returns the matrix of distance between the fixes with indexes.
+func matrix(indexes []int, distance func(int, int) float64) []float64 {
+	n := len(indexes)
+	matrix := make([]float64, n*n)
+	for i := range n {
+		for j := i + 1; j < n; j++ {
+			d := distance(indexes[i], indexes[j])
+			matrix[i*n+j] = d
+			matrix[j*n+i] = d
+		}
+	}
+	return matrix
+}
+
+// thin returns the indexes of about n evenly spaced fixes, including the
+// first and last fixes.
+func (o *optimizer) thin(n int) []int {
+	last := len(o.bRecords) - 1
+	step := max(1, (last+n-1)/n)
+	indexes := make([]int, 0, last/step+2)
+	for i := 0; i < last; i += step {
+		indexes = append(indexes, i)
+	}
+	return append(indexes, last)
+}
+
+// free returns the indexes of the fixes of the longest path with legs legs
+// that starts at most maxAltDifference meters above its finish, or nil if
+// there is no such path. A maxAltDifference of zero means no limit. The
+// longest path is found on the thinned track using chords, or on a more
+// thinned track if the altitude difference is limited, and then refined by
+// searching the fixes around each of its turnpoints using central angles
+// until it no longer improves.
+func (o *optimizer) free(legs int, maxAltDifference float64) []int {
+	thinned := o.thin(freeThinnedLen)
+	chords := matrix(thinned, o.chord)
+
+	bests, parents := o.longestThinnedPaths(thinned, chords, legs, math.Inf(1))
+	path, _ := o.longestThinnedPath(thinned, bests, parents, legs, math.Inf(-1))
+	if !o.altDifferenceOK(path[0], path[legs], maxAltDifference) {
+		thinned = o.thin(freeLimitedThinnedLen)
+		if path = o.limitedThinnedPath(thinned, matrix(thinned, o.chord), legs, maxAltDifference); path == nil {
+			return nil
+		}
+	}
+
+	// The turnpoints are first refined with every stride fixes within step of
+	// them. Whenever the path no longer improves, stride and the size of the
+	// windows are halved, until every fix near the turnpoints is searched.
+	candidates := make([][]int, legs+1)
+	step := max(1, thinned[1]-thinned[0])
+	stride := (step + refineWindowLen - 1) / refineWindowLen
+	score := math.Inf(-1)
+	for {
+		for range maxRefineRounds {
+			for i, index := range path {
+				candidates[i] = o.window(index, refineWindowLen*stride, stride)
+			}
+			refinedPath, refinedScore := o.longestPathWithin(candidates, maxAltDifference)
+			if refinedScore <= score {
+				break
+			}
+			path, score = refinedPath, refinedScore
+		}
+		if stride == 1 {
+			return path
+		}
+		stride /= 2
+	}
+}
+
+// longestThinnedPaths returns the lengths of the longest paths through the
+// fixes with indexes thinned, using chords, that start at or below
+// maxStartAlt. bests[i*n+j], where n is the length of thinned, is the length
+// of the longest path with i legs ending at thinned fix j, or -Inf if there is
+// no such path, and parents[i*n+j] is the previous thinned fix.
+func (o *optimizer) longestThinnedPaths(thinned []int, chords []float64, legs int, maxStartAlt float64) (bests []float64, parents []int) {
+	n := len(thinned)
+	bests = make([]float64, (legs+1)*n)
+	parents = make([]int, (legs+1)*n)
+	for j, index := range thinned {
+		if o.alts[index] > maxStartAlt {
+			bests[j] = math.Inf(-1)
+		}
+	}
+	for i := 1; i <= legs; i++ {
+		for j := range n {
+			best, parent := math.Inf(-1), j
+			for k := 0; k <= j; k++ {
+				if total := bests[(i-1)*n+k] + chords[k*n+j]; total > best {
+					best, parent = total, k
+				}
+			}
+			bests[i*n+j] = best
+			parents[i*n+j] = parent
+		}
+	}
+	return bests, parents
+}
+
+// longestThinnedPath returns the indexes of the fixes of the longest path with
+// legs legs in bests and parents, as returned by longestThinnedPaths, that
+// finishes at or above minFinishAlt, and its length, or nil and -Inf if there
+// is no such path.
+func (o *optimizer) longestThinnedPath(thinned []int, bests []float64, parents []int, legs int, minFinishAlt float64) ([]int, float64) {
+	n := len(thinned)
+	j, best := -1, math.Inf(-1)
+	for k, index := range thinned {
+		if o.alts[index] >= minFinishAlt && bests[legs*n+k] > best {
+			j, best = k, bests[legs*n+k]
+		}
+	}
+	if j < 0 {
+		return nil, best
+	}
+	path := make([]int, legs+1)
+	for i := legs; i >= 0; i-- {
+		path[i] = thinned[j]
+		j = parents[i*n+j]
+	}
+	return path, best
+}
+
+// limitedThinnedPath returns the indexes of the fixes of the longest path
+// with legs legs through the fixes with indexes thinned, using chords, that
+// starts at most maxAltDifference meters above its finish, or nil if there is
+// no such path.
+func (o *optimizer) limitedThinnedPath(thinned []int, chords []float64, legs int, maxAltDifference float64) []int {
+	n := len(thinned)
+	path, _ := o.limitedPath(thinned, maxAltDifference, func(maxStartAlt float64) ([]int, float64, []float64) {
+		bests, parents := o.longestThinnedPaths(thinned, chords, legs, maxStartAlt)
+		path, score := o.longestThinnedPath(thinned, bests, parents, legs, maxStartAlt-maxAltDifference)
+		return path, score, bests[legs*n:]
+	})
+	return path
+}
+
+// limitedPath returns the longest path that finishes at one of the fixes with
+// indexes finishes and starts at most maxAltDifference meters above its
+// finish, and its length, or nil and -Inf if there is no such path.
+//
+// search returns the longest path that starts at or below maxStartAlt and
+// finishes at or above maxStartAlt minus maxAltDifference and its length, or
+// nil and -Inf if there is no such path, and the lengths of the longest paths
+// that start at or below maxStartAlt to each of finishes.
+//
+// Each finish limits the altitude of the start to its own altitude plus
+// maxAltDifference. The longest paths are found for some of these limits. The
+// longest paths for a higher limit bound the lengths of the paths to the
+// finishes with lower limits, and the longest paths for a lower limit are
+// valid for the finishes with higher limits, so the limits between two
+// searched limits are bisected only while the bound is greater than the
+// longest valid path, up to maxLimitedSearches searches.
+func (o *optimizer) limitedPath(finishes []int, maxAltDifference float64, search func(maxStartAlt float64) ([]int, float64, []float64)) ([]int, float64) {
+	maxStartAlts := make([]float64, 0, len(finishes))
+	for _, index := range finishes {
+		maxStartAlts = append(maxStartAlts, o.alts[index]+maxAltDifference)
+	}
+	slices.Sort(maxStartAlts)
+	maxStartAlts = slices.Compact(maxStartAlts)
+
+	// finalBests[i] are the lengths of the longest paths to each finish for
+	// maxStartAlts[i], if they have been searched.
+	finalBests := make(map[int][]float64)
+	var path []int
+	bestScore := math.Inf(-1)
+	searchLimit := func(i int) {
+		limitedPath, score, bests := search(maxStartAlts[i])
+		finalBests[i] = bests
+		if score > bestScore {
+			path, bestScore = limitedPath, score
+		}
+	}
+
+	// bound returns the bound on the lengths of the paths to the finishes
+	// with limits strictly between maxStartAlts[lo] and maxStartAlts[hi].
+	bound := func(lo, hi int) float64 {
+		bound := math.Inf(-1)
+		for j, index := range finishes {
+			if maxStartAlt := o.alts[index] + maxAltDifference; maxStartAlt > maxStartAlts[lo] && maxStartAlt < maxStartAlts[hi] {
+				bound = max(bound, finalBests[hi][j])
+			}
+		}
+		return bound
+	}
+
+	last := len(maxStartAlts) - 1
+	searchLimit(last)
+	if last == 0 {
+		return path, bestScore
+	}
+	searchLimit(0)
+	intervals := [][2]int{{0, last}}
+	for searches := 2; searches < maxLimitedSearches && len(intervals) > 0; searches++ {
+		// Bisect the interval with the greatest bound.
+		bestInterval, bestBound := -1, bestScore
+		for i, interval := range intervals {
+			if b := bound(interval[0], interval[1]); b > bestBound {
+				bestInterval, bestBound = i, b
+			}
+		}
+		if bestInterval < 0 {
+			break
+		}
+		lo, hi := intervals[bestInterval][0], intervals[bestInterval][1]
+		intervals = slices.Delete(intervals, bestInterval, bestInterval+1)
+		mid := (lo + hi) / 2
+		searchLimit(mid)
+		for _, interval := range [][2]int{{lo, mid}, {mid, hi}} {
+			if interval[1]-interval[0] > 1 {
+				intervals = append(intervals, interval)
+			}
+		}
+	}
+	return path, bestScore
+}
+
+// longestPathWithin returns the longest path through one of each of
+// candidates that starts at most maxAltDifference meters above its finish,
+// and its length, or nil and -Inf if there is no such path.
+func (o *optimizer) longestPathWithin(candidates [][]int, maxAltDifference float64) ([]int, float64) {
+	if maxAltDifference == 0 {
+		return longestPath(candidates, o.angle)
+	}
+
+	// The angles between candidates are the same for every search, so they
+	// are calculated once.
+	angles := make([][]float64, len(candidates))
+	for i := 1; i < len(candidates); i++ {
+		n := len(candidates[i-1])
+		angles[i] = make([]float64, len(candidates[i])*n)
+		for j, candidate := range candidates[i] {
+			for k, previous := range candidates[i-1] {
+				if previous > candidate {
+					break
+				}
+				angles[i][j*n+k] = o.angle(previous, candidate)
+			}
+		}
+	}
+	angle := func(i, j, k int) float64 {
+		return angles[i][j*len(candidates[i-1])+k]
+	}
+
+	last := len(candidates) - 1
+	bests, parents := longestPaths(candidates, make([]float64, len(candidates[0])), angle)
+	if path, score := bestPath(candidates, bests, parents); path == nil || o.altDifferenceOK(path[0], path[last], maxAltDifference) {
+		return path, score
+	}
+
+	// Search with only the starts that are low enough for each limit.
+	finishes := candidates[last]
+	starts := make([]float64, len(candidates[0]))
+	return o.limitedPath(finishes, maxAltDifference, func(maxStartAlt float64) ([]int, float64, []float64) {
+		for k, start := range candidates[0] {
+			if o.alts[start] <= maxStartAlt {
+				starts[k] = 0
+			} else {
+				starts[k] = math.Inf(-1)
+			}
+		}
+		bests, parents := longestPaths(candidates, starts, angle)
+		j, score := -1, math.Inf(-1)
+		for k, finish := range finishes {
+			if o.alts[finish] >= maxStartAlt-maxAltDifference && bests[last][k] > score {
+				j, score = k, bests[last][k]
+			}
+		}
+		if j < 0 {
+			return nil, score, bests[last]
+		}
+		return pathTo(candidates, parents, j), score, bests[last]
+	})
+}
+
+// altDifferenceOK returns whether the fix with index start is at most
+// maxAltDifference meters above the fix with index finish. A
+// maxAltDifference of zero means no limit.
+func (o *optimizer) altDifferenceOK(start, finish int, maxAltDifference float64) bool {
+	return maxAltDifference == 0 || o.alts[start]-o.alts[finish] <= maxAltDifference
+}
+
+// window returns the indexes of every stride fixes within step of index,
+// including index.
+func (o *optimizer) window(index, step, stride int) []int {
+	start := index - (min(step, index)/stride)*stride
+	end := min(index+step, len(o.bRecords)-1)
+	indexes := make([]int, 0, (end-start)/stride+1)
+	for i := start; i <= end; i += stride {
+		indexes = append(indexes, i)
+	}
+	return indexes
+}
+
+// longestPath returns the path through one of each of candidates, in order,
+// that maximizes the sum of angle between consecutive candidates, and the sum,
+// or nil and -Inf if there is no such path. Each element of candidates must be
+// sorted in increasing order.
+func longestPath(candidates [][]int, angle func(int, int) float64) ([]int, float64) {
+	bests, parents := longestPaths(candidates, make([]float64, len(candidates[0])), func(i, j, k int) float64 {
+		return angle(candidates[i-1][k], candidates[i][j])
+	})
+	return bestPath(candidates, bests, parents)
+}
+
+// bestPath returns the longest path in bests and parents, as returned by
+// longestPaths, and its length, or nil and -Inf if there is no such path.
+func bestPath(candidates [][]int, bests [][]float64, parents [][]int) ([]int, float64) {
+	last := len(candidates) - 1
+	j := 0
+	for k, best := range bests[last] {
+		if best > bests[last][j] {
+			j = k
+		}
+	}
+	score := bests[last][j]
+	if math.IsInf(score, -1) {
+		return nil, score
+	}
+	return pathTo(candidates, parents, j), score
+}
+
+// longestPaths returns the lengths of the longest paths through one of each of
+// candidates, in order, where starts are the lengths of the paths at
+// candidates[0], or -Inf to exclude them, and angle(i, j, k) is the angle
+// between candidates[i-1][k] and candidates[i][j]. bests[i][j] is the length of
+// the longest path ending at candidates[i][j], or -Inf if there is no such
+// path, and parents[i][j] is the index in candidates[i-1] of the previous
+// candidate. Each element of candidates must be sorted in increasing order.
+func longestPaths(candidates [][]int, starts []float64, angle func(i, j, k int) float64) (bests [][]float64, parents [][]int) {
+	bests = make([][]float64, len(candidates))
+	parents = make([][]int, len(candidates))
+	bests[0] = starts
+	for i := 1; i < len(candidates); i++ {
+		bests[i] = make([]float64, len(candidates[i]))
+		parents[i] = make([]int, len(candidates[i]))
+		for j, candidate := range candidates[i] {
+			best, parent := math.Inf(-1), -1
+			for k, previous := range candidates[i-1] {
+				if previous > candidate {
+					break
+				}
+				if math.IsInf(bests[i-1][k], -1) {
+					continue
+				}
+				if total := bests[i-1][k] + angle(i, j, k); total > best {
+					best, parent = total, k
+				}
+			}
+			bests[i][j] = best
+			parents[i][j] = parent
+		}
+	}
+	return bests, parents
+}
+
+// pathTo returns the path through candidates that ends at the jth of the last
+// candidates, from parents as returned by longestPaths.
+func pathTo(candidates, parents [][]int, j int) []int {
+	last := len(candidates) - 1
+	path := make([]int, len(candidates))
+	for i := last; i >= 0; i-- {
+		path[i] = candidates[i][j]
+		if i > 0 {
+			j = parents[i][j]
+		}
+	}
+	return path
+}
+
+// A triangle is the indexes of the fixes of the closing start, the three
+// vertices, and the closing finish of a triangle.
+type triangle [5]int
+
+// triangleConstraints are the constraints on a triangle.
+type triangleConstraints struct {
+	minLegFraction     float64 // minLegFraction is the minimum length of each leg as a fraction of the perimeter.
+	maxClosingFraction float64 // maxClosingFraction is the maximum closing distance as a fraction of the perimeter, or zero for no limit.
+	maxClosingDistance float64 // maxClosingDistance is the maximum closing distance in meters, or zero for no limit.
+	maxAltDifference   float64 // maxAltDifference is the maximum altitude of the closing start above the closing finish in meters, or zero for no limit.
+}
+
+// maxClosing returns the maximum closing distance of a triangle with
+// perimeter, where meter is the length of a meter in the units of perimeter.
+func (c triangleConstraints) maxClosing(perimeter, meter float64) float64 {
+	maxClosing := math.Inf(1)
+	if c.maxClosingFraction != 0 {
+		maxClosing = c.maxClosingFraction * perimeter
+	}
+	if c.maxClosingDistance != 0 {
+		maxClosing = min(maxClosing, c.maxClosingDistance*meter)
+	}
+	return maxClosing
+}
+
+// triangles returns the flat triangle and the triangle with each leg at least
+// constraints.minLegFraction of the perimeter with the greatest perimeter
+// minus closing distance that meet constraints. Either triangle is nil if
+// there is no such triangle.
+//
+// The triangles are found on the thinned track and then refined by searching
+// the fixes around each of their points in turn until they no longer improve.
+// Refinement uses distances on earth, so the refined triangles meet the
+// constraints exactly.
+func (o *optimizer) triangles(earth geo.Earth, constraints triangleConstraints) (*triangle, *triangle) {
+	thinned := o.thin(triangleThinnedLen)
+	n := len(thinned)
+	angles := matrix(thinned, o.angle)
+	meter := 1 / geo.FAISphere.Radius
+
+	// closings[i*n+k], for i <= k, is the minimum angle between any fix at or
+	// before i and any fix at or after k that meet the altitude difference
+	// constraint, and closingStarts[i*n+k] and closingFinishes[i*n+k] are the
+	// positions of those fixes. closings[i*n+k] is +Inf if there are no such
+	// fixes.
+	closings := make([]float64, n*n)
+	closingStarts := make([]int, n*n)
+	closingFinishes := make([]int, n*n)
+	for i := range n {
+		for k := n - 1; k >= i; k-- {
+			closing, start, finish := math.Inf(1), i, k
+			if o.altDifferenceOK(thinned[i], thinned[k], constraints.maxAltDifference) {
+				closing = angles[i*n+k]
+			}
+			if i > 0 && closings[(i-1)*n+k] < closing {
+				closing = closings[(i-1)*n+k]
+				start, finish = closingStarts[(i-1)*n+k], closingFinishes[(i-1)*n+k]
+			}
+			if k < n-1 && closings[i*n+k+1] < closing {
+				closing = closings[i*n+k+1]
+				start, finish = closingStarts[i*n+k+1], closingFinishes[i*n+k+1]
+			}
+			closings[i*n+k] = closing
+			closingStarts[i*n+k] = start
+			closingFinishes[i*n+k] = finish
+		}
+	}
+
+	// maxAngles[i] is the maximum angle between the thinned fix at i and any
+	// other thinned fix. The perimeter of a triangle is bounded by the sum of
+	// the leg from a to c and the maximum angles of a and c, and, if each leg
+	// must be at least minLegFraction of the perimeter, by the leg from a to c
+	// divided by minLegFraction.
+	maxAngles := make([]float64, n)
+	for i := range n {
+		for j := range n {
+			maxAngles[i] = max(maxAngles[i], angles[i*n+j])
+		}
+	}
+
+	// The thinned track might not come as close to closing as the full track,
+	// so an absolute limit on the closing distance is relaxed by twice the
+	// maximum angle between any fix and the nearest thinned fix. Triangles
+	// that meet the relaxed limit might not be refined to meet the exact
+	// limit, so the best triangles that meet the exact limit are kept too.
+	var slack float64
+	if constraints.maxClosingDistance != 0 {
+		slack = 2 * o.thinningError(thinned)
+	}
+
+	// bests[fai][exact] and bestScores[fai][exact] are the best triangles and
+	// their scores, where fai is 1 for triangles with each leg at least
+	// minLegFraction of the perimeter and exact is 1 for triangles that meet
+	// the closing limit without slack.
+	var bests [2][2]triangle
+	var bestScores [2][2]float64
+	for a := range n {
+		for c := a + 2; c < n; c++ {
+			ca := angles[c*n+a]
+			closing := closings[a*n+c]
+			maxPerimeter := ca + maxAngles[a] + maxAngles[c]
+			maxFAIPerimeter := maxPerimeter
+			if constraints.minLegFraction > 0 {
+				maxFAIPerimeter = min(maxFAIPerimeter, ca/constraints.minLegFraction)
+			}
+			if maxPerimeter-closing <= bestScores[0][1] && maxFAIPerimeter-closing <= bestScores[1][1] {
+				continue
+			}
+			if closing > constraints.maxClosing(maxPerimeter, meter)+slack {
+				continue
+			}
+			for b := a + 1; b < c; b++ {
+				ab, bc := angles[a*n+b], angles[b*n+c]
+				perimeter := ab + bc + ca
+				maxClosing := constraints.maxClosing(perimeter, meter)
+				if closing > maxClosing+slack {
+					continue
+				}
+				score := perimeter - closing
+				fai := 0
+				if min(ab, bc, ca) >= constraints.minLegFraction*perimeter {
+					fai = 1
+				}
+				exact := 0
+				if closing <= maxClosing {
+					exact = 1
+				}
+				for i := range fai + 1 {
+					for j := range exact + 1 {
+						if score > bestScores[i][j] {
+							bestScores[i][j] = score
+							bests[i][j] = triangle{closingStarts[a*n+c], a, b, c, closingFinishes[a*n+c]}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// candidates[fai] are the triangles to refine, in order of preference.
+	var candidates [2][]triangle
+	for i := range 2 {
+		for j := range 2 {
+			if bestScores[i][j] == 0 {
+				continue
+			}
+			var t triangle
+			for k, position := range bests[i][j] {
+				t[k] = thinned[position]
+			}
+			if len(candidates[i]) == 0 || candidates[i][0] != t {
+				candidates[i] = append(candidates[i], t)
+			}
+		}
+	}
+
+	step := max(1, thinned[1]-thinned[0])
+	flatConstraints := constraints
+	flatConstraints.minLegFraction = 0
+	return o.refineTriangle(candidates[0], earth, step, flatConstraints),
+		o.refineTriangle(candidates[1], earth, step, constraints)
+}
+
+// thinningError returns the maximum angle between any fix and the nearest of
+// the fixes with indexes thinned.
+func (o *optimizer) thinningError(thinned []int) float64 {
+	var maxChord float64
+	for k := 1; k < len(thinned); k++ {
+		for i := thinned[k-1] + 1; i < thinned[k]; i++ {
+			maxChord = max(maxChord, min(o.chord(thinned[k-1], i), o.chord(i, thinned[k])))
+		}
+	}
+	return 2 * math.Asin(min(maxChord/2, 1))
+}
+
+// refineTriangle returns the first of candidates that can be refined by
+// searching the fixes within step of each of its points to meet constraints
+// on earth, refined, or nil if there is no such candidate. Each candidate is
+// first refined using central angles, and skipped if it cannot meet
+// constraints. If the refined triangle does not meet the constraints on earth,
+// for example because it is marginal on the WGS84 ellipsoid, then it is
+// refined again using distances on earth.
+func (o *optimizer) refineTriangle(candidates []triangle, earth geo.Earth, step int, constraints triangleConstraints) *triangle {
+	earthDistance := func(i, j int) float64 {
+		return o.distance(earth, i, j)
+	}
+	for _, t := range candidates {
+		refined, ok := o.refineTriangleWith(t, o.angle, 1/geo.FAISphere.Radius, step, constraints)
+		if !ok {
+			continue
+		}
+		if o.meetsConstraints(refined, o.triangleLegs(refined, earthDistance), 1, constraints) {
+			return &refined
+		}
+		if refined, ok = o.refineTriangleWith(refined, earthDistance, 1, step, constraints); ok {
+			return &refined
+		}
+	}
+	return nil
+}
+
+// refineTriangleWith returns t refined by searching the fixes within step of
+// each of its points in turn, using distance, where meter is the length of a
+// meter in the units of distance, and whether the refined triangle meets
+// constraints. While the triangle does not meet constraints, the closing
+// start and finish are also searched together, as moving either alone might
+// not meet the closing limit.
+func (o *optimizer) refineTriangleWith(t triangle, distance func(int, int) float64, meter float64, step int, constraints triangleConstraints) (triangle, bool) {
+	score := func(t triangle, legs [4]float64) (float64, bool) {
+		if !o.meetsConstraints(t, legs, meter, constraints) {
+			return 0, false
+		}
+		return legs[0] + legs[1] + legs[2] - legs[3], true
+	}
+
+	best := t
+	bestScore, ok := score(best, o.triangleLegs(best, distance))
+	if !ok {
+		bestScore = math.Inf(-1)
+	}
+	for range maxRefineRounds {
+		improved := false
+		if math.IsInf(bestScore, -1) {
+			candidate := best
+			legs := o.triangleLegs(candidate, distance)
+			for _, start := range o.window(best[0], step, 1) {
+				if start > best[1] {
+					break
+				}
+				candidate[0] = start
+				for _, finish := range o.window(best[4], step, 1) {
+					if finish < best[3] {
+						continue
+					}
+					candidate[4] = finish
+					legs[3] = distance(start, finish)
+					if candidateScore, ok := score(candidate, legs); ok && candidateScore > bestScore {
+						bestScore = candidateScore
+						best = candidate
+						improved = true
+					}
+				}
+			}
+		}
+		for i := range best {
+			candidate := best
+			for _, index := range o.window(best[i], step, 1) {
+				if i > 0 && index < best[i-1] || i < len(best)-1 && index > best[i+1] {
+					continue
+				}
+				candidate[i] = index
+				if candidateScore, ok := score(candidate, o.triangleLegs(candidate, distance)); ok && candidateScore > bestScore {
+					bestScore = candidateScore
+					best = candidate
+					improved = true
+				}
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+	return best, !math.IsInf(bestScore, -1)
+}
+
+// triangleLegs returns the lengths of the three legs and the closing distance
+// of t using distance.
+func (o *optimizer) triangleLegs(t triangle, distance func(int, int) float64) [4]float64 {
+	return [4]float64{
+		distance(t[1], t[2]),
+		distance(t[2], t[3]),
+		distance(t[3], t[1]),
+		distance(t[0], t[4]),
+	}
+}
+
+// meetsConstraints returns whether t with legs meets constraints, where meter
+// is the length of a meter in the units of legs.
+func (o *optimizer) meetsConstraints(t triangle, legs [4]float64, meter float64, constraints triangleConstraints) bool {
+	perimeter := legs[0] + legs[1] + legs[2]
+	return perimeter > 0 &&
+		legs[3] <= constraints.maxClosing(perimeter, meter) &&
+		min(legs[0], legs[1], legs[2]) >= constraints.minLegFraction*perimeter &&
+		o.altDifferenceOK(t[0], t[4], constraints.maxAltDifference)
+}
+
+// distance returns the distance on earth between the fixes with indexes i and
+// j.
+func (o *optimizer) distance(earth geo.Earth, i, j int) float64 {
+	return distance(earth, o.bRecords[i], o.bRecords[j])
+}
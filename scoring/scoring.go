@@ -0,0 +1,321 @@
+// Package scoring implements cross-country scoring of IGC files, for both
+// paragliding and hang gliding contests, such as XContest, and gliding
+// contests, such as the OLC and the DMSt.
+//
+// Scores are optimized over the fixes of a flight. The search runs on a
+// thinned track and is then refined on the full track, so scores are optimal
+// for short logs and very close to optimal for long high-frequency logs.
+package scoring
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/twpayne/go-igc"
+	"github.com/twpayne/go-igc/geo"
+	"github.com/twpayne/go-igc/track"
+)
+
+// collinearTolerance is the distance in meters by which a turnpoint must
+// lengthen a free flight to be included.
+const collinearTolerance = 1e-3
+
+// A ScoreType is a type of scored flight.
+type ScoreType string
+
+// Score types.
+const (
+	ScoreTypeFree         ScoreType = "free"
+	ScoreTypeFlatTriangle ScoreType = "flat-triangle"
+	ScoreTypeFAITriangle  ScoreType = "fai-triangle"
+	ScoreTypePlus         ScoreType = "plus"
+)
+
+// A RuleSet is a set of scoring rules. A zero multiplier disables the
+// corresponding score type, and a zero limit means no limit.
+//
+// The predefined rule sets follow the published rules at the time of writing.
+// Contests change their rules from season to season, so callers that need
+// other values should define their own RuleSet.
+type RuleSet struct {
+	Name                   string
+	Earth                  geo.Earth // Earth is the model of the Earth used to calculate distances.
+	FreeMultiplier         float64
+	MaxFreeTurnpoints      int // MaxFreeTurnpoints is the maximum number of turnpoints between the start and finish of a free flight.
+	FlatTriangleMultiplier float64
+	FAITriangleMultiplier  float64
+	FAIMinLegFraction      float64 // FAIMinLegFraction is the minimum length of each leg of an FAI triangle as a fraction of its perimeter.
+	MaxClosingFraction     float64 // MaxClosingFraction is the maximum closing distance of a triangle as a fraction of its perimeter.
+	MaxClosingDistance     float64 // MaxClosingDistance is the maximum closing distance of a triangle in meters.
+	MaxAltDifference       float64 // MaxAltDifference is the maximum altitude of the start above the finish in meters.
+	PlusMultiplier         float64 // PlusMultiplier is the multiplier of the triangle in a plus score, which adds the points of the free flight and the best triangle.
+	ExcludeEngineRuns      bool    // ExcludeEngineRuns is whether scored flights must not include engine runs.
+}
+
+// Predefined rule sets.
+var (
+	XContest = &RuleSet{
+		Name:                   "XContest",
+		Earth:                  geo.WGS84,
+		FreeMultiplier:         1,
+		MaxFreeTurnpoints:      3,
+		FlatTriangleMultiplier: 1.2,
+		FAITriangleMultiplier:  1.4,
+		FAIMinLegFraction:      0.28,
+		MaxClosingFraction:     0.2,
+	}
+	WXC = &RuleSet{
+		Name:                   "WXC",
+		Earth:                  geo.FAISphere,
+		FreeMultiplier:         1,
+		MaxFreeTurnpoints:      3,
+		FlatTriangleMultiplier: 1.2,
+		FAITriangleMultiplier:  1.4,
+		FAIMinLegFraction:      0.28,
+		MaxClosingFraction:     0.2,
+	}
+	FFVLCFD = &RuleSet{
+		Name:                   "FFVL CFD",
+		Earth:                  geo.FAISphere,
+		FreeMultiplier:         1,
+		MaxFreeTurnpoints:      3,
+		FlatTriangleMultiplier: 1.2,
+		FAITriangleMultiplier:  1.4,
+		FAIMinLegFraction:      0.28,
+		MaxClosingFraction:     0.05,
+	}
+	OLCPlus = &RuleSet{
+		Name:                   "OLC-plus",
+		Earth:                  geo.WGS84,
+		FreeMultiplier:         1,
+		MaxFreeTurnpoints:      5,
+		FlatTriangleMultiplier: 1,
+		MaxClosingDistance:     1000,
+		MaxAltDifference:       1000,
+		PlusMultiplier:         0.3,
+		ExcludeEngineRuns:      true,
+	}
+	DMSt = &RuleSet{
+		Name:                   "DMSt",
+		Earth:                  geo.WGS84,
+		FreeMultiplier:         1,
+		MaxFreeTurnpoints:      5,
+		FlatTriangleMultiplier: 1,
+		MaxClosingDistance:     1000,
+		MaxAltDifference:       1000,
+		ExcludeEngineRuns:      true,
+	}
+)
+
+// A Score is the best scored flight of a type.
+type Score struct {
+	Type            ScoreType
+	Turnpoints      []*igc.BRecord // Turnpoints are the start, turnpoints, and finish of a free flight, or the vertices of a triangle.
+	ClosingStart    *igc.BRecord   // ClosingStart is the start of the closing distance of a triangle.
+	ClosingFinish   *igc.BRecord   // ClosingFinish is the finish of the closing distance of a triangle.
+	Legs            []float64      // Legs are the lengths of the legs between the turnpoints in meters, including the closing leg of a triangle.
+	LegSpeeds       []float64      // LegSpeeds are the average speeds along Legs in m/s. The closing leg of a triangle is timed to ClosingFinish.
+	Distance        float64        // Distance is the total length of Legs in meters.
+	ClosingDistance float64        // ClosingDistance is the distance between ClosingStart and ClosingFinish in meters.
+	ScoredDistance  float64        // ScoredDistance is Distance minus ClosingDistance in meters.
+	Speed           float64        // Speed is ScoredDistance divided by the time from the start to the finish, or from ClosingStart to ClosingFinish, in m/s.
+	Multiplier      float64
+	Points          float64  // Points is ScoredDistance in kilometers multiplied by Multiplier and by 100 divided by the handicap index.
+	Parts           []*Score // Parts are the free flight and triangle of a plus score. A plus score has no turnpoints or distances of its own.
+}
+
+// An OptimizeOption sets an option on Optimize.
+type OptimizeOption func(*optimizeOptions)
+
+type optimizeOptions struct {
+	handicap      int
+	engineOptions []track.EngineOption
+}
+
+// WithEngineOptions sets the options used to detect engine runs, for example
+// the manufacturer of the flight recorder. Engine runs are detected only if
+// the rule set excludes them, and ambiguous engine runs are not excluded.
+func WithEngineOptions(engineOptions ...track.EngineOption) OptimizeOption {
+	return func(o *optimizeOptions) {
+		o.engineOptions = engineOptions
+	}
+}
+
+// WithHandicap sets the handicap index of the aircraft, for example its DAeC
+// index. Points are multiplied by 100 and divided by handicap. The default is
+// 100, and non-positive handicaps are ignored.
+func WithHandicap(handicap int) OptimizeOption {
+	return func(o *optimizeOptions) {
+		if handicap > 0 {
+			o.handicap = handicap
+		}
+	}
+}
+
+// Optimize returns the best score of each type enabled by ruleSet for
+// bRecords, ordered by decreasing points. Invalid fixes are ignored, unless
+// all fixes are invalid.
+func Optimize(bRecords []*igc.BRecord, ruleSet *RuleSet, options ...OptimizeOption) []*Score {
+	optimizeOptions := optimizeOptions{
+		handicap: 100,
+	}
+	for _, option := range options {
+		option(&optimizeOptions)
+	}
+
+	segments := [][]*igc.BRecord{bRecords}
+	if ruleSet.ExcludeEngineRuns {
+		segments = engineOffSegments(bRecords, &optimizeOptions)
+	}
+	bestsByType := make(map[ScoreType]*Score)
+	for _, segment := range segments {
+		for _, score := range optimizeSegment(segment, ruleSet, optimizeOptions.handicap) {
+			if best, ok := bestsByType[score.Type]; !ok || score.Points > best.Points {
+				bestsByType[score.Type] = score
+			}
+		}
+	}
+
+	var scores []*Score
+	for _, scoreType := range []ScoreType{ScoreTypeFree, ScoreTypeFlatTriangle, ScoreTypeFAITriangle, ScoreTypePlus} {
+		if score, ok := bestsByType[scoreType]; ok {
+			scores = append(scores, score)
+		}
+	}
+	slices.SortStableFunc(scores, func(a, b *Score) int {
+		return -cmp.Compare(a.Points, b.Points)
+	})
+	return scores
+}
+
+// optimizeSegment returns the best score of each type enabled by ruleSet for
+// bRecords.
+func optimizeSegment(bRecords []*igc.BRecord, ruleSet *RuleSet, handicap int) []*Score {
+	o := newOptimizer(bRecords)
+	if len(o.bRecords) < 2 {
+		return nil
+	}
+
+	var scores []*Score
+	var free *Score
+	if ruleSet.FreeMultiplier != 0 {
+		if indexes := o.free(ruleSet.MaxFreeTurnpoints+1, ruleSet.MaxAltDifference); indexes != nil {
+			free = o.newFreeScore(ruleSet, ScoreTypeFree, ruleSet.FreeMultiplier, indexes)
+			scores = append(scores, free)
+		}
+	}
+	var bestTriangle *Score
+	if ruleSet.FlatTriangleMultiplier != 0 || ruleSet.FAITriangleMultiplier != 0 {
+		flat, fai := o.triangles(ruleSet.Earth, triangleConstraints{
+			minLegFraction:     ruleSet.FAIMinLegFraction,
+			maxClosingFraction: ruleSet.MaxClosingFraction,
+			maxClosingDistance: ruleSet.MaxClosingDistance,
+			maxAltDifference:   ruleSet.MaxAltDifference,
+		})
+		if flat != nil && ruleSet.FlatTriangleMultiplier != 0 {
+			scores = append(scores, o.newTriangleScore(ruleSet, ScoreTypeFlatTriangle, ruleSet.FlatTriangleMultiplier, flat))
+		}
+		if fai != nil && ruleSet.FAITriangleMultiplier != 0 {
+			scores = append(scores, o.newTriangleScore(ruleSet, ScoreTypeFAITriangle, ruleSet.FAITriangleMultiplier, fai))
+		}
+	}
+	for _, score := range scores {
+		score.Points = score.Multiplier * score.ScoredDistance / 1000 * 100 / float64(handicap)
+		if score.Type != ScoreTypeFree && (bestTriangle == nil || score.Points > bestTriangle.Points) {
+			bestTriangle = score
+		}
+	}
+
+	if ruleSet.PlusMultiplier != 0 && free != nil {
+		plus := &Score{
+			Type:       ScoreTypePlus,
+			Multiplier: ruleSet.PlusMultiplier,
+			Points:     free.Points,
+			Parts:      []*Score{free},
+		}
+		if bestTriangle != nil {
+			plus.Points += ruleSet.PlusMultiplier * bestTriangle.Points
+			plus.Parts = append(plus.Parts, bestTriangle)
+		}
+		scores = append(scores, plus)
+	}
+	return scores
+}
+
+// newFreeScore returns a new free Score with turnpoints at indexes.
+// Turnpoints that do not add to the distance are omitted.
+func (o *optimizer) newFreeScore(ruleSet *RuleSet, scoreType ScoreType, multiplier float64, indexes []int) *Score {
+	turnpoints := make([]*igc.BRecord, 0, len(indexes))
+	for _, index := range indexes {
+		bRecord := o.bRecords[index]
+		if n := len(turnpoints); n >= 2 {
+			previous, turnpoint := turnpoints[n-2], turnpoints[n-1]
+			if distance(ruleSet.Earth, previous, turnpoint)+distance(ruleSet.Earth, turnpoint, bRecord)-distance(ruleSet.Earth, previous, bRecord) < collinearTolerance {
+				turnpoints = turnpoints[:n-1]
+			}
+		}
+		if n := len(turnpoints); n > 0 && distance(ruleSet.Earth, turnpoints[n-1], bRecord) == 0 {
+			continue
+		}
+		turnpoints = append(turnpoints, bRecord)
+	}
+
+	score := &Score{
+		Type:       scoreType,
+		Turnpoints: turnpoints,
+		Multiplier: multiplier,
+	}
+	for i := 1; i < len(turnpoints); i++ {
+		leg := distance(ruleSet.Earth, turnpoints[i-1], turnpoints[i])
+		score.Legs = append(score.Legs, leg)
+		score.LegSpeeds = append(score.LegSpeeds, speed(leg, turnpoints[i-1].Time, turnpoints[i].Time))
+		score.Distance += leg
+	}
+	score.ScoredDistance = score.Distance
+	score.Speed = speed(score.ScoredDistance, turnpoints[0].Time, turnpoints[len(turnpoints)-1].Time)
+	return score
+}
+
+// newTriangleScore returns a new triangle Score from t.
+func (o *optimizer) newTriangleScore(ruleSet *RuleSet, scoreType ScoreType, multiplier float64, t *triangle) *Score {
+	score := &Score{
+		Type: scoreType,
+		Turnpoints: []*igc.BRecord{
+			o.bRecords[t[1]],
+			o.bRecords[t[2]],
+			o.bRecords[t[3]],
+		},
+		ClosingStart:  o.bRecords[t[0]],
+		ClosingFinish: o.bRecords[t[4]],
+		Multiplier:    multiplier,
+	}
+	route := append(slices.Clone(score.Turnpoints), score.ClosingFinish)
+	for i := range 3 {
+		leg := distance(ruleSet.Earth, score.Turnpoints[i], score.Turnpoints[(i+1)%3])
+		score.Legs = append(score.Legs, leg)
+		score.LegSpeeds = append(score.LegSpeeds, speed(leg, route[i].Time, route[i+1].Time))
+		score.Distance += leg
+	}
+	score.ClosingDistance = distance(ruleSet.Earth, score.ClosingStart, score.ClosingFinish)
+	score.ScoredDistance = score.Distance - score.ClosingDistance
+	score.Speed = speed(score.ScoredDistance, score.ClosingStart.Time, score.ClosingFinish.Time)
+	return score
+}
+
+// speed returns the speed in m/s of covering distance from t1 to t2, or zero
+// if t2 is not after t1.
+func speed(distance float64, t1, t2 time.Time) float64 {
+	seconds := t2.Sub(t1).Seconds()
+	if seconds <= 0 {
+		return 0
+	}
+	return distance / seconds
+}
+
+// distance returns the distance between bRecord1 and bRecord2 on earth.
+func distance(earth geo.Earth, bRecord1, bRecord2 *igc.BRecord) float64 {
+	p1 := geo.Point{Lat: bRecord1.Lat, Lon: bRecord1.Lon}
+	p2 := geo.Point{Lat: bRecord2.Lat, Lon: bRecord2.Lon}
+	return earth.Distance(p1, p2)
+}
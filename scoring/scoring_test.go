@@ -0,0 +1,626 @@
+package scoring_test
+
+import (
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-igc"
+	"github.com/twpayne/go-igc/geo"
+	"github.com/twpayne/go-igc/scoring"
+	"github.com/twpayne/go-igc/track"
+)
+
+var (
+	startTime = time.Date(2008, time.May, 2, 10, 0, 0, 0, time.UTC)
+	origin    = geo.Point{Lat: 46, Lon: 7}
+)
+
+// sphereRuleSet is XContest's rule set on the FAI sphere, so that distances
+// can be checked exactly.
+var sphereRuleSet = &scoring.RuleSet{
+	Name:                   "Sphere",
+	Earth:                  geo.FAISphere,
+	FreeMultiplier:         1,
+	MaxFreeTurnpoints:      3,
+	FlatTriangleMultiplier: 1.2,
+	FAITriangleMultiplier:  1.4,
+	FAIMinLegFraction:      0.28,
+	MaxClosingFraction:     0.2,
+}
+
+// sphereGlidingRuleSet is the OLC-plus rule set on the FAI sphere.
+var sphereGlidingRuleSet = &scoring.RuleSet{
+	Name:                   "Sphere gliding",
+	Earth:                  geo.FAISphere,
+	FreeMultiplier:         1,
+	MaxFreeTurnpoints:      5,
+	FlatTriangleMultiplier: 1,
+	MaxClosingDistance:     1000,
+	MaxAltDifference:       1000,
+	PlusMultiplier:         0.3,
+	ExcludeEngineRuns:      true,
+}
+
+// fly returns B records flying through waypoints at speed m/s, recorded at
+// frequency Hz.
+func fly(waypoints []geo.Point, speed, frequency float64) []*igc.BRecord {
+	var bRecords []*igc.BRecord
+	interval := time.Duration(float64(time.Second) / frequency)
+	t := startTime
+	for i := 1; i < len(waypoints); i++ {
+		p1, p2 := waypoints[i-1], waypoints[i]
+		distance := geo.FAISphere.Distance(p1, p2)
+		bearing := geo.FAISphere.InitialBearing(p1, p2)
+		for d := 0.0; d < distance; d += speed / frequency {
+			p := geo.FAISphere.Destination(p1, bearing, d)
+			bRecords = append(bRecords, &igc.BRecord{Time: t, Lat: p.Lat, Lon: p.Lon, Validity: igc.Validity3D})
+			t = t.Add(interval)
+		}
+	}
+	p := waypoints[len(waypoints)-1]
+	return append(bRecords, &igc.BRecord{Time: t, Lat: p.Lat, Lon: p.Lon, Validity: igc.Validity3D})
+}
+
+// randomWalk returns n B records of a random walk.
+func randomWalk(r *rand.Rand, n int) []*igc.BRecord {
+	waypoints := make([]geo.Point, n)
+	waypoints[0] = origin
+	bearing := 0.0
+	for i := 1; i < n; i++ {
+		bearing += 20 * r.NormFloat64()
+		waypoints[i] = geo.FAISphere.Destination(waypoints[i-1], bearing, 100*r.Float64())
+	}
+	bRecords := make([]*igc.BRecord, n)
+	for i, waypoint := range waypoints {
+		bRecords[i] = &igc.BRecord{
+			Time:     startTime.Add(time.Duration(i) * time.Second),
+			Lat:      waypoint.Lat,
+			Lon:      waypoint.Lon,
+			Validity: igc.Validity3D,
+		}
+	}
+	return bRecords
+}
+
+func scoresByType(scores []*scoring.Score) map[scoring.ScoreType]*scoring.Score {
+	scoresByType := make(map[scoring.ScoreType]*scoring.Score, len(scores))
+	for _, score := range scores {
+		scoresByType[score.Type] = score
+	}
+	return scoresByType
+}
+
+func TestOptimizeFAITriangle(t *testing.T) {
+	a := origin
+	b := geo.FAISphere.Destination(a, 30, 20000)
+	c := geo.FAISphere.Destination(a, 90, 20000)
+	bRecords := fly([]geo.Point{a, b, c, a}, 10, 1)
+
+	scores := scoring.Optimize(bRecords, sphereRuleSet)
+	assert.Equal(t, 3, len(scores))
+	assert.Equal(t, scoring.ScoreTypeFAITriangle, scores[0].Type)
+
+	faiTriangle := scores[0]
+	assert.Equal(t, 3, len(faiTriangle.Turnpoints))
+	assert.Equal(t, 3, len(faiTriangle.Legs))
+	assertClose(t, 60000, faiTriangle.Distance, 20)
+	assertClose(t, 0, faiTriangle.ClosingDistance, 20)
+	assertClose(t, faiTriangle.Distance-faiTriangle.ClosingDistance, faiTriangle.ScoredDistance, 1e-6)
+	assertClose(t, 1.4*faiTriangle.ScoredDistance/1000, faiTriangle.Points, 1e-6)
+	for _, leg := range faiTriangle.Legs {
+		assert.True(t, leg >= 0.28*faiTriangle.Distance)
+	}
+	assert.Equal(t, 3, len(faiTriangle.LegSpeeds))
+	for _, legSpeed := range faiTriangle.LegSpeeds {
+		assertClose(t, 10, legSpeed, 0.1)
+	}
+	assertClose(t, 10, faiTriangle.Speed, 0.1)
+
+	byType := scoresByType(scores)
+	assertClose(t, faiTriangle.ScoredDistance, byType[scoring.ScoreTypeFlatTriangle].ScoredDistance, 1e-6)
+	free := byType[scoring.ScoreTypeFree]
+	assert.True(t, len(free.Turnpoints) <= 5)
+	assert.Equal(t, len(free.Turnpoints)-1, len(free.Legs))
+	assertClose(t, 60000, free.Distance, 20)
+}
+
+func TestOptimizeFlatTriangle(t *testing.T) {
+	a := origin
+	b := geo.FAISphere.Destination(a, 0, 30000)
+	c := geo.FAISphere.Destination(a, 20, 25000)
+	bRecords := fly([]geo.Point{a, b, c, a}, 10, 1)
+
+	byType := scoresByType(scoring.Optimize(bRecords, sphereRuleSet))
+	flatTriangle := byType[scoring.ScoreTypeFlatTriangle]
+	assert.NotZero(t, flatTriangle)
+	perimeter := 30000 + geo.FAISphere.Distance(b, c) + 25000
+	assertClose(t, perimeter, flatTriangle.Distance, 20)
+	assertClose(t, 0, flatTriangle.ClosingDistance, 20)
+	faiTriangle := byType[scoring.ScoreTypeFAITriangle]
+	assert.True(t, faiTriangle.Distance < flatTriangle.Distance)
+	for _, leg := range faiTriangle.Legs {
+		assert.True(t, leg >= 0.28*faiTriangle.Distance)
+	}
+}
+
+func TestOptimizeClosingDistance(t *testing.T) {
+	a := origin
+	b := geo.FAISphere.Destination(a, 0, 20000)
+	c := geo.FAISphere.Destination(a, 60, 20000)
+	finish := geo.FAISphere.Destination(a, 60, 4000)
+	bRecords := fly([]geo.Point{a, b, c, finish}, 10, 1)
+
+	byType := scoresByType(scoring.Optimize(bRecords, sphereRuleSet))
+	faiTriangle := byType[scoring.ScoreTypeFAITriangle]
+	assertClose(t, 60000, faiTriangle.Distance, 20)
+	assertClose(t, 4000, faiTriangle.ClosingDistance, 20)
+	assertClose(t, faiTriangle.Distance-faiTriangle.ClosingDistance, faiTriangle.ScoredDistance, 1e-6)
+
+	// The closing distance is too long for the large triangle under FFVL
+	// CFD's rules.
+	for _, score := range scoring.Optimize(bRecords, scoring.FFVLCFD) {
+		if score.Type != scoring.ScoreTypeFree {
+			assert.True(t, score.Distance < 40000)
+			assert.True(t, score.ClosingDistance <= 0.05*score.Distance)
+		}
+	}
+}
+
+func TestOptimizeOpenFlight(t *testing.T) {
+	a := origin
+	b := geo.FAISphere.Destination(a, 90, 40000)
+	bRecords := fly([]geo.Point{a, b}, 10, 1)
+
+	scores := scoring.Optimize(bRecords, sphereRuleSet)
+	assert.Equal(t, 1, len(scores))
+	assert.Equal(t, scoring.ScoreTypeFree, scores[0].Type)
+	assert.Equal(t, 2, len(scores[0].Turnpoints))
+	assertClose(t, 40000, scores[0].ScoredDistance, 1)
+	assertClose(t, 40, scores[0].Points, 1e-3)
+}
+
+func TestOptimizeHandicap(t *testing.T) {
+	a := origin
+	b := geo.FAISphere.Destination(a, 90, 40000)
+	bRecords := fly([]geo.Point{a, b}, 10, 1)
+
+	scores := scoring.Optimize(bRecords, sphereRuleSet, scoring.WithHandicap(80))
+	assert.Equal(t, 1, len(scores))
+	assertClose(t, 40000, scores[0].ScoredDistance, 1)
+	assertClose(t, 50, scores[0].Points, 1e-3)
+	assertClose(t, 10, scores[0].Speed, 0.01)
+	assert.Equal(t, []float64{scores[0].Speed}, scores[0].LegSpeeds)
+}
+
+func TestOptimizeInvalidHandicap(t *testing.T) {
+	a := origin
+	b := geo.FAISphere.Destination(a, 90, 40000)
+	bRecords := fly([]geo.Point{a, b}, 10, 1)
+
+	for _, handicap := range []int{0, -100} {
+		scores := scoring.Optimize(bRecords, sphereRuleSet, scoring.WithHandicap(handicap))
+		assert.Equal(t, 1, len(scores))
+		assertClose(t, 40, scores[0].Points, 1e-3)
+	}
+}
+
+func TestOptimizeAltDifference(t *testing.T) {
+	a := origin
+	b := geo.FAISphere.Destination(a, 90, 45000)
+	bRecords := fly([]geo.Point{a, b}, 10, 1)
+	for i, bRecord := range bRecords {
+		bRecord.AltBarometric = 2000 - 1500*float64(i)/float64(len(bRecords)-1)
+	}
+
+	// The finish may be at most 1000m below the start, so only two thirds of
+	// the glide is scored.
+	free := scoresByType(scoring.Optimize(bRecords, sphereGlidingRuleSet))[scoring.ScoreTypeFree]
+	assertClose(t, 30000, free.ScoredDistance, 20)
+	start, finish := free.Turnpoints[0], free.Turnpoints[len(free.Turnpoints)-1]
+	assert.True(t, start.AltBarometric-finish.AltBarometric <= 1000)
+}
+
+func TestOptimizeEngineRuns(t *testing.T) {
+	layout := []igc.RecordAddition{
+		{StartColumn: 36, FinishColumn: 38, TLC: "ENL"},
+	}
+	a := origin
+	b := geo.FAISphere.Destination(a, 90, 60000)
+	bRecords := fly([]geo.Point{a, b}, 10, 1)
+	for i, bRecord := range bRecords {
+		enl := 20
+		if i >= 3000 && i < 4000 {
+			enl = 900
+		}
+		bRecord.Additions = igc.NewAdditions(layout, map[string]int{"ENL": enl})
+	}
+
+	// The engine runs from 30km to 40km, so the longest flight without the
+	// engine is the first 30km.
+	free := scoresByType(scoring.Optimize(bRecords, sphereGlidingRuleSet))[scoring.ScoreTypeFree]
+	assertClose(t, 30000, free.ScoredDistance, 20)
+
+	noEngineRules := *sphereGlidingRuleSet
+	noEngineRules.ExcludeEngineRuns = false
+	free = scoresByType(scoring.Optimize(bRecords, &noEngineRules))[scoring.ScoreTypeFree]
+	assertClose(t, 60000, free.ScoredDistance, 20)
+}
+
+func TestOptimizeEngineOptions(t *testing.T) {
+	layout := []igc.RecordAddition{
+		{StartColumn: 36, FinishColumn: 38, TLC: "ENL"},
+	}
+	a := origin
+	b := geo.FAISphere.Destination(a, 90, 60000)
+	bRecords := fly([]geo.Point{a, b}, 10, 1)
+	for i, bRecord := range bRecords {
+		enl := 20
+		if i >= 3000 && i < 4000 {
+			enl = 300
+		}
+		bRecord.Additions = igc.NewAdditions(layout, map[string]int{"ENL": enl})
+	}
+
+	// The engine noise level is ambiguous with the default thresholds, so
+	// the engine run is not excluded.
+	free := scoresByType(scoring.Optimize(bRecords, sphereGlidingRuleSet))[scoring.ScoreTypeFree]
+	assertClose(t, 60000, free.ScoredDistance, 20)
+
+	free = scoresByType(scoring.Optimize(bRecords, sphereGlidingRuleSet, scoring.WithEngineOptions(
+		track.WithEngineThresholds("XYZ", track.EngineThresholds{ENL: 200}),
+		track.WithManufacturerID("XYZ"),
+	)))[scoring.ScoreTypeFree]
+	assertClose(t, 30000, free.ScoredDistance, 20)
+}
+
+func TestOptimizeMaxClosingDistance(t *testing.T) {
+	a := origin
+	b := geo.FAISphere.Destination(a, 0, 20000)
+	c := geo.FAISphere.Destination(a, 60, 20000)
+	finish := geo.FAISphere.Destination(a, 60, 1500)
+	bRecords := fly([]geo.Point{a, b, c, finish}, 10, 1)
+
+	// The closing distance is at least 1.5km, which is too long for any
+	// significant triangle.
+	byType := scoresByType(scoring.Optimize(bRecords, sphereGlidingRuleSet))
+	if triangle, ok := byType[scoring.ScoreTypeFlatTriangle]; ok {
+		assert.True(t, triangle.Distance < 5000)
+	}
+
+	relaxedRules := *sphereGlidingRuleSet
+	relaxedRules.MaxClosingDistance = 2000
+	byType = scoresByType(scoring.Optimize(bRecords, &relaxedRules))
+	triangle := byType[scoring.ScoreTypeFlatTriangle]
+	assertClose(t, 60000, triangle.Distance, 20)
+	assertClose(t, 1500, triangle.ClosingDistance, 20)
+	assert.True(t, triangle.ClosingDistance <= 2000)
+
+	free := byType[scoring.ScoreTypeFree]
+	plus := byType[scoring.ScoreTypePlus]
+	assert.Equal(t, []*scoring.Score{free, triangle}, plus.Parts)
+	assertClose(t, free.Points+0.3*triangle.Points, plus.Points, 1e-6)
+}
+
+func TestOptimizePlusFlatTriangle(t *testing.T) {
+	a := origin
+	b := geo.FAISphere.Destination(a, 0, 40000)
+	c := geo.FAISphere.Destination(a, 20, 40000)
+	bRecords := fly([]geo.Point{a, b, c, a}, 10, 1)
+
+	// The triangle is too narrow to be an FAI triangle, but the plus score
+	// includes free triangles.
+	byType := scoresByType(scoring.Optimize(bRecords, sphereGlidingRuleSet))
+	triangle := byType[scoring.ScoreTypeFlatTriangle]
+	expected := 80000 + geo.FAISphere.Distance(b, c)
+	assertClose(t, expected, triangle.ScoredDistance, 20)
+	assert.True(t, triangle.Legs[1] < 0.28*triangle.Distance)
+
+	free := byType[scoring.ScoreTypeFree]
+	plus := byType[scoring.ScoreTypePlus]
+	assert.Equal(t, []*scoring.Score{free, triangle}, plus.Parts)
+	assertClose(t, free.Points+0.3*triangle.Points, plus.Points, 1e-6)
+}
+
+func TestOptimizeDegenerate(t *testing.T) {
+	assert.Equal(t, 0, len(scoring.Optimize(nil, scoring.XContest)))
+	assert.Equal(t, 0, len(scoring.Optimize([]*igc.BRecord{{Time: startTime, Lat: 46, Lon: 7}}, scoring.XContest)))
+
+	// Invalid fixes are ignored.
+	bRecords := fly([]geo.Point{origin, geo.FAISphere.Destination(origin, 0, 1000)}, 10, 1)
+	bRecords = append(bRecords, &igc.BRecord{Time: startTime.Add(time.Hour), Lat: 47, Lon: 7, Validity: igc.Validity2D}, nil)
+	scores := scoring.Optimize(bRecords, sphereRuleSet)
+	assert.Equal(t, 1, len(scores))
+	assertClose(t, 1000, scores[0].Distance, 1)
+}
+
+// TestOptimizeExhaustive compares the optimizer with exhaustive searches on
+// random walks. The walks are longer than the thinned tracks, so this checks
+// the refinement.
+func TestOptimizeExhaustive(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2)) //nolint:gosec
+	for range 2 {
+		bRecords := randomWalk(r, 2000)
+		points := make([]geo.Point, len(bRecords))
+		for i, bRecord := range bRecords {
+			points[i] = geo.Point{Lat: bRecord.Lat, Lon: bRecord.Lon}
+		}
+
+		// Longest path with four legs.
+		n := len(points)
+		bests := make([]float64, n)
+		for range 4 {
+			nextBests := make([]float64, n)
+			for j := range n {
+				for i := 0; i <= j; i++ {
+					nextBests[j] = max(nextBests[j], bests[i]+geo.FAISphere.Distance(points[i], points[j]))
+				}
+			}
+			bests = nextBests
+		}
+		expectedFree := slices.Max(bests)
+
+		free := scoresByType(scoring.Optimize(bRecords, sphereRuleSet))[scoring.ScoreTypeFree]
+		assert.True(t, free.ScoredDistance >= 0.999*expectedFree, "expected %f, got %f", expectedFree, free.ScoredDistance)
+		assert.True(t, free.ScoredDistance <= expectedFree+1e-6)
+
+		// Best triangles on the first m fixes.
+		m := 600
+		distances := make([]float64, m*m)
+		for i := range m {
+			for j := range m {
+				distances[i*m+j] = geo.FAISphere.Distance(points[i], points[j])
+			}
+		}
+		closings := make([]float64, m*m)
+		for a := range m {
+			for c := m - 1; c >= a; c-- {
+				closings[a*m+c] = distances[a*m+c]
+				if a > 0 {
+					closings[a*m+c] = min(closings[a*m+c], closings[(a-1)*m+c])
+				}
+				if c < m-1 {
+					closings[a*m+c] = min(closings[a*m+c], closings[a*m+c+1])
+				}
+			}
+		}
+		expectedTriangles := make(map[scoring.ScoreType]float64)
+		for a := range m {
+			for c := a + 2; c < m; c++ {
+				closing := closings[a*m+c]
+				for b := a + 1; b < c; b++ {
+					ab, bc, ca := distances[a*m+b], distances[b*m+c], distances[c*m+a]
+					perimeter := ab + bc + ca
+					if closing > 0.2*perimeter {
+						continue
+					}
+					score := perimeter - closing
+					expectedTriangles[scoring.ScoreTypeFlatTriangle] = max(expectedTriangles[scoring.ScoreTypeFlatTriangle], score)
+					if min(ab, bc, ca) >= 0.28*perimeter {
+						expectedTriangles[scoring.ScoreTypeFAITriangle] = max(expectedTriangles[scoring.ScoreTypeFAITriangle], score)
+					}
+				}
+			}
+		}
+
+		byType := scoresByType(scoring.Optimize(bRecords[:m], sphereRuleSet))
+		for _, scoreType := range []scoring.ScoreType{scoring.ScoreTypeFlatTriangle, scoring.ScoreTypeFAITriangle} {
+			expected := expectedTriangles[scoreType]
+			score, ok := byType[scoreType]
+			assert.True(t, ok)
+			assert.True(t, score.ScoredDistance >= 0.999*expected, "%s: expected %f, got %f", scoreType, expected, score.ScoredDistance)
+			assert.True(t, score.ScoredDistance <= expected+1e-6)
+		}
+	}
+}
+
+// TestOptimizeExhaustiveAltDifference compares the optimizer with an
+// exhaustive search for free flights with a limited altitude difference on
+// random walks.
+func TestOptimizeExhaustiveAltDifference(t *testing.T) {
+	r := rand.New(rand.NewPCG(3, 4)) //nolint:gosec
+	ruleSet := *sphereGlidingRuleSet
+	ruleSet.MaxAltDifference = 100
+	for range 2 {
+		n := 300
+		bRecords := randomWalk(r, n)
+		alt := 1000.0
+		for _, bRecord := range bRecords {
+			alt += 20 * r.NormFloat64()
+			bRecord.AltBarometric = alt
+		}
+		distances := make([]float64, n*n)
+		for i := range n {
+			for j := range n {
+				distances[i*n+j] = distance(bRecords[i], bRecords[j])
+			}
+		}
+
+		// Longest path with six legs from each start.
+		expected := 0.0
+		for start := range n {
+			bests := make([]float64, n)
+			for j := range n {
+				bests[j] = math.Inf(-1)
+			}
+			bests[start] = 0
+			for range 6 {
+				nextBests := make([]float64, n)
+				for j := range n {
+					nextBests[j] = math.Inf(-1)
+					for i := start; i <= j; i++ {
+						nextBests[j] = max(nextBests[j], bests[i]+distances[i*n+j])
+					}
+				}
+				bests = nextBests
+			}
+			for finish := start; finish < n; finish++ {
+				if bRecords[start].AltBarometric-bRecords[finish].AltBarometric <= 100 {
+					expected = max(expected, bests[finish])
+				}
+			}
+		}
+
+		free := scoresByType(scoring.Optimize(bRecords, &ruleSet))[scoring.ScoreTypeFree]
+		assert.True(t, free.ScoredDistance >= 0.999*expected, "expected %f, got %f", expected, free.ScoredDistance)
+		assert.True(t, free.ScoredDistance <= expected+1e-6)
+		start, finish := free.Turnpoints[0], free.Turnpoints[len(free.Turnpoints)-1]
+		assert.True(t, start.AltBarometric-finish.AltBarometric <= 100)
+	}
+}
+
+func TestOptimizeTestdata(t *testing.T) {
+	dirEntries, err := os.ReadDir("../testdata")
+	assert.NoError(t, err)
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		if strings.ToLower(filepath.Ext(name)) != ".igc" {
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			file, err := os.Open(filepath.Join("../testdata", name))
+			assert.NoError(t, err)
+			defer file.Close()
+			igcFile, err := igc.Parse(file)
+			assert.NoError(t, err)
+			scores := scoring.Optimize(igcFile.BRecords, scoring.XContest)
+			for i, score := range scores {
+				if i > 0 {
+					assert.True(t, scores[i-1].Points >= score.Points)
+				}
+				assert.True(t, score.ScoredDistance >= 0)
+				if score.Type != scoring.ScoreTypeFree {
+					assert.True(t, score.ClosingDistance <= 0.2*score.Distance+1e-6)
+				}
+			}
+			for _, score := range scoring.Optimize(igcFile.BRecords, scoring.OLCPlus) {
+				switch score.Type {
+				case scoring.ScoreTypeFree:
+					start, finish := score.Turnpoints[0], score.Turnpoints[len(score.Turnpoints)-1]
+					assert.True(t, start.AltBarometric-finish.AltBarometric <= 1000 || start.AltWGS84-finish.AltWGS84 <= 1000)
+				case scoring.ScoreTypeFlatTriangle:
+					assert.True(t, score.ClosingDistance <= 1000)
+				}
+			}
+		})
+	}
+}
+
+// TestOptimizeHighFrequency checks the optimizer on eight hour flights
+// recorded at 10 Hz.
+func TestOptimizeHighFrequency(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	for _, tc := range []struct {
+		name     string
+		bRecords []*igc.BRecord
+	}{
+		{
+			name:     "triangle",
+			bRecords: highFrequencyFlight(),
+		},
+		{
+			name:     "noisy",
+			bRecords: noisyHighFrequencyFlight(rand.New(rand.NewPCG(1, 2))), //nolint:gosec
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			start := time.Now()
+			scores := scoring.Optimize(tc.bRecords, scoring.XContest)
+			elapsed := time.Since(start)
+			t.Logf("optimized %d fixes in %s", len(tc.bRecords), elapsed)
+			assert.True(t, elapsed < time.Second, "optimized in %s", elapsed)
+			assert.Equal(t, scoring.ScoreTypeFAITriangle, scores[0].Type)
+			assertClose(t, 216000, scores[0].ScoredDistance, 1000)
+		})
+	}
+}
+
+func BenchmarkOptimize(b *testing.B) {
+	for _, tc := range []struct {
+		name     string
+		bRecords []*igc.BRecord
+	}{
+		{
+			name:     "triangle",
+			bRecords: highFrequencyFlight(),
+		},
+		{
+			name:     "noisy",
+			bRecords: noisyHighFrequencyFlight(rand.New(rand.NewPCG(1, 2))), //nolint:gosec
+		},
+		{
+			name:     "random_walk",
+			bRecords: highFrequencyRandomWalk(rand.New(rand.NewPCG(1, 2))), //nolint:gosec
+		},
+	} {
+		for _, ruleSet := range []*scoring.RuleSet{scoring.XContest, scoring.OLCPlus} {
+			b.Run(tc.name+"/"+ruleSet.Name, func(b *testing.B) {
+				for b.Loop() {
+					scoring.Optimize(tc.bRecords, ruleSet)
+				}
+			})
+		}
+	}
+}
+
+// highFrequencyFlight returns the B records of an eight hour FAI triangle
+// flight with a perimeter of 216 km recorded at 10 Hz.
+func highFrequencyFlight() []*igc.BRecord {
+	a := origin
+	b := geo.FAISphere.Destination(a, 0, 72000)
+	c := geo.FAISphere.Destination(a, 60, 72000)
+	return fly([]geo.Point{a, b, c, a}, 7.5, 10)
+}
+
+// noisyHighFrequencyFlight returns the B records of highFrequencyFlight with
+// each fix displaced by a random walk of up to 50 m, like the noise of a real
+// flight recorder, and with altitudes that descend from 2500 m to 500 m.
+func noisyHighFrequencyFlight(r *rand.Rand) []*igc.BRecord {
+	bRecords := highFrequencyFlight()
+	var dx, dy float64
+	for i, bRecord := range bRecords {
+		dx = max(-50, min(50, dx+r.NormFloat64()))
+		dy = max(-50, min(50, dy+r.NormFloat64()))
+		p := geo.FAISphere.Destination(geo.Point{Lat: bRecord.Lat, Lon: bRecord.Lon}, 0, dy)
+		p = geo.FAISphere.Destination(p, 90, dx)
+		bRecord.Lat, bRecord.Lon = p.Lat, p.Lon
+		bRecord.AltBarometric = descendingAlt(r, i, len(bRecords))
+	}
+	return bRecords
+}
+
+// highFrequencyRandomWalk returns the B records of an eight hour random walk
+// recorded at 10 Hz, with altitudes that descend from 2500 m to 500 m.
+func highFrequencyRandomWalk(r *rand.Rand) []*igc.BRecord {
+	bRecords := randomWalk(r, 8*60*60*10+1)
+	for i, bRecord := range bRecords {
+		bRecord.Time = startTime.Add(time.Duration(i) * 100 * time.Millisecond)
+		bRecord.AltBarometric = descendingAlt(r, i, len(bRecords))
+	}
+	return bRecords
+}
+
+// descendingAlt returns the altitude of the ith of n fixes descending from
+// 2500 m to 500 m, with noise.
+func descendingAlt(r *rand.Rand, i, n int) float64 {
+	return 2500 - 2000*float64(i)/float64(n-1) + 10*r.NormFloat64()
+}
+
+func distance(bRecord1, bRecord2 *igc.BRecord) float64 {
+	return geo.FAISphere.Distance(geo.Point{Lat: bRecord1.Lat, Lon: bRecord1.Lon}, geo.Point{Lat: bRecord2.Lat, Lon: bRecord2.Lon})
+}
+
+func assertClose(t *testing.T, expected, actual, delta float64) {
+	t.Helper()
+	assert.True(t, math.Abs(expected-actual) <= delta, "expected %f, got %f", expected, actual)
+}
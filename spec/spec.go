@@ -0,0 +1,507 @@
+// Package spec checks IGC files against the structural rules of the FAI IGC
+// specification.
+//
+// See https://www.fai.org/sites/default/files/igc_fr_specification_with_al8_2023-2-1_0.pdf.
+package spec
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/twpayne/go-igc"
+)
+
+// A RuleID is a stable, machine-readable identifier of a rule.
+type RuleID string
+
+// Rule IDs.
+const (
+	RuleIDAFirst           RuleID = "a-first"
+	RuleIDASingle          RuleID = "a-single"
+	RuleIDCBeforeB         RuleID = "c-before-b"
+	RuleIDCoordinateRange  RuleID = "coordinate-range"
+	RuleIDDate             RuleID = "date"
+	RuleIDGLast            RuleID = "g-last"
+	RuleIDHBeforeB         RuleID = "h-before-b"
+	RuleIDHDTERequired     RuleID = "h-dte-required"
+	RuleIDHRequired        RuleID = "h-required"
+	RuleIDIBeforeB         RuleID = "i-before-b"
+	RuleIDJBeforeK         RuleID = "j-before-k"
+	RuleIDMinutes          RuleID = "minutes"
+	RuleIDMonotonicFixTime RuleID = "monotonic-fix-time"
+	RuleIDTimeOfDay        RuleID = "time-of-day"
+)
+
+// A Rule is a rule of the IGC specification.
+type Rule struct {
+	ID          RuleID
+	Section     string       // Section is the section of the IGC specification that defines the rule.
+	Description string       // Description is a short description of the rule.
+	Severity    igc.Severity // Severity is the default severity of violations of the rule.
+	check       func(*validator)
+}
+
+// A Violation is a violation of a rule.
+type Violation struct {
+	Rule     *Rule
+	Line     int // Line is the 1-based line number, or zero if the violation applies to the whole file.
+	Severity igc.Severity
+	Message  string
+}
+
+func (v *Violation) String() string {
+	if v.Line == 0 {
+		return fmt.Sprintf("%s: %s: %s (%s)", v.Severity, v.Rule.ID, v.Message, v.Rule.Section)
+	}
+	return fmt.Sprintf("%d: %s: %s: %s (%s)", v.Line, v.Severity, v.Rule.ID, v.Message, v.Rule.Section)
+}
+
+// RequiredHRecordTLCs are the three-letter codes of the H records that must be
+// present in every IGC file, excluding DTE which is checked separately.
+var RequiredHRecordTLCs = []string{
+	"PLT", "GTY", "GID", "FTY", "RFW", "RHW", "GPS", "PRS", "ALG", "ALP",
+}
+
+var rules = []*Rule{
+	{
+		ID:          RuleIDAFirst,
+		Section:     "A3.1",
+		Description: "the A record must be the first record",
+		Severity:    igc.SeverityError,
+		check:       checkAFirst,
+	},
+	{
+		ID:          RuleIDASingle,
+		Section:     "A4.1",
+		Description: "there must be exactly one A record",
+		Severity:    igc.SeverityError,
+		check:       checkASingle,
+	},
+	{
+		ID:          RuleIDHDTERequired,
+		Section:     "A4.2",
+		Description: "the HFDTE record must be present",
+		Severity:    igc.SeverityError,
+		check:       checkHDTERequired,
+	},
+	{
+		ID:          RuleIDHRequired,
+		Section:     "A4.2",
+		Description: "the required H records must be present",
+		Severity:    igc.SeverityWarning,
+		check:       checkHRequired,
+	},
+	{
+		ID:          RuleIDHBeforeB,
+		Section:     "A3.1",
+		Description: "H records must precede the first B record",
+		Severity:    igc.SeverityWarning,
+		check:       checkBefore('H', 'B'),
+	},
+	{
+		ID:          RuleIDIBeforeB,
+		Section:     "A4.3",
+		Description: "I records must precede the first B record",
+		Severity:    igc.SeverityError,
+		check:       checkBefore('I', 'B'),
+	},
+	{
+		ID:          RuleIDJBeforeK,
+		Section:     "A4.3",
+		Description: "J records must precede the first K record",
+		Severity:    igc.SeverityError,
+		check:       checkBefore('J', 'K'),
+	},
+	{
+		ID:          RuleIDCBeforeB,
+		Section:     "A4.4",
+		Description: "C records must precede the first B record",
+		Severity:    igc.SeverityError,
+		check:       checkBefore('C', 'B'),
+	},
+	{
+		ID:          RuleIDGLast,
+		Section:     "A3.1",
+		Description: "G records must be the last records",
+		Severity:    igc.SeverityError,
+		check:       checkGLast,
+	},
+	{
+		ID:          RuleIDDate,
+		Section:     "A4.2",
+		Description: "dates must be valid calendar dates",
+		Severity:    igc.SeverityError,
+		check:       checkDate,
+	},
+	{
+		ID:          RuleIDTimeOfDay,
+		Section:     "A4.1",
+		Description: "times must be valid UTC times of day",
+		Severity:    igc.SeverityError,
+		check:       checkTimeOfDay,
+	},
+	{
+		ID:          RuleIDMinutes,
+		Section:     "A4.1",
+		Description: "minutes of latitude and longitude must be less than 60",
+		Severity:    igc.SeverityError,
+		check:       checkMinutes,
+	},
+	{
+		ID:          RuleIDCoordinateRange,
+		Section:     "A4.1",
+		Description: "latitudes and longitudes must be in range",
+		Severity:    igc.SeverityError,
+		check:       checkCoordinateRange,
+	},
+	{
+		ID:          RuleIDMonotonicFixTime,
+		Section:     "A4.1",
+		Description: "fix times must be strictly increasing",
+		Severity:    igc.SeverityError,
+		check:       checkMonotonicFixTime,
+	},
+}
+
+var rulesByID = func() map[RuleID]*Rule {
+	rulesByID := make(map[RuleID]*Rule, len(rules))
+	for _, rule := range rules {
+		rulesByID[rule.ID] = rule
+	}
+	return rulesByID
+}()
+
+// An Option sets an option on validation.
+type Option func(*validator)
+
+// WithSeverity sets the severity of violations of the rule with the given ID.
+// Rules with severity igc.SeverityIgnore are not checked.
+func WithSeverity(id RuleID, severity igc.Severity) Option {
+	return func(v *validator) {
+		v.severities[id] = severity
+	}
+}
+
+// Rules returns all rules, in the order in which they are checked.
+func Rules() []*Rule {
+	return append([]*Rule(nil), rules...)
+}
+
+// RuleByID returns the rule with the given ID, or nil if there is no such rule.
+func RuleByID(id RuleID) *Rule {
+	return rulesByID[id]
+}
+
+// A validator validates an IGC file.
+type validator struct {
+	lines      []*igc.Line
+	severities map[RuleID]igc.Severity
+	rule       *Rule
+	violations []*Violation
+}
+
+// Validate checks the lines of igcFile against all rules and returns any
+// violations, ordered by rule and then by line.
+func Validate(igcFile *igc.IGC, options ...Option) []*Violation {
+	v := &validator{
+		severities: make(map[RuleID]igc.Severity),
+	}
+	for _, line := range igcFile.Lines {
+		if parsed(line.Record) {
+			v.lines = append(v.lines, line)
+		}
+	}
+	for _, option := range options {
+		option(v)
+	}
+	for _, rule := range rules {
+		if v.severity(rule) == igc.SeverityIgnore {
+			continue
+		}
+		v.rule = rule
+		rule.check(v)
+	}
+	return v.violations
+}
+
+// parsed returns whether record was parsed. Lines that could not be parsed
+// have no record, or a nil record of their type, and are ignored.
+func parsed(record igc.Record) bool {
+	switch record := record.(type) {
+	case nil:
+		return false
+	case *igc.ERecordWithoutTLC:
+		return record != nil
+	case *igc.HFDTERecord:
+		return record != nil
+	case *igc.HRecordWithInvalidSource:
+		return record != nil
+	case *igc.LRecordWithoutTLC:
+		return record != nil
+	default:
+		return record.Valid()
+	}
+}
+
+// addf adds a violation of the current rule at line.
+func (v *validator) addf(line int, format string, args ...any) {
+	v.violations = append(v.violations, &Violation{
+		Rule:     v.rule,
+		Line:     line,
+		Severity: v.severity(v.rule),
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// severity returns the severity of violations of rule.
+func (v *validator) severity(rule *Rule) igc.Severity {
+	if severity, ok := v.severities[rule.ID]; ok {
+		return severity
+	}
+	return rule.Severity
+}
+
+func checkAFirst(v *validator) {
+	if len(v.lines) > 0 && v.lines[0].Record.Type() != 'A' {
+		v.addf(v.lines[0].Number, "%c record before A record", v.lines[0].Record.Type())
+	}
+}
+
+func checkASingle(v *validator) {
+	n := 0
+	for _, line := range v.lines {
+		if line.Record.Type() != 'A' {
+			continue
+		}
+		n++
+		if n > 1 {
+			v.addf(line.Number, "duplicate A record")
+		}
+	}
+	if n == 0 {
+		v.addf(0, "missing A record")
+	}
+}
+
+func checkHDTERequired(v *validator) {
+	for _, line := range v.lines {
+		if _, ok := line.Record.(*igc.HFDTERecord); ok {
+			return
+		}
+	}
+	v.addf(0, "missing HFDTE record")
+}
+
+func checkHRequired(v *validator) {
+	present := make(map[string]bool)
+	for _, line := range v.lines {
+		switch record := line.Record.(type) {
+		case *igc.HRecord:
+			present[record.TLC] = true
+		case *igc.HFDTERecord:
+			present[record.TLC] = true
+		}
+	}
+	for _, tlc := range RequiredHRecordTLCs {
+		if !present[tlc] {
+			v.addf(0, "missing %s H record", tlc)
+		}
+	}
+}
+
+// checkBefore returns a check that all records of type before precede the
+// first record of type after.
+func checkBefore(before, after byte) func(*validator) {
+	return func(v *validator) {
+		afterLine := 0
+		for _, line := range v.lines {
+			switch recordType := line.Record.Type(); {
+			case recordType == after && afterLine == 0:
+				afterLine = line.Number
+			case recordType == before && afterLine != 0:
+				v.addf(line.Number, "%c record after %c record at line %d", before, after, afterLine)
+			}
+		}
+	}
+}
+
+func checkGLast(v *validator) {
+	gLine := 0
+	for _, line := range v.lines {
+		switch recordType := line.Record.Type(); {
+		case recordType == 'G' && gLine == 0:
+			gLine = line.Number
+		case recordType != 'G' && gLine != 0:
+			v.addf(line.Number, "%c record after G record at line %d", recordType, gLine)
+		}
+	}
+}
+
+func checkDate(v *validator) {
+	for _, line := range v.lines {
+		data := trimLine(line.Raw)
+		switch record := line.Record.(type) {
+		case *igc.HFDTERecord:
+			if len(record.Value) >= 6 && !validDate([]byte(record.Value[:6])) {
+				v.addf(line.Number, "%s: invalid date", record.Value[:6])
+			}
+		case *igc.CRecordDeclaration:
+			// A flight date of 000000 means that no flight date is declared.
+			if len(data) >= 19 && !validDate(data[1:7]) {
+				v.addf(line.Number, "%s: invalid declaration date", data[1:7])
+			}
+			if len(data) >= 19 && string(data[13:19]) != "000000" && !validDate(data[13:19]) {
+				v.addf(line.Number, "%s: invalid flight date", data[13:19])
+			}
+		}
+	}
+}
+
+func checkTimeOfDay(v *validator) {
+	for _, line := range v.lines {
+		data := trimLine(line.Raw)
+		var timeData []byte
+		switch line.Record.(type) {
+		case *igc.BRecord, *igc.ERecord, *igc.FRecord, *igc.KRecord, *igc.NRecord:
+			if len(data) >= 7 {
+				timeData = data[1:7]
+			}
+		case *igc.CRecordDeclaration:
+			if len(data) >= 13 {
+				timeData = data[7:13]
+			}
+		}
+		if timeData == nil {
+			continue
+		}
+		if !validTimeOfDay(timeData) {
+			v.addf(line.Number, "%s: invalid time of day", timeData)
+		}
+	}
+}
+
+func checkMinutes(v *validator) {
+	for _, line := range v.lines {
+		data := trimLine(line.Raw)
+		var latMinutesData, lonMinutesData []byte
+		switch line.Record.(type) {
+		case *igc.BRecord:
+			if len(data) >= 20 {
+				latMinutesData, lonMinutesData = data[9:11], data[18:20]
+			}
+		case *igc.CRecordWaypoint:
+			if len(data) >= 14 {
+				latMinutesData, lonMinutesData = data[3:5], data[12:14]
+			}
+		}
+		if latMinutesData == nil {
+			continue
+		}
+		if digitsValue(latMinutesData) >= 60 {
+			v.addf(line.Number, "%s: invalid minutes of latitude", latMinutesData)
+		}
+		if digitsValue(lonMinutesData) >= 60 {
+			v.addf(line.Number, "%s: invalid minutes of longitude", lonMinutesData)
+		}
+	}
+}
+
+func checkCoordinateRange(v *validator) {
+	for _, line := range v.lines {
+		var lat, lon float64
+		switch record := line.Record.(type) {
+		case *igc.BRecord:
+			lat, lon = record.Lat, record.Lon
+		case *igc.CRecordWaypoint:
+			lat, lon = record.Lat, record.Lon
+		default:
+			continue
+		}
+		if lat < -90 || 90 < lat {
+			v.addf(line.Number, "%f: latitude out of range", lat)
+		}
+		if lon < -180 || 180 < lon {
+			v.addf(line.Number, "%f: longitude out of range", lon)
+		}
+	}
+}
+
+// checkMonotonicFixTime checks the times of day of B records, as written,
+// because the parser moves a time before the previous time to the next day.
+// A time more than half a day before the previous time is taken to be a
+// rollover past midnight UTC.
+func checkMonotonicFixTime(v *validator) {
+	var prevTimeOfDay []byte
+	prevSeconds, prevLine := 0, 0
+	for _, line := range v.lines {
+		if _, ok := line.Record.(*igc.BRecord); !ok {
+			continue
+		}
+		data := trimLine(line.Raw)
+		if len(data) < 7 || !validTimeOfDay(data[1:7]) {
+			continue
+		}
+		timeOfDay := data[1:7]
+		seconds := 3600*digitsValue(timeOfDay[0:2]) + 60*digitsValue(timeOfDay[2:4]) + digitsValue(timeOfDay[4:6])
+		if difference := seconds - prevSeconds; prevLine != 0 && difference <= 0 && difference > -12*3600 {
+			v.addf(line.Number, "fix time %s not after fix time %s at line %d",
+				formatTimeOfDay(timeOfDay), formatTimeOfDay(prevTimeOfDay), prevLine)
+		}
+		prevTimeOfDay = timeOfDay
+		prevSeconds = seconds
+		prevLine = line.Number
+	}
+}
+
+// formatTimeOfDay formats data, in HHMMSS format, as HH:MM:SS.
+func formatTimeOfDay(data []byte) string {
+	return string(data[0:2]) + ":" + string(data[2:4]) + ":" + string(data[4:6])
+}
+
+// trimLine returns raw without its line terminator.
+func trimLine(raw []byte) []byte {
+	return bytes.TrimRight(raw, "\r\n")
+}
+
+// validDate returns whether data, in DDMMYY format, is a valid calendar date.
+func validDate(data []byte) bool {
+	if len(data) != 6 || !isDigits(data) {
+		return false
+	}
+	day := digitsValue(data[0:2])
+	month := digitsValue(data[2:4])
+	year := 2000 + digitsValue(data[4:6])
+	if month < 1 || 12 < month || day < 1 {
+		return false
+	}
+	return day <= time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// validTimeOfDay returns whether data, in HHMMSS format, is a valid time of
+// day.
+func validTimeOfDay(data []byte) bool {
+	return len(data) == 6 && isDigits(data) &&
+		digitsValue(data[0:2]) < 24 &&
+		digitsValue(data[2:4]) < 60 &&
+		digitsValue(data[4:6]) < 60
+}
+
+// isDigits returns whether data consists only of ASCII digits.
+func isDigits(data []byte) bool {
+	for _, c := range data {
+		if c < '0' || '9' < c {
+			return false
+		}
+	}
+	return true
+}
+
+// digitsValue returns the value of the ASCII digits in data.
+func digitsValue(data []byte) int {
+	value := 0
+	for _, c := range data {
+		value = 10*value + int(c-'0')
+	}
+	return value
+}
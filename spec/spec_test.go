@@ -0,0 +1,173 @@
+package spec_test
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-igc"
+	"github.com/twpayne/go-igc/spec"
+)
+
+type violation struct {
+	ruleID   spec.RuleID
+	line     int
+	severity igc.Severity
+	message  string
+}
+
+func TestValidate(t *testing.T) {
+	validHeader := []string{
+		"AXXXABC",
+		"HFDTE020508",
+		"HFPLTPILOTINCHARGE:Pilot",
+		"HFGTYGLIDERTYPE:Glider",
+		"HFGIDGLIDERID:ABC",
+		"HFFTYFRTYPE:Logger",
+		"HFRFWFIRMWAREVERSION:1.0",
+		"HFRHWHARDWAREVERSION:1.0",
+		"HFGPSRECEIVER:GPS",
+		"HFPRSPRESSALTSENSOR:Sensor",
+		"HFALGALTGPS:GEO",
+		"HFALPALTPRESSURE:ISA",
+	}
+	for _, tc := range []struct {
+		name               string
+		lines              []string
+		options            []spec.Option
+		expectedViolations []violation
+	}{
+		{
+			name: "valid",
+			lines: append(validHeader,
+				"I013638FXA",
+				"C020508120000000000000102",
+				"C0000000N00000000ETAKEOFF",
+				"C4556123N00612345ETP1",
+				"C0000000N00000000ELANDING",
+				"B1200004556123N00612345EA0100001100123",
+				"B1200014556123N00612345EA0100001100123",
+				"GABCDEF",
+			),
+		},
+		{
+			name: "invalid_records",
+			lines: append(validHeader,
+				"B1200",
+				"C",
+				"B1200004556123N00612345EA0100001100",
+			),
+		},
+		{
+			name:  "empty",
+			lines: []string{},
+			expectedViolations: []violation{
+				{ruleID: spec.RuleIDASingle, severity: igc.SeverityError, message: "missing A record"},
+				{ruleID: spec.RuleIDHDTERequired, severity: igc.SeverityError, message: "missing HFDTE record"},
+				{ruleID: spec.RuleIDHRequired, severity: igc.SeverityWarning, message: "missing PLT H record"},
+				{ruleID: spec.RuleIDHRequired, severity: igc.SeverityWarning, message: "missing GTY H record"},
+				{ruleID: spec.RuleIDHRequired, severity: igc.SeverityWarning, message: "missing GID H record"},
+				{ruleID: spec.RuleIDHRequired, severity: igc.SeverityWarning, message: "missing FTY H record"},
+				{ruleID: spec.RuleIDHRequired, severity: igc.SeverityWarning, message: "missing RFW H record"},
+				{ruleID: spec.RuleIDHRequired, severity: igc.SeverityWarning, message: "missing RHW H record"},
+				{ruleID: spec.RuleIDHRequired, severity: igc.SeverityWarning, message: "missing GPS H record"},
+				{ruleID: spec.RuleIDHRequired, severity: igc.SeverityWarning, message: "missing PRS H record"},
+				{ruleID: spec.RuleIDHRequired, severity: igc.SeverityWarning, message: "missing ALG H record"},
+				{ruleID: spec.RuleIDHRequired, severity: igc.SeverityWarning, message: "missing ALP H record"},
+			},
+		},
+		{
+			name: "order",
+			lines: []string{
+				"HFDTE020508",
+				"AXXXABC",
+				"AXXXDEF",
+				"B1200004556123N00612345EA0100001100",
+				"HFPLTPILOTINCHARGE:Pilot",
+				"I013636FXA",
+				"C4556123N00612345ETP1",
+				"GABCDEF",
+				"LXXXTEXT",
+			},
+			options: []spec.Option{
+				spec.WithSeverity(spec.RuleIDHRequired, igc.SeverityIgnore),
+			},
+			expectedViolations: []violation{
+				{ruleID: spec.RuleIDAFirst, line: 1, severity: igc.SeverityError, message: "H record before A record"},
+				{ruleID: spec.RuleIDASingle, line: 3, severity: igc.SeverityError, message: "duplicate A record"},
+				{ruleID: spec.RuleIDHBeforeB, line: 5, severity: igc.SeverityWarning, message: "H record after B record at line 4"},
+				{ruleID: spec.RuleIDIBeforeB, line: 6, severity: igc.SeverityError, message: "I record after B record at line 4"},
+				{ruleID: spec.RuleIDCBeforeB, line: 7, severity: igc.SeverityError, message: "C record after B record at line 4"},
+				{ruleID: spec.RuleIDGLast, line: 9, severity: igc.SeverityError, message: "L record after G record at line 8"},
+			},
+		},
+		{
+			name: "values",
+			lines: []string{
+				"AXXXABC",
+				"HFDTE310208",
+				"C300208250000000000000102",
+				"C9156123N00667345ETP1",
+				"B1200004556123N00612345EA0100001100",
+				"B1200004556123N00612345EA0100001100",
+				"B1260004596123N18112345EA0100001100",
+				"E246000PEV",
+			},
+			options: []spec.Option{
+				spec.WithSeverity(spec.RuleIDHRequired, igc.SeverityIgnore),
+				spec.WithSeverity(spec.RuleIDMonotonicFixTime, igc.SeverityWarning),
+			},
+			expectedViolations: []violation{
+				{ruleID: spec.RuleIDDate, line: 2, severity: igc.SeverityError, message: "310208: invalid date"},
+				{ruleID: spec.RuleIDDate, line: 3, severity: igc.SeverityError, message: "300208: invalid declaration date"},
+				{ruleID: spec.RuleIDTimeOfDay, line: 3, severity: igc.SeverityError, message: "250000: invalid time of day"},
+				{ruleID: spec.RuleIDTimeOfDay, line: 7, severity: igc.SeverityError, message: "126000: invalid time of day"},
+				{ruleID: spec.RuleIDTimeOfDay, line: 8, severity: igc.SeverityError, message: "246000: invalid time of day"},
+				{ruleID: spec.RuleIDMinutes, line: 4, severity: igc.SeverityError, message: "67: invalid minutes of longitude"},
+				{ruleID: spec.RuleIDMinutes, line: 7, severity: igc.SeverityError, message: "96: invalid minutes of latitude"},
+				{ruleID: spec.RuleIDCoordinateRange, line: 4, severity: igc.SeverityError, message: "91.935383: latitude out of range"},
+				{ruleID: spec.RuleIDCoordinateRange, line: 7, severity: igc.SeverityError, message: "181.205750: longitude out of range"},
+				{ruleID: spec.RuleIDMonotonicFixTime, line: 6, severity: igc.SeverityWarning, message: "fix time 12:00:00 not after fix time 12:00:00 at line 5"},
+			},
+		},
+		{
+			name: "fix_times",
+			lines: append(validHeader,
+				"B1200054556123N00612345EA0100001100",
+				"B1200004556123N00612345EA0100001100",
+				"B2359594556123N00612345EA0100001100",
+				"B0000014556123N00612345EA0100001100",
+			),
+			expectedViolations: []violation{
+				{ruleID: spec.RuleIDMonotonicFixTime, line: 14, severity: igc.SeverityError, message: "fix time 12:00:00 not after fix time 12:00:05 at line 13"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			igcFile, err := igc.ParseLines(tc.lines)
+			assert.NoError(t, err)
+			actualViolations := make([]violation, 0, len(tc.expectedViolations))
+			for _, v := range spec.Validate(igcFile, tc.options...) {
+				actualViolations = append(actualViolations, violation{
+					ruleID:   v.Rule.ID,
+					line:     v.Line,
+					severity: v.Severity,
+					message:  v.Message,
+				})
+			}
+			if tc.expectedViolations == nil {
+				tc.expectedViolations = []violation{}
+			}
+			assert.Equal(t, tc.expectedViolations, actualViolations)
+		})
+	}
+}
+
+func TestRules(t *testing.T) {
+	for _, rule := range spec.Rules() {
+		assert.Equal(t, rule, spec.RuleByID(rule.ID))
+		assert.NotEqual(t, "", rule.Section)
+		assert.NotEqual(t, "", rule.Description)
+	}
+	assert.Zero(t, spec.RuleByID("unknown"))
+}
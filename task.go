@@ -0,0 +1,183 @@
+package igc
+
+import (
+	"strings"
+	"time"
+)
+
+// A TaskPointType is the type of a point in a declared task.
+type TaskPointType string
+
+// Task point types.
+const (
+	TaskPointTypeTakeoff   TaskPointType = "TAKEOFF"
+	TaskPointTypeStart     TaskPointType = "START"
+	TaskPointTypeTurnpoint TaskPointType = "TURN"
+	TaskPointTypeFinish    TaskPointType = "FINISH"
+	TaskPointTypeLanding   TaskPointType = "LANDING"
+)
+
+// A TaskPoint is a point in a declared task.
+type TaskPoint struct {
+	Type        TaskPointType
+	Name        string
+	Lat         float64
+	Lon         float64
+	Area        bool             // Area is true if the point is an area, for example TURNAREA.
+	Placeholder bool             // Placeholder is true if the coordinates are all zero, meaning that the point is not defined.
+	CRecord     *CRecordWaypoint // CRecord is the C record of the point.
+}
+
+// A Task is a task declared in C records. See section A3.5 of the IGC
+// specification.
+type Task struct {
+	DeclarationTime    time.Time
+	FlightDate         time.Time // FlightDate is the intended date of the flight, or zero if it is not declared.
+	TaskNumber         int
+	NumberOfTurnpoints int // NumberOfTurnpoints is the declared number of turnpoints.
+	Text               string
+	Takeoff            *TaskPoint // Takeoff is the takeoff, or nil if there is none.
+	Start              *TaskPoint // Start is the start, or nil if there is none.
+	Turnpoints         []*TaskPoint
+	Finish             *TaskPoint   // Finish is the finish, or nil if there is none.
+	Landing            *TaskPoint   // Landing is the landing, or nil if there is none.
+	Points             []*TaskPoint // Points are all points in order.
+	Declaration        *CRecordDeclaration
+}
+
+// NewTask returns the task declared in the C records in records, or nil if
+// there is no task declaration.
+//
+// The type of each point is taken from the leading keyword of its text, for
+// example "TAKEOFF Feltre" or "TURN B55". Points without a keyword are
+// assigned types by position, as specified: takeoff, start, turnpoints,
+// finish, and landing.
+func NewTask(records []Record) *Task {
+	var task *Task
+	for _, record := range records {
+		switch record := record.(type) {
+		case *CRecordDeclaration:
+			if record == nil || task != nil {
+				continue
+			}
+			task = &Task{
+				DeclarationTime:    record.DeclarationTime,
+				TaskNumber:         record.TaskNumber,
+				NumberOfTurnpoints: record.NumberOfTurnpoints,
+				Text:               strings.TrimSpace(record.Text),
+				Declaration:        record,
+			}
+			if record.FlightYear != 0 || record.FlightMonth != 0 || record.FlightDay != 0 {
+				task.FlightDate = time.Date(makeYear(record.FlightYear), time.Month(record.FlightMonth), record.FlightDay, 0, 0, 0, 0, time.UTC)
+			}
+		case *CRecordWaypoint:
+			if record == nil || task == nil {
+				continue
+			}
+			task.Points = append(task.Points, newTaskPoint(record))
+		}
+	}
+	if task == nil {
+		return nil
+	}
+
+	hasType := make(map[TaskPointType]bool)
+	for _, point := range task.Points {
+		hasType[point.Type] = true
+	}
+	n := len(task.Points)
+	for i, point := range task.Points {
+		if point.Type == "" {
+			switch {
+			case n < 4:
+				point.Type = TaskPointTypeTurnpoint
+			case i == 0:
+				point.Type = TaskPointTypeTakeoff
+			case i == 1 && !hasType[TaskPointTypeStart]:
+				point.Type = TaskPointTypeStart
+			case i == n-2 && !hasType[TaskPointTypeFinish]:
+				point.Type = TaskPointTypeFinish
+			case i == n-1:
+				point.Type = TaskPointTypeLanding
+			default:
+				point.Type = TaskPointTypeTurnpoint
+			}
+		}
+		switch point.Type {
+		case TaskPointTypeTakeoff:
+			if task.Takeoff == nil {
+				task.Takeoff = point
+			}
+		case TaskPointTypeStart:
+			if task.Start == nil {
+				task.Start = point
+			}
+		case TaskPointTypeTurnpoint:
+			task.Turnpoints = append(task.Turnpoints, point)
+		case TaskPointTypeFinish:
+			if task.Finish == nil {
+				task.Finish = point
+			}
+		case TaskPointTypeLanding:
+			if task.Landing == nil {
+				task.Landing = point
+			}
+		}
+	}
+	return task
+}
+
+// Task returns the task declared in igc's C records, or nil if there is no task
+// declaration.
+func (igc *IGC) Task() *Task {
+	return NewTask(igc.Records)
+}
+
+// Check returns a *TurnpointCountError if the declared number of turnpoints
+// does not match the number of turnpoints.
+func (t *Task) Check() error {
+	if t.NumberOfTurnpoints != len(t.Turnpoints) {
+		return &TurnpointCountError{
+			Declared: t.NumberOfTurnpoints,
+			Actual:   len(t.Turnpoints),
+		}
+	}
+	return nil
+}
+
+// newTaskPoint returns a new TaskPoint from cRecordWaypoint. The type is empty
+// if the text does not start with a keyword.
+func newTaskPoint(cRecordWaypoint *CRecordWaypoint) *TaskPoint {
+	point := &TaskPoint{
+		Lat:         cRecordWaypoint.Lat,
+		Lon:         cRecordWaypoint.Lon,
+		Placeholder: cRecordWaypoint.Lat == 0 && cRecordWaypoint.Lon == 0,
+		CRecord:     cRecordWaypoint,
+	}
+	// Some flight recorders prefix the keyword with the numeric parameters of
+	// the area.
+	text := strings.TrimLeft(cRecordWaypoint.Text, "0123456789")
+	keyword, name, _ := strings.Cut(text, " ")
+	switch strings.ToUpper(keyword) {
+	case "TAKEOFF":
+		point.Type = TaskPointTypeTakeoff
+	case "START":
+		point.Type = TaskPointTypeStart
+	case "STARTAREA":
+		point.Type, point.Area = TaskPointTypeStart, true
+	case "TURN":
+		point.Type = TaskPointTypeTurnpoint
+	case "TURNAREA":
+		point.Type, point.Area = TaskPointTypeTurnpoint, true
+	case "FINISH":
+		point.Type = TaskPointTypeFinish
+	case "FINISHAREA":
+		point.Type, point.Area = TaskPointTypeFinish, true
+	case "LANDING":
+		point.Type = TaskPointTypeLanding
+	default:
+		name = cRecordWaypoint.Text
+	}
+	point.Name = strings.TrimSpace(name)
+	return point
+}
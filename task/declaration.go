@@ -0,0 +1,26 @@
+package task
+
+import (
+	"github.com/twpayne/go-igc"
+	"github.com/twpayne/go-igc/geo"
+)
+
+// NewFromDeclaration returns the task declared in C records in declaration,
+// with a cylinder of radius meters around each point. The takeoff, the
+// landing, and placeholder points are omitted, so the speed section runs from
+// the start to the finish.
+func NewFromDeclaration(declaration *igc.Task, radius float64) *Task {
+	t := &Task{}
+	for _, point := range declaration.Points {
+		if point.Placeholder || point.Type == igc.TaskPointTypeTakeoff || point.Type == igc.TaskPointTypeLanding {
+			continue
+		}
+		t.Turnpoints = append(t.Turnpoints, &Turnpoint{
+			Name:   point.Name,
+			Point:  geo.Point{Lat: point.Lat, Lon: point.Lon},
+			Type:   ZoneTypeCylinder,
+			Radius: radius,
+		})
+	}
+	return t
+}
@@ -0,0 +1,174 @@
+package task
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/twpayne/go-igc/geo"
+)
+
+const (
+	// maxRoutePasses is the maximum number of passes when optimizing a route.
+	maxRoutePasses = 64
+	// routeTolerance is the change in meters in the length of a route below
+	// which its optimization stops.
+	routeTolerance = 1e-3
+	// circleSamples is the number of bearings sampled around a cylinder before
+	// refining the best of them.
+	circleSamples = 36
+	// circleRefinements is the number of golden section iterations when
+	// refining a bearing around a cylinder.
+	circleRefinements = 48
+)
+
+// OptimizedRoute returns the points and the length in meters of the shortest
+// route of t, which is the official task distance. The route starts at the
+// first turnpoint and touches the zone of each following turnpoint in order.
+// Sectors and lines are touched at their turnpoints. The route is calculated
+// on t's model of the Earth.
+func (t *Task) OptimizedRoute() ([]geo.Point, float64) {
+	return optimizeRoute(t.zones(t.earth()))
+}
+
+// optimizeRoute returns the points and the length in meters of the shortest
+// route that starts at the point of the first of zones and touches each
+// following zone in order.
+func optimizeRoute(zones []*zone) ([]geo.Point, float64) {
+	route := make([]geo.Point, 0, len(zones))
+	for _, zone := range zones {
+		route = append(route, zone.Point)
+	}
+	length := math.Inf(1)
+	for range maxRoutePasses {
+		for i := 1; i < len(zones); i++ {
+			if i == len(zones)-1 {
+				route[i] = zones[i].nearest(route[i-1])
+			} else {
+				route[i], _ = zones[i].touch(route[i-1], route[i+1])
+			}
+		}
+		prevLength := length
+		length = 0
+		for i := 1; i < len(route); i++ {
+			length += zones[i].earth.Distance(route[i-1], route[i])
+		}
+		if prevLength-length < routeTolerance {
+			break
+		}
+	}
+	if len(route) < 2 {
+		length = 0
+	}
+	return route, length
+}
+
+// closest returns the index of the point in points with the shortest remaining
+// distance to goal through zones[next:], and that distance. route is the
+// shortest route of the task and remaining are the distances from its points
+// to goal. The remaining distances are measured along route after
+// zones[next], so they are approximate unless zones[next] is the goal.
+func closest(zones []*zone, next int, points []geo.Point, route []geo.Point, remaining []float64) (int, float64) {
+	zone := zones[next]
+	if next == len(zones)-1 {
+		bestIndex, bestDistance := 0, math.Inf(1)
+		for i, point := range points {
+			if distance := zone.goalDistance(point); distance < bestDistance {
+				bestIndex, bestDistance = i, distance
+			}
+		}
+		return bestIndex, bestDistance
+	}
+
+	// The straight line distance to the next point of the route is a lower
+	// bound, so points are considered in order of their lower bounds until no
+	// better point can be found.
+	type candidate struct {
+		index      int
+		lowerBound float64
+	}
+	candidates := make([]candidate, 0, len(points))
+	for i, point := range points {
+		candidates = append(candidates, candidate{
+			index:      i,
+			lowerBound: zone.earth.Distance(point, route[next+1]) + remaining[next+1],
+		})
+	}
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		return cmp.Compare(a.lowerBound, b.lowerBound)
+	})
+	bestIndex, bestDistance := 0, math.Inf(1)
+	for _, candidate := range candidates {
+		if candidate.lowerBound >= bestDistance {
+			break
+		}
+		_, distance := zone.touch(points[candidate.index], route[next+1])
+		if distance += remaining[next+1]; distance < bestDistance {
+			bestIndex, bestDistance = candidate.index, distance
+		}
+	}
+	return bestIndex, bestDistance
+}
+
+// goalDistance returns the distance from p to the goal z.
+func (z *zone) goalDistance(p geo.Point) float64 {
+	distance := z.earth.Distance(p, z.Point)
+	if z.Type == ZoneTypeCylinder {
+		return max(0, distance-z.Radius)
+	}
+	return distance
+}
+
+// nearest returns the point of z nearest to p at which a route ends.
+func (z *zone) nearest(p geo.Point) geo.Point {
+	if z.Type != ZoneTypeCylinder || z.Radius == 0 {
+		return z.Point
+	}
+	return z.earth.Destination(z.Point, z.earth.InitialBearing(z.Point, p), z.Radius)
+}
+
+// touch returns the point of z at which the shortest route from p1 to p2
+// touches z, and the length of the route.
+func (z *zone) touch(p1, p2 geo.Point) (geo.Point, float64) {
+	if z.Type != ZoneTypeCylinder || z.Radius == 0 {
+		return z.Point, z.earth.Distance(p1, z.Point) + z.earth.Distance(z.Point, p2)
+	}
+
+	length := func(bearing float64) float64 {
+		p := z.earth.Destination(z.Point, bearing, z.Radius)
+		return z.earth.Distance(p1, p) + z.earth.Distance(p, p2)
+	}
+
+	bestBearing, bestLength := 0.0, math.Inf(1)
+	for i := range circleSamples {
+		bearing := 360 * float64(i) / circleSamples
+		if l := length(bearing); l < bestLength {
+			bestBearing, bestLength = bearing, l
+		}
+	}
+
+	// Refine the best bearing with a golden section search between its
+	// neighbors.
+	invPhi := (math.Sqrt(5) - 1) / 2
+	a, b := bestBearing-360/circleSamples, bestBearing+360/circleSamples
+	c, d := b-invPhi*(b-a), a+invPhi*(b-a)
+	lengthC, lengthD := length(c), length(d)
+	for range circleRefinements {
+		if lengthC < lengthD {
+			b, d, lengthD = d, c, lengthC
+			c = b - invPhi*(b-a)
+			lengthC = length(c)
+		} else {
+			a, c, lengthC = c, d, lengthD
+			d = a + invPhi*(b-a)
+			lengthD = length(d)
+		}
+	}
+	if lengthC < bestLength {
+		bestBearing, bestLength = c, lengthC
+	}
+	if lengthD < bestLength {
+		bestBearing, bestLength = d, lengthD
+	}
+	return z.earth.Destination(z.Point, bestBearing, z.Radius), bestLength
+}
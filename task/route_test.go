@@ -0,0 +1,224 @@
+package task_test
+
+import (
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-igc"
+	"github.com/twpayne/go-igc/geo"
+	"github.com/twpayne/go-igc/task"
+)
+
+func TestOptimizedRoute(t *testing.T) {
+	for _, earth := range []geo.Earth{geo.FAISphere, geo.WGS84} {
+		// The task is symmetric about the meridian through the turnpoint, so
+		// the route touches the turnpoint cylinder due south of its center.
+		start := geo.Point{Lat: 0, Lon: 0}
+		goal := earth.Destination(start, 90, 40000)
+		middle := earth.Destination(start, 90, 20000)
+		turnpoint := earth.Destination(middle, 0, 10000)
+		touch := earth.Destination(turnpoint, 180, 5000)
+		expected := 2 * earth.Distance(start, touch)
+
+		t.Run("symmetric", func(t *testing.T) {
+			route, distance := (&task.Task{
+				Turnpoints: []*task.Turnpoint{
+					{Point: start, Type: task.ZoneTypeCylinder},
+					{Point: turnpoint, Type: task.ZoneTypeCylinder, Radius: 5000},
+					{Point: goal, Type: task.ZoneTypeCylinder},
+				},
+				Earth: earth,
+			}).OptimizedRoute()
+			assertClose(t, expected, distance, 1e-2)
+			assert.Equal(t, 3, len(route))
+			assertClose(t, 0, earth.Distance(touch, route[1]), 1e-1)
+		})
+
+		t.Run("goal_cylinder", func(t *testing.T) {
+			route, distance := (&task.Task{
+				Turnpoints: []*task.Turnpoint{
+					{Point: start, Type: task.ZoneTypeCylinder, Radius: 400},
+					{Point: middle, Type: task.ZoneTypeCylinder, Radius: 2000},
+					{Point: goal, Type: task.ZoneTypeCylinder, Radius: 1000},
+				},
+				Earth: earth,
+			}).OptimizedRoute()
+			assertClose(t, 39000, distance, 1e-2)
+			assert.Equal(t, start, route[0])
+			assertClose(t, 1000, earth.Distance(goal, route[2]), 1e-2)
+		})
+
+		t.Run("exit_start", func(t *testing.T) {
+			// The start cylinder is centered on takeoff and contains the
+			// first turnpoint, so the route must leave it and return.
+			_, distance := (&task.Task{
+				Turnpoints: []*task.Turnpoint{
+					{Point: start, Type: task.ZoneTypeCylinder},
+					{Point: start, Type: task.ZoneTypeCylinder, Radius: 30000},
+					{Point: middle, Type: task.ZoneTypeCylinder, Radius: 1000},
+					{Point: goal, Type: task.ZoneTypeCylinder},
+				},
+				SSS:   1,
+				Earth: earth,
+			}).OptimizedRoute()
+			assertClose(t, 30000+9000+19000, distance, 1e-1)
+		})
+
+		t.Run("goal_line", func(t *testing.T) {
+			_, distance := (&task.Task{
+				Turnpoints: []*task.Turnpoint{
+					{Point: start, Type: task.ZoneTypeCylinder, Radius: 400},
+					{Point: middle, Type: task.ZoneTypeCylinder, Radius: 2000},
+					{Point: goal, Type: task.ZoneTypeLine, Radius: 1000},
+				},
+				Earth: earth,
+			}).OptimizedRoute()
+			assertClose(t, 40000, distance, 1e-2)
+		})
+	}
+}
+
+func TestOptimizedRouteEmpty(t *testing.T) {
+	route, distance := (&task.Task{}).OptimizedRoute()
+	assert.Equal(t, 0, len(route))
+	assert.Equal(t, 0.0, distance)
+
+	route, distance = (&task.Task{
+		Turnpoints: []*task.Turnpoint{
+			{Point: launch, Type: task.ZoneTypeCylinder, Radius: 1000},
+		},
+	}).OptimizedRoute()
+	assert.Equal(t, []geo.Point{launch}, route)
+	assert.Equal(t, 0.0, distance)
+}
+
+func TestNewFromDeclaration(t *testing.T) {
+	file, err := os.Open("../testdata/0014.igc")
+	assert.NoError(t, err)
+	defer file.Close()
+	igcFile, err := igc.Parse(file)
+	assert.NoError(t, err)
+
+	declaration := igcFile.Task()
+	assert.NotZero(t, declaration)
+	actual := task.NewFromDeclaration(declaration, 0)
+	assert.Equal(t, 2, len(actual.Turnpoints))
+	assert.Equal(t, "Becs de Bosson", actual.Turnpoints[0].Name)
+	assert.Equal(t, "Vercorin", actual.Turnpoints[1].Name)
+
+	for _, earth := range []geo.Earth{geo.FAISphere, geo.WGS84} {
+		actual.Earth = earth
+		_, distance := actual.OptimizedRoute()
+		assertClose(t, earth.Distance(actual.Turnpoints[0].Point, actual.Turnpoints[1].Point), distance, 1e-9)
+	}
+
+	cylinders := task.NewFromDeclaration(declaration, 400)
+	_, distance := cylinders.OptimizedRoute()
+	assertClose(t, geo.FAISphere.Distance(cylinders.Turnpoints[0].Point, cylinders.Turnpoints[1].Point)-400, distance, 1e-2)
+}
+
+func TestParseXCTrack(t *testing.T) {
+	date := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	actual, err := task.ParseXCTrack(strings.NewReader(`{
+		"taskType": "CLASSIC",
+		"version": 1,
+		"earthModel": "WGS84",
+		"turnpoints": [
+			{"type": "TAKEOFF", "radius": 400, "waypoint": {"name": "Launch", "lat": 46, "lon": 7}},
+			{"type": "SSS", "radius": 5000, "waypoint": {"name": "Start", "lat": 46, "lon": 7}},
+			{"radius": 2000, "waypoint": {"name": "TP", "lat": 46.2, "lon": 7}},
+			{"type": "ESS", "radius": 3000, "waypoint": {"name": "ESS", "lat": 46.4, "lon": 7}},
+			{"radius": 500, "waypoint": {"name": "Goal", "lat": 46.4, "lon": 7}}
+		],
+		"sss": {"type": "RACE", "direction": "EXIT", "timeGates": ["11:00:00Z", "11:15:00Z"]},
+		"goal": {"type": "LINE", "deadline": "17:30:00Z"}
+	}`), date)
+	assert.NoError(t, err)
+	assert.Equal(t, geo.Earth(geo.WGS84), actual.Earth)
+	assert.Equal(t, 5, len(actual.Turnpoints))
+	assert.Equal(t, &task.Turnpoint{
+		Name:   "TP",
+		Point:  geo.Point{Lat: 46.2, Lon: 7},
+		Type:   task.ZoneTypeCylinder,
+		Radius: 2000,
+	}, actual.Turnpoints[2])
+	assert.Equal(t, task.ZoneTypeLine, actual.Turnpoints[4].Type)
+	assert.Equal(t, 1, actual.SSS)
+	assert.Equal(t, 3, actual.ESS)
+	assert.Equal(t, task.StartDirection(""), actual.StartDirection)
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 7, 1, 11, 0, 0, 0, time.UTC),
+		time.Date(2024, 7, 1, 11, 15, 0, 0, time.UTC),
+	}, actual.StartGates)
+	assert.Equal(t, time.Date(2024, 7, 1, 17, 30, 0, 0, time.UTC), actual.Deadline)
+
+	// The route leaves the start cylinder and passes through the turnpoint
+	// cylinder to the ESS cylinder and the goal line at its center.
+	_, distance := actual.OptimizedRoute()
+	expected := geo.WGS84.Distance(actual.Turnpoints[0].Point, actual.Turnpoints[4].Point)
+	assertClose(t, expected, distance, 1e-1)
+}
+
+func TestParseXCTrackErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		data string
+	}{
+		{name: "invalid_json", data: `{`},
+		{name: "unsupported_version", data: `{"version": 2}`},
+		{name: "no_turnpoints", data: `{"version": 1}`},
+		{name: "invalid_time_gate", data: `{"version": 1, "turnpoints": [{"radius": 400}], "sss": {"timeGates": ["noon"]}}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := task.ParseXCTrack(strings.NewReader(tc.data), time.Time{})
+			assert.Error(t, err)
+		})
+	}
+}
+
+// TestOptimizedRouteBruteForce checks the optimized route of a real task
+// against a brute force search through points sampled around each cylinder.
+// The task is the task declared in testdata/2024-06-16-Renato-Spaeni.igc.igc.
+func TestOptimizedRouteBruteForce(t *testing.T) {
+	const samples = 720
+
+	file, err := os.Open("../testdata/2024-06-16-Renato-Spaeni.xctsk")
+	assert.NoError(t, err)
+	defer file.Close()
+	actual, err := task.ParseXCTrack(file, time.Date(2024, 6, 16, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, 8, len(actual.Turnpoints))
+	assert.Equal(t, 1, actual.SSS)
+	assert.Equal(t, 6, actual.ESS)
+
+	for _, earth := range []geo.Earth{geo.FAISphere, geo.WGS84} {
+		// bests are the lengths of the shortest routes to each of points,
+		// which are sampled around the previous cylinder.
+		points := []geo.Point{actual.Turnpoints[0].Point}
+		bests := []float64{0}
+		for _, turnpoint := range actual.Turnpoints[1:] {
+			nextPoints := make([]geo.Point, samples)
+			nextBests := make([]float64, samples)
+			for i := range samples {
+				nextPoints[i] = earth.Destination(turnpoint.Point, 360*float64(i)/samples, turnpoint.Radius)
+				nextBests[i] = math.Inf(1)
+				for j, point := range points {
+					nextBests[i] = min(nextBests[i], bests[j]+earth.Distance(point, nextPoints[i]))
+				}
+			}
+			points, bests = nextPoints, nextBests
+		}
+		expected := slices.Min(bests)
+
+		actual.Earth = earth
+		_, distance := actual.OptimizedRoute()
+		assert.True(t, distance <= expected+1e-3, "expected at most %f, got %f", expected, distance)
+		assert.True(t, distance >= expected-1, "expected at least %f, got %f", expected-1, distance)
+	}
+}
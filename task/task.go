@@ -0,0 +1,388 @@
+// Package task implements the verification and optimized distances of
+// competition tasks flown in paragliding, hang gliding, and gliding
+// competitions.
+//
+// A pilot reaches a turnpoint at the first fix inside its zone, or at the
+// first fix after crossing its line. Times are the times of fixes and are not
+// interpolated between fixes.
+package task
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/twpayne/go-igc"
+	"github.com/twpayne/go-igc/geo"
+)
+
+// CIVLTolerance is the tolerance of turnpoint radii in CIVL competitions,
+// 0.5% of the radius.
+const CIVLTolerance = 0.005
+
+// A ZoneType is the type of the observation zone of a turnpoint.
+type ZoneType string
+
+// Zone types.
+const (
+	// ZoneTypeCylinder is a cylinder centered on the turnpoint.
+	ZoneTypeCylinder ZoneType = "cylinder"
+	// ZoneTypeSector is an FAI sector, a 90° sector symmetric about the
+	// bisector of the legs to and from the turnpoint and pointing away from
+	// them.
+	ZoneTypeSector ZoneType = "sector"
+	// ZoneTypeLine is a line centered on the turnpoint and perpendicular to
+	// the leg from the turnpoint if it is the first turnpoint or the start of
+	// the speed section, or to the leg to the turnpoint otherwise. It must be
+	// crossed in the direction of the leg.
+	ZoneTypeLine ZoneType = "line"
+)
+
+// A StartDirection is the direction in which the start of the speed section
+// must be crossed. It does not apply to lines.
+type StartDirection string
+
+// Start directions.
+const (
+	StartDirectionExit  StartDirection = "exit"
+	StartDirectionEnter StartDirection = "enter"
+)
+
+// A StartRule selects which valid crossing of the start of the speed section
+// is the pilot's start.
+type StartRule string
+
+// Start rules.
+const (
+	// StartRuleLast selects the last valid crossing before the pilot reaches
+	// the turnpoint after the start.
+	StartRuleLast StartRule = "last"
+	// StartRuleFirst selects the first valid crossing.
+	StartRuleFirst StartRule = "first"
+)
+
+// A Turnpoint is a turnpoint of a task.
+type Turnpoint struct {
+	Name   string
+	Point  geo.Point
+	Type   ZoneType
+	Radius float64 // Radius is the radius of a cylinder or sector, or half the length of a line, in meters. A sector with a zero radius has no limit.
+}
+
+// A Task is a competition task. The first turnpoint is usually the takeoff and
+// the last turnpoint is the goal.
+//
+// The zero values of StartDirection, StartRule, and Earth are
+// StartDirectionExit, StartRuleLast, and geo.FAISphere.
+type Task struct {
+	Turnpoints     []*Turnpoint
+	SSS            int // SSS is the index of the start of the speed section in Turnpoints.
+	ESS            int // ESS is the index of the end of the speed section in Turnpoints. If it is not after SSS then the end of the speed section is the goal.
+	StartDirection StartDirection
+	StartRule      StartRule
+	StartGates     []time.Time // StartGates are the times of the start gates in order. If there are none then the task is an elapsed time task.
+	Deadline       time.Time   // Deadline is the time after which fixes are ignored, or zero if there is none.
+	Tolerance      float64     // Tolerance is the tolerance of turnpoint radii as a fraction of the radius, for example CIVLTolerance. The zero value is no tolerance.
+	Earth          geo.Earth   // Earth is the model of the Earth used to calculate distances.
+}
+
+// An Achievement is the reaching of a turnpoint.
+type Achievement struct {
+	Turnpoint *Turnpoint
+	Index     int // Index is the index of Turnpoint in the task's Turnpoints.
+	Time      time.Time
+	BRecord   *igc.BRecord
+}
+
+// A Result is the result of a flight of a task.
+type Result struct {
+	Achievements     []*Achievement // Achievements are the achievements of the reached turnpoints in order.
+	Start            *Achievement   // Start is the valid start, or nil if there is none.
+	StartTime        time.Time      // StartTime is the time of the last start gate before Start, or the time of Start for elapsed time tasks.
+	ESS              *Achievement   // ESS is the achievement of the end of the speed section, or nil if it was not reached.
+	Goal             *Achievement   // Goal is the achievement of the goal, or nil if it was not reached.
+	SpeedSectionTime time.Duration  // SpeedSectionTime is the time from StartTime to ESS, or zero if ESS was not reached.
+	TaskDistance     float64        // TaskDistance is the length of the shortest route of the task in meters.
+	Distance         float64        // Distance is the distance flown in meters, which is TaskDistance for pilots in goal.
+	DistanceBRecord  *igc.BRecord   // DistanceBRecord is the B record at which Distance was reached.
+}
+
+// Verify returns the result of the flight in igcFile.
+func (t *Task) Verify(igcFile *igc.IGC) *Result {
+	return t.VerifyBRecords(igcFile.BRecords)
+}
+
+// VerifyBRecords returns the result of the flight in bRecords.
+//
+// Pilots who are not in goal are credited with the distance of the task minus
+// their remaining distance to goal. This is measured from the fix after their
+// last reached turnpoint that is closest to goal along the shortest route of
+// the task, and is the length of the shortest route from that fix.
+func (t *Task) VerifyBRecords(bRecords []*igc.BRecord) *Result {
+	earth := t.earth()
+	zones := t.zones(earth)
+	route, _ := t.OptimizedRoute()
+	remaining := make([]float64, len(route))
+	for i := len(route) - 2; i >= 0; i-- {
+		remaining[i] = remaining[i+1] + earth.Distance(route[i], route[i+1])
+	}
+
+	points := make([]geo.Point, 0, len(bRecords))
+	fixes := make([]*igc.BRecord, 0, len(bRecords))
+	for _, bRecord := range bRecords {
+		if bRecord == nil || !t.Deadline.IsZero() && bRecord.Time.After(t.Deadline) {
+			continue
+		}
+		points = append(points, geo.Point{Lat: bRecord.Lat, Lon: bRecord.Lon})
+		fixes = append(fixes, bRecord)
+	}
+
+	result := &Result{}
+	if len(route) > 0 {
+		result.TaskDistance = remaining[0]
+	}
+	ess := t.ESS
+	if ess <= t.SSS || ess >= len(t.Turnpoints) {
+		ess = len(t.Turnpoints) - 1
+	}
+	from := 0
+	for index, zone := range zones {
+		var i int
+		if index == t.SSS {
+			i = t.start(zones, points, fixes, from)
+		} else {
+			i = zone.reach(points, from)
+		}
+		if i < 0 {
+			break
+		}
+		achievement := &Achievement{
+			Turnpoint: zone.Turnpoint,
+			Index:     index,
+			Time:      fixes[i].Time,
+			BRecord:   fixes[i],
+		}
+		result.Achievements = append(result.Achievements, achievement)
+		switch index {
+		case t.SSS:
+			result.Start = achievement
+			result.StartTime = t.startTime(achievement.Time)
+		case ess:
+			result.ESS = achievement
+			result.SpeedSectionTime = achievement.Time.Sub(result.StartTime)
+		}
+		if index == len(zones)-1 {
+			result.Goal = achievement
+		}
+		from = i
+	}
+
+	switch next := len(result.Achievements); {
+	case result.Goal != nil:
+		result.Distance = result.TaskDistance
+		result.DistanceBRecord = result.Goal.BRecord
+	case next > 0:
+		i, distance := closest(zones, next, points[from:], route, remaining)
+		if next < len(zones)-1 {
+			// The shortest route from the fix might touch the later zones
+			// elsewhere than the shortest route of the task.
+			start := &zone{
+				Turnpoint: &Turnpoint{Point: points[from+i], Type: ZoneTypeCylinder},
+				earth:     earth,
+			}
+			_, optimized := optimizeRoute(append([]*zone{start}, zones[next:]...))
+			distance = min(distance, optimized)
+		}
+		result.Distance = max(0, result.TaskDistance-distance)
+		result.DistanceBRecord = fixes[from+i]
+	}
+	return result
+}
+
+// earth returns the model of the Earth of t.
+func (t *Task) earth() geo.Earth {
+	if t.Earth == nil {
+		return geo.FAISphere
+	}
+	return t.Earth
+}
+
+// start returns the index of the valid start in points, searching from the
+// index from, or -1 if there is none.
+func (t *Task) start(zones []*zone, points []geo.Point, fixes []*igc.BRecord, from int) int {
+	zone := zones[t.SSS]
+	exit := t.StartDirection != StartDirectionEnter
+	var crossings []int
+	for i := from + 1; i < len(points); i++ {
+		if len(t.StartGates) > 0 && fixes[i].Time.Before(t.StartGates[0]) {
+			continue
+		}
+		if zone.startCrossed(points[i-1], points[i], exit) {
+			crossings = append(crossings, i)
+		}
+	}
+	switch {
+	case len(crossings) == 0:
+		return -1
+	case t.StartRule == StartRuleFirst || t.SSS+1 >= len(zones):
+		return crossings[0]
+	}
+	next := zones[t.SSS+1].reach(points, crossings[0])
+	if next < 0 {
+		next = len(points)
+	}
+	last := crossings[0]
+	for _, i := range crossings {
+		if i <= next {
+			last = i
+		}
+	}
+	return last
+}
+
+// startTime returns the start time of a pilot who started at crossingTime.
+func (t *Task) startTime(crossingTime time.Time) time.Time {
+	if len(t.StartGates) == 0 {
+		return crossingTime
+	}
+	i, found := slices.BinarySearchFunc(t.StartGates, crossingTime, time.Time.Compare)
+	if found {
+		return t.StartGates[i]
+	}
+	return t.StartGates[i-1]
+}
+
+// A zone is the observation zone of a turnpoint.
+type zone struct {
+	*Turnpoint
+	earth     geo.Earth
+	tolerance float64
+	bearing   float64 // bearing is the bearing of the bisector of a sector or the direction of crossing of a line.
+}
+
+// zones returns the zones of the turnpoints of t.
+func (t *Task) zones(earth geo.Earth) []*zone {
+	zones := make([]*zone, 0, len(t.Turnpoints))
+	for i, turnpoint := range t.Turnpoints {
+		z := &zone{
+			Turnpoint: turnpoint,
+			earth:     earth,
+			tolerance: t.Tolerance,
+		}
+		// The legs to and from the turnpoint are to the nearest turnpoints at
+		// other points, for example an ESS cylinder and a goal line with the
+		// same center.
+		var prev, next *Turnpoint
+		for j := i - 1; j >= 0 && prev == nil; j-- {
+			if t.Turnpoints[j].Point != turnpoint.Point {
+				prev = t.Turnpoints[j]
+			}
+		}
+		for j := i + 1; j < len(t.Turnpoints) && next == nil; j++ {
+			if t.Turnpoints[j].Point != turnpoint.Point {
+				next = t.Turnpoints[j]
+			}
+		}
+		switch turnpoint.Type {
+		case ZoneTypeCylinder:
+		case ZoneTypeSector:
+			switch {
+			case prev != nil && next != nil:
+				bearing1 := geo.Radians(earth.InitialBearing(turnpoint.Point, prev.Point))
+				bearing2 := geo.Radians(earth.InitialBearing(turnpoint.Point, next.Point))
+				z.bearing = geo.Degrees(math.Atan2(math.Sin(bearing1)+math.Sin(bearing2), math.Cos(bearing1)+math.Cos(bearing2))) + 180
+			case prev != nil:
+				z.bearing = earth.InitialBearing(turnpoint.Point, prev.Point) + 180
+			case next != nil:
+				z.bearing = earth.InitialBearing(turnpoint.Point, next.Point) + 180
+			}
+		case ZoneTypeLine:
+			if next != nil && (prev == nil || i == t.SSS) {
+				z.bearing = earth.InitialBearing(turnpoint.Point, next.Point)
+			} else if prev != nil {
+				z.bearing = earth.FinalBearing(prev.Point, turnpoint.Point)
+			}
+		}
+		zones = append(zones, z)
+	}
+	return zones
+}
+
+// maxRadius returns the radius of z including the tolerance.
+func (z *zone) maxRadius() float64 {
+	return z.Radius * (1 + z.tolerance)
+}
+
+// minRadius returns the radius of z excluding the tolerance.
+func (z *zone) minRadius() float64 {
+	return z.Radius * (1 - z.tolerance)
+}
+
+// inside returns whether p is inside z.
+func (z *zone) inside(p geo.Point) bool {
+	distance := z.earth.Distance(z.Point, p)
+	if z.Type != ZoneTypeSector {
+		return distance <= z.maxRadius()
+	}
+	if z.Radius != 0 && distance > z.maxRadius() {
+		return false
+	}
+	return distance == 0 || math.Abs(geo.BearingDifference(z.bearing, z.earth.InitialBearing(z.Point, p))) <= 45
+}
+
+// lineCrossed returns whether the segment from p1 to p2 crosses the line z in
+// its direction.
+func (z *zone) lineCrossed(p1, p2 geo.Point) bool {
+	along1, across1 := z.lineCoordinates(p1)
+	along2, across2 := z.lineCoordinates(p2)
+	if along1 >= 0 || along2 < 0 {
+		return false
+	}
+	across := across1 + (across2-across1)*along1/(along1-along2)
+	return math.Abs(across) <= z.maxRadius()
+}
+
+// lineCoordinates returns the distances of p along the direction of the line
+// z and across it.
+func (z *zone) lineCoordinates(p geo.Point) (float64, float64) {
+	distance := z.earth.Distance(z.Point, p)
+	angle := geo.Radians(z.earth.InitialBearing(z.Point, p) - z.bearing)
+	return distance * math.Cos(angle), distance * math.Sin(angle)
+}
+
+// reach returns the index of the first point in points at which z is reached,
+// searching from the index from, or -1 if it is not reached.
+func (z *zone) reach(points []geo.Point, from int) int {
+	for i := from; i < len(points); i++ {
+		if z.Type == ZoneTypeLine {
+			if i > from && z.lineCrossed(points[i-1], points[i]) {
+				return i
+			}
+		} else if z.inside(points[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// startCrossed returns whether the segment from p1 to p2 is a start crossing
+// of z. With the tolerance, a cylinder is exited when the inside edge of its
+// tolerance band is crossed, and entered when the outside edge is crossed.
+func (z *zone) startCrossed(p1, p2 geo.Point, exit bool) bool {
+	switch z.Type {
+	case ZoneTypeCylinder:
+		distance1 := z.earth.Distance(z.Point, p1)
+		distance2 := z.earth.Distance(z.Point, p2)
+		if exit {
+			return distance1 < z.minRadius() && distance2 >= z.minRadius()
+		}
+		return distance1 > z.maxRadius() && distance2 <= z.maxRadius()
+	case ZoneTypeLine:
+		return z.lineCrossed(p1, p2)
+	case ZoneTypeSector:
+		inside1, inside2 := z.inside(p1), z.inside(p2)
+		return inside1 != inside2 && inside1 == exit
+	default:
+		return false
+	}
+}
@@ -0,0 +1,429 @@
+package task_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-igc/geo"
+	"github.com/twpayne/go-igc/internal/synthetic"
+	"github.com/twpayne/go-igc/task"
+)
+
+var (
+	startTime = time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	launch    = geo.Point{Lat: 46, Lon: 7}
+)
+
+// north returns the point distance meters north of launch.
+func north(distance float64) geo.Point {
+	return geo.FAISphere.Destination(launch, 0, distance)
+}
+
+// newTask returns a task with an exit start cylinder of radius 1km at launch,
+// a turnpoint cylinder of radius 2.004km 20km north, and a goal 40km north.
+func newTask(goal *task.Turnpoint) *task.Task {
+	return &task.Task{
+		Turnpoints: []*task.Turnpoint{
+			{Name: "SSS", Point: launch, Type: task.ZoneTypeCylinder, Radius: 1000},
+			{Name: "TP", Point: north(20000), Type: task.ZoneTypeCylinder, Radius: 2004},
+			goal,
+		},
+		Tolerance: task.CIVLTolerance,
+	}
+}
+
+func goalCylinder() *task.Turnpoint {
+	return &task.Turnpoint{Name: "Goal", Point: north(40000), Type: task.ZoneTypeCylinder, Radius: 1000}
+}
+
+func assertClose(t *testing.T, expected, actual, tolerance float64) {
+	t.Helper()
+	assert.True(t, math.Abs(expected-actual) <= tolerance, "expected %f, got %f", expected, actual)
+}
+
+func TestVerify(t *testing.T) {
+	for _, tc := range []struct {
+		name                     string
+		task                     *task.Task
+		origin                   geo.Point
+		segments                 []synthetic.Segment
+		expectedAchievementTimes []time.Duration
+		expectedStartTime        time.Duration
+		expectedESSTime          time.Duration
+		expectedGoal             bool
+		expectedDistance         float64
+	}{
+		{
+			name: "elapsed_time_goal",
+			task: newTask(goalCylinder()),
+			segments: []synthetic.Segment{
+				{Duration: 10 * time.Minute},
+				{Duration: 70 * time.Minute, GroundSpeed: 10},
+			},
+			expectedAchievementTimes: []time.Duration{
+				10*time.Minute + 100*time.Second,
+				10*time.Minute + 1799*time.Second,
+				10*time.Minute + 3900*time.Second,
+			},
+			expectedStartTime: 10*time.Minute + 100*time.Second,
+			expectedESSTime:   10*time.Minute + 3900*time.Second,
+			expectedGoal:      true,
+			expectedDistance:  39000,
+		},
+		{
+			name: "start_gates",
+			task: func() *task.Task {
+				t := newTask(goalCylinder())
+				t.StartGates = []time.Time{
+					startTime.Add(5 * time.Minute),
+					startTime.Add(8 * time.Minute),
+					startTime.Add(15 * time.Minute),
+				}
+				return t
+			}(),
+			segments: []synthetic.Segment{
+				{Duration: 10 * time.Minute},
+				{Duration: 70 * time.Minute, GroundSpeed: 10},
+			},
+			expectedAchievementTimes: []time.Duration{
+				10*time.Minute + 100*time.Second,
+				10*time.Minute + 1799*time.Second,
+				10*time.Minute + 3900*time.Second,
+			},
+			expectedStartTime: 8 * time.Minute,
+			expectedESSTime:   10*time.Minute + 3900*time.Second,
+			expectedGoal:      true,
+			expectedDistance:  39000,
+		},
+		{
+			name: "early_start",
+			task: func() *task.Task {
+				t := newTask(goalCylinder())
+				t.StartGates = []time.Time{startTime.Add(time.Hour)}
+				return t
+			}(),
+			segments: []synthetic.Segment{
+				{Duration: 10 * time.Minute},
+				{Duration: 70 * time.Minute, GroundSpeed: 10},
+			},
+		},
+		{
+			name: "landed_out",
+			task: newTask(goalCylinder()),
+			segments: []synthetic.Segment{
+				{Duration: 10 * time.Minute},
+				{Duration: 3000 * time.Second, GroundSpeed: 10},
+				{Duration: 10 * time.Minute},
+			},
+			expectedAchievementTimes: []time.Duration{
+				10*time.Minute + 100*time.Second,
+				10*time.Minute + 1799*time.Second,
+			},
+			expectedStartTime: 10*time.Minute + 100*time.Second,
+			expectedDistance:  30000,
+		},
+		{
+			name: "landed_out_before_turnpoint",
+			task: newTask(goalCylinder()),
+			segments: []synthetic.Segment{
+				{Duration: 10 * time.Minute},
+				{Duration: 1000 * time.Second, GroundSpeed: 10},
+				{Duration: 10 * time.Minute},
+			},
+			expectedAchievementTimes: []time.Duration{
+				10*time.Minute + 100*time.Second,
+			},
+			expectedStartTime: 10*time.Minute + 100*time.Second,
+			expectedDistance:  10000,
+		},
+		{
+			name: "deadline",
+			task: func() *task.Task {
+				t := newTask(goalCylinder())
+				t.Deadline = startTime.Add(time.Hour)
+				return t
+			}(),
+			segments: []synthetic.Segment{
+				{Duration: 10 * time.Minute},
+				{Duration: 70 * time.Minute, GroundSpeed: 10},
+			},
+			expectedAchievementTimes: []time.Duration{
+				10*time.Minute + 100*time.Second,
+				10*time.Minute + 1799*time.Second,
+			},
+			expectedStartTime: 10*time.Minute + 100*time.Second,
+			expectedDistance:  30000,
+		},
+		{
+			name: "last_start",
+			task: newTask(goalCylinder()),
+			segments: []synthetic.Segment{
+				{Duration: 200 * time.Second, GroundSpeed: 10},
+				{Duration: 200 * time.Second, GroundSpeed: 10, Track: 180},
+				{Duration: 70 * time.Minute, GroundSpeed: 10},
+			},
+			expectedAchievementTimes: []time.Duration{
+				500 * time.Second,
+				400*time.Second + 1799*time.Second,
+				400*time.Second + 3900*time.Second,
+			},
+			expectedStartTime: 500 * time.Second,
+			expectedESSTime:   400*time.Second + 3900*time.Second,
+			expectedGoal:      true,
+			expectedDistance:  39000,
+		},
+		{
+			name: "first_start",
+			task: func() *task.Task {
+				t := newTask(goalCylinder())
+				t.StartRule = task.StartRuleFirst
+				return t
+			}(),
+			segments: []synthetic.Segment{
+				{Duration: 200 * time.Second, GroundSpeed: 10},
+				{Duration: 200 * time.Second, GroundSpeed: 10, Track: 180},
+				{Duration: 70 * time.Minute, GroundSpeed: 10},
+			},
+			expectedAchievementTimes: []time.Duration{
+				100 * time.Second,
+				400*time.Second + 1799*time.Second,
+				400*time.Second + 3900*time.Second,
+			},
+			expectedStartTime: 100 * time.Second,
+			expectedESSTime:   400*time.Second + 3900*time.Second,
+			expectedGoal:      true,
+			expectedDistance:  39000,
+		},
+		{
+			name: "enter_start",
+			task: func() *task.Task {
+				t := newTask(goalCylinder())
+				t.Turnpoints[0].Point = north(5000)
+				t.StartDirection = task.StartDirectionEnter
+				return t
+			}(),
+			segments: []synthetic.Segment{
+				{Duration: 70 * time.Minute, GroundSpeed: 10},
+			},
+			expectedAchievementTimes: []time.Duration{
+				400 * time.Second,
+				1799 * time.Second,
+				3900 * time.Second,
+			},
+			expectedStartTime: 400 * time.Second,
+			expectedESSTime:   3900 * time.Second,
+			expectedGoal:      true,
+			expectedDistance:  34000,
+		},
+		{
+			name: "ess_before_goal",
+			task: func() *task.Task {
+				t := newTask(goalCylinder())
+				t.Turnpoints[2].Radius = 3000
+				t.Turnpoints = append(t.Turnpoints, &task.Turnpoint{
+					Name:   "Goal",
+					Point:  north(40000),
+					Type:   task.ZoneTypeLine,
+					Radius: 500,
+				})
+				t.ESS = 2
+				return t
+			}(),
+			segments: []synthetic.Segment{
+				{Duration: 70 * time.Minute, GroundSpeed: 10},
+			},
+			expectedAchievementTimes: []time.Duration{
+				100 * time.Second,
+				1799 * time.Second,
+				3699 * time.Second,
+				4001 * time.Second,
+			},
+			expectedStartTime: 100 * time.Second,
+			expectedESSTime:   3699 * time.Second,
+			expectedGoal:      true,
+			expectedDistance:  40000,
+		},
+		{
+			name: "goal_line",
+			task: newTask(&task.Turnpoint{Name: "Goal", Point: north(40000), Type: task.ZoneTypeLine, Radius: 1000}),
+			segments: []synthetic.Segment{
+				{Duration: 70 * time.Minute, GroundSpeed: 10},
+			},
+			expectedAchievementTimes: []time.Duration{
+				100 * time.Second,
+				1799 * time.Second,
+				4001 * time.Second,
+			},
+			expectedStartTime: 100 * time.Second,
+			expectedESSTime:   4001 * time.Second,
+			expectedGoal:      true,
+			expectedDistance:  40000,
+		},
+		{
+			name:   "goal_line_missed",
+			task:   newTask(&task.Turnpoint{Name: "Goal", Point: north(40000), Type: task.ZoneTypeLine, Radius: 500}),
+			origin: geo.FAISphere.Destination(launch, 90, 800),
+			segments: []synthetic.Segment{
+				{Duration: 70 * time.Minute, GroundSpeed: 10},
+			},
+			expectedAchievementTimes: []time.Duration{
+				60 * time.Second,
+				1816 * time.Second,
+			},
+			expectedStartTime: 60 * time.Second,
+			expectedDistance:  39205,
+		},
+		{
+			name: "tolerance",
+			task: func() *task.Task {
+				t := newTask(goalCylinder())
+				t.Turnpoints[1].Point = geo.FAISphere.Destination(north(20000), 270, 2010)
+				return t
+			}(),
+			segments: []synthetic.Segment{
+				{Duration: 70 * time.Minute, GroundSpeed: 10},
+			},
+			expectedAchievementTimes: []time.Duration{
+				100 * time.Second,
+				1988 * time.Second,
+				3900 * time.Second,
+			},
+			expectedStartTime: 100 * time.Second,
+			expectedESSTime:   3900 * time.Second,
+			expectedGoal:      true,
+		},
+		{
+			name: "outside_tolerance",
+			task: func() *task.Task {
+				t := newTask(goalCylinder())
+				t.Turnpoints[1].Point = geo.FAISphere.Destination(north(20000), 270, 2018)
+				return t
+			}(),
+			segments: []synthetic.Segment{
+				{Duration: 70 * time.Minute, GroundSpeed: 10},
+			},
+			expectedAchievementTimes: []time.Duration{
+				100 * time.Second,
+			},
+			expectedStartTime: 100 * time.Second,
+		},
+		{
+			name: "sector",
+			task: &task.Task{
+				Turnpoints: []*task.Turnpoint{
+					{Name: "Start", Point: launch, Type: task.ZoneTypeLine, Radius: 1000},
+					{Name: "TP", Point: north(20000), Type: task.ZoneTypeSector},
+					{Name: "Finish", Point: geo.FAISphere.Destination(north(20000), 90, 20000), Type: task.ZoneTypeLine, Radius: 2000},
+				},
+			},
+			origin: geo.FAISphere.Destination(north(-995), 270, 300),
+			segments: []synthetic.Segment{
+				{Duration: 2200 * time.Second, GroundSpeed: 10},
+				{Duration: 2500 * time.Second, GroundSpeed: 10, Track: 90},
+			},
+			expectedAchievementTimes: []time.Duration{
+				100 * time.Second,
+				2100 * time.Second,
+				4230 * time.Second,
+			},
+			expectedStartTime: 100 * time.Second,
+			expectedESSTime:   4230 * time.Second,
+			expectedGoal:      true,
+			expectedDistance:  40000,
+		},
+		{
+			name: "sector_missed",
+			task: &task.Task{
+				Turnpoints: []*task.Turnpoint{
+					{Name: "Start", Point: launch, Type: task.ZoneTypeLine, Radius: 1000},
+					{Name: "TP", Point: north(20000), Type: task.ZoneTypeSector},
+					{Name: "Finish", Point: geo.FAISphere.Destination(north(20000), 90, 20000), Type: task.ZoneTypeLine, Radius: 2000},
+				},
+			},
+			origin: geo.FAISphere.Destination(north(-995), 270, 300),
+			segments: []synthetic.Segment{
+				{Duration: 1900 * time.Second, GroundSpeed: 10},
+				{Duration: 2200 * time.Second, GroundSpeed: 10, Track: 90},
+			},
+			expectedAchievementTimes: []time.Duration{
+				100 * time.Second,
+			},
+			expectedStartTime: 100 * time.Second,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			origin := tc.origin
+			if origin == (geo.Point{}) {
+				origin = launch
+			}
+			result := tc.task.VerifyBRecords(synthetic.BRecords(startTime, origin, tc.segments))
+			var actualAchievementTimes []time.Duration
+			for _, achievement := range result.Achievements {
+				actualAchievementTimes = append(actualAchievementTimes, achievement.Time.Sub(startTime))
+			}
+			assert.Equal(t, tc.expectedAchievementTimes, actualAchievementTimes)
+			if len(tc.expectedAchievementTimes) > 0 {
+				assert.NotZero(t, result.Start)
+				assert.Equal(t, startTime.Add(tc.expectedStartTime), result.StartTime)
+			} else {
+				assert.Zero(t, result.Start)
+			}
+			if tc.expectedESSTime != 0 {
+				assert.NotZero(t, result.ESS)
+				assert.Equal(t, startTime.Add(tc.expectedESSTime), result.ESS.Time)
+				assert.Equal(t, tc.expectedESSTime-tc.expectedStartTime, result.SpeedSectionTime)
+			} else {
+				assert.Zero(t, result.ESS)
+			}
+			assert.Equal(t, tc.expectedGoal, result.Goal != nil)
+			if tc.expectedDistance != 0 {
+				assertClose(t, tc.expectedDistance, result.Distance, 1)
+			}
+		})
+	}
+}
+
+func TestVerifyTaskDistance(t *testing.T) {
+	result := newTask(goalCylinder()).VerifyBRecords(nil)
+	assertClose(t, 39000, result.TaskDistance, 1e-3)
+	assert.Zero(t, result.Achievements)
+	assert.Zero(t, result.Distance)
+}
+
+func TestVerifyRemainingDistance(t *testing.T) {
+	// The pilot reaches the first turnpoint and lands to the east of the route
+	// of the task, so the shortest route from the landing touches the later
+	// cylinders elsewhere than the route of the task.
+	tp2 := geo.FAISphere.Destination(north(30000), 90, 15000)
+	tp3 := geo.FAISphere.Destination(north(45000), 270, 15000)
+	goal := north(60000)
+	tk := &task.Task{
+		Turnpoints: []*task.Turnpoint{
+			{Name: "SSS", Point: launch, Type: task.ZoneTypeCylinder, Radius: 1000},
+			{Name: "TP1", Point: north(20000), Type: task.ZoneTypeCylinder, Radius: 2004},
+			{Name: "TP2", Point: tp2, Type: task.ZoneTypeCylinder, Radius: 5000},
+			{Name: "TP3", Point: tp3, Type: task.ZoneTypeCylinder, Radius: 5000},
+			{Name: "Goal", Point: goal, Type: task.ZoneTypeCylinder, Radius: 1000},
+		},
+		Tolerance: task.CIVLTolerance,
+	}
+	result := tk.VerifyBRecords(synthetic.BRecords(startTime, launch, []synthetic.Segment{
+		{Duration: 2000 * time.Second, GroundSpeed: 10, Track: 0},
+		{Duration: 2000 * time.Second, GroundSpeed: 10, Track: 30},
+	}))
+	assert.Equal(t, 2, len(result.Achievements))
+
+	landing := geo.Point{Lat: result.DistanceBRecord.Lat, Lon: result.DistanceBRecord.Lon}
+	_, remaining := (&task.Task{
+		Turnpoints: []*task.Turnpoint{
+			{Point: landing, Type: task.ZoneTypeCylinder},
+			tk.Turnpoints[2],
+			tk.Turnpoints[3],
+			tk.Turnpoints[4],
+		},
+	}).OptimizedRoute()
+	assertClose(t, result.TaskDistance-remaining, result.Distance, 1e-2)
+}
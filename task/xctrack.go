@@ -0,0 +1,117 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/twpayne/go-igc/geo"
+)
+
+var (
+	errNoTurnpoints       = errors.New("no turnpoints")
+	errUnsupportedVersion = errors.New("unsupported version")
+)
+
+// An xcTrackTask is a task in XCTrack's .xctsk format.
+type xcTrackTask struct {
+	Version    int    `json:"version"`
+	EarthModel string `json:"earthModel"`
+	Turnpoints []struct {
+		Type     string  `json:"type"`
+		Radius   float64 `json:"radius"`
+		Waypoint struct {
+			Name string  `json:"name"`
+			Lat  float64 `json:"lat"`
+			Lon  float64 `json:"lon"`
+		} `json:"waypoint"`
+	} `json:"turnpoints"`
+	SSS *struct {
+		Type      string   `json:"type"`
+		Direction string   `json:"direction"`
+		TimeGates []string `json:"timeGates"`
+	} `json:"sss"`
+	Goal *struct {
+		Type     string `json:"type"`
+		Deadline string `json:"deadline"`
+	} `json:"goal"`
+}
+
+// ParseXCTrack parses a task in XCTrack's .xctsk format, version 1, from r.
+// The times of the task are times of day, so date is the date of the task.
+//
+// The time gates of elapsed time tasks, which are the opening times of their
+// starts, are not imported, and the tolerance is not set.
+func ParseXCTrack(r io.Reader, date time.Time) (*Task, error) {
+	var xcTrackTask xcTrackTask
+	if err := json.NewDecoder(r).Decode(&xcTrackTask); err != nil {
+		return nil, err
+	}
+	if xcTrackTask.Version != 1 {
+		return nil, fmt.Errorf("%d: %w", xcTrackTask.Version, errUnsupportedVersion)
+	}
+	if len(xcTrackTask.Turnpoints) == 0 {
+		return nil, errNoTurnpoints
+	}
+
+	t := &Task{}
+	if xcTrackTask.EarthModel == "WGS84" {
+		t.Earth = geo.WGS84
+	}
+	for i, turnpoint := range xcTrackTask.Turnpoints {
+		switch turnpoint.Type {
+		case "SSS":
+			t.SSS = i
+		case "ESS":
+			t.ESS = i
+		}
+		t.Turnpoints = append(t.Turnpoints, &Turnpoint{
+			Name:   turnpoint.Waypoint.Name,
+			Point:  geo.Point{Lat: turnpoint.Waypoint.Lat, Lon: turnpoint.Waypoint.Lon},
+			Type:   ZoneTypeCylinder,
+			Radius: turnpoint.Radius,
+		})
+	}
+
+	if sss := xcTrackTask.SSS; sss != nil {
+		if sss.Direction == "ENTER" {
+			t.StartDirection = StartDirectionEnter
+		}
+		if sss.Type != "ELAPSED-TIME" {
+			for _, timeGate := range sss.TimeGates {
+				startGate, err := parseXCTrackTime(timeGate, date)
+				if err != nil {
+					return nil, fmt.Errorf("sss: %w", err)
+				}
+				t.StartGates = append(t.StartGates, startGate)
+			}
+		}
+	}
+
+	if goal := xcTrackTask.Goal; goal != nil {
+		if goal.Type == "LINE" {
+			t.Turnpoints[len(t.Turnpoints)-1].Type = ZoneTypeLine
+		}
+		if goal.Deadline != "" {
+			deadline, err := parseXCTrackTime(goal.Deadline, date)
+			if err != nil {
+				return nil, fmt.Errorf("goal: %w", err)
+			}
+			t.Deadline = deadline
+		}
+	}
+
+	return t, nil
+}
+
+// parseXCTrackTime parses the time of day s, for example 12:30:00Z, on date.
+func parseXCTrackTime(s string, date time.Time) (time.Time, error) {
+	timeOfDay, err := time.Parse("15:04:05Z07:00", s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	timeOfDay = timeOfDay.UTC()
+	return time.Date(date.Year(), date.Month(), date.Day(), timeOfDay.Hour(), timeOfDay.Minute(), timeOfDay.Second(), 0, time.UTC), nil
+}
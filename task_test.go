@@ -0,0 +1,153 @@
+package igc_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-igc"
+)
+
+func TestTask(t *testing.T) {
+	igcFile, err := igc.ParseLines([]string{
+		"C011023130954120508000102 Competition task",
+		"C4601772N01149565ETAKEOFF Feltre",
+		"C4550225N01144935ESTART D03",
+		"C4548722N01144696ETURN D06",
+		"C4553315N01153879ETURN B55",
+		"C4548341N01147193EFINISH A02",
+		"C0000000N00000000ELANDING",
+	})
+	assert.NoError(t, err)
+	task := igcFile.Task()
+	assert.NotZero(t, task)
+	assert.NoError(t, task.Check())
+
+	assert.Equal(t, time.Date(2023, time.October, 1, 13, 9, 54, 0, time.UTC), task.DeclarationTime)
+	assert.Equal(t, time.Date(2008, time.May, 12, 0, 0, 0, 0, time.UTC), task.FlightDate)
+	assert.Equal(t, 1, task.TaskNumber)
+	assert.Equal(t, 2, task.NumberOfTurnpoints)
+	assert.Equal(t, "Competition task", task.Text)
+	assert.Equal(t, 6, len(task.Points))
+
+	assert.Equal(t, igc.TaskPointTypeTakeoff, task.Takeoff.Type)
+	assert.Equal(t, "Feltre", task.Takeoff.Name)
+	assert.False(t, task.Takeoff.Placeholder)
+	assert.Equal(t, "D03", task.Start.Name)
+	assert.Equal(t, 2, len(task.Turnpoints))
+	assert.Equal(t, "D06", task.Turnpoints[0].Name)
+	assert.Equal(t, "B55", task.Turnpoints[1].Name)
+	assert.Equal(t, "A02", task.Finish.Name)
+	assert.Equal(t, igc.TaskPointTypeLanding, task.Landing.Type)
+	assert.Equal(t, "", task.Landing.Name)
+	assert.True(t, task.Landing.Placeholder)
+}
+
+func TestTaskPointTypes(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		lines         []string
+		expectedTypes []igc.TaskPointType
+		expectedNames []string
+		expectedErr   error
+	}{
+		{
+			name: "positional",
+			lines: []string{
+				"C210615130110210615000002",
+				"C4728218N01041532EReutte Hoefen",
+				"C4728218N01041532EReutte Hoefen",
+				"C4620100N01054167EMALE",
+				"C4636333N01122633ESARENTINO",
+				"C4627617N01119600EBOLZANO",
+				"C4627617N01119600EBOLZANO",
+			},
+			expectedTypes: []igc.TaskPointType{
+				igc.TaskPointTypeTakeoff,
+				igc.TaskPointTypeStart,
+				igc.TaskPointTypeTurnpoint,
+				igc.TaskPointTypeTurnpoint,
+				igc.TaskPointTypeFinish,
+				igc.TaskPointTypeLanding,
+			},
+			expectedNames: []string{"Reutte Hoefen", "Reutte Hoefen", "MALE", "SARENTINO", "BOLZANO", "BOLZANO"},
+		},
+		{
+			name: "partial_keywords",
+			lines: []string{
+				"C081116224317000000000001",
+				"C0000000N00000000ETAKEOFF",
+				"C4346000S17007700EL235-MT COOK",
+				"C4530700S16919000EL530 ROXBURGH",
+				"C4429030S16958700E001-OMARAMA",
+				"C0000000N00000000ELANDING",
+			},
+			expectedTypes: []igc.TaskPointType{
+				igc.TaskPointTypeTakeoff,
+				igc.TaskPointTypeStart,
+				igc.TaskPointTypeTurnpoint,
+				igc.TaskPointTypeFinish,
+				igc.TaskPointTypeLanding,
+			},
+			expectedNames: []string{"", "L235-MT COOK", "L530 ROXBURGH", "001-OMARAMA", ""},
+		},
+		{
+			name: "areas",
+			lines: []string{
+				"C081023170008081023000101TASK",
+				"C0000000N00000000ETAKEOFF",
+				"C3652920N00524424W00000000000400000000360000STARTAREA Waypoint",
+				"C3624672N00607911W00000000000000000000360000TURNAREA C74000",
+				"C3653619N00522147W00000000001000000000360000FINISHAREA C70039",
+				"C0000000N00000000ELANDING",
+			},
+			expectedTypes: []igc.TaskPointType{
+				igc.TaskPointTypeTakeoff,
+				igc.TaskPointTypeStart,
+				igc.TaskPointTypeTurnpoint,
+				igc.TaskPointTypeFinish,
+				igc.TaskPointTypeLanding,
+			},
+			expectedNames: []string{"", "Waypoint", "C74000", "C70039", ""},
+		},
+		{
+			name: "turnpoint_count_mismatch",
+			lines: []string{
+				"C0910230630240000000000-2 Competition task",
+				"C3203444N07644616ETAKEOFF Bir Billing",
+				"C0000000N00000000ELANDING",
+			},
+			expectedTypes: []igc.TaskPointType{
+				igc.TaskPointTypeTakeoff,
+				igc.TaskPointTypeLanding,
+			},
+			expectedNames: []string{"Bir Billing", ""},
+			expectedErr:   &igc.TurnpointCountError{Declared: -2, Actual: 0},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			igcFile, err := igc.ParseLines(tc.lines)
+			assert.NoError(t, err)
+			task := igcFile.Task()
+			assert.NotZero(t, task)
+			var actualTypes []igc.TaskPointType
+			var actualNames []string
+			for _, point := range task.Points {
+				actualTypes = append(actualTypes, point.Type)
+				actualNames = append(actualNames, point.Name)
+			}
+			assert.Equal(t, tc.expectedTypes, actualTypes)
+			assert.Equal(t, tc.expectedNames, actualNames)
+			assert.Equal(t, tc.expectedErr, task.Check())
+		})
+	}
+}
+
+func TestNoTask(t *testing.T) {
+	igcFile, err := igc.ParseLines([]string{
+		"HFDTE020508",
+	})
+	assert.NoError(t, err)
+	assert.Zero(t, igcFile.Task())
+}
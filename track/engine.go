@@ -0,0 +1,225 @@
+package track
+
+import (
+	"time"
+
+	"github.com/twpayne/go-igc"
+)
+
+// EngineThresholds are the levels of the engine additions of B records at
+// which the engine is considered to be running. The scales of ENL and MOP
+// vary between flight recorders, so thresholds can be set per manufacturer. A
+// zero threshold disables the corresponding check.
+type EngineThresholds struct {
+	ENL          int // ENL is the engine noise level at or above which the engine is running.
+	AmbiguousENL int // AmbiguousENL is the engine noise level at or above which the engine might be running.
+	MOP          int // MOP is the means of propulsion level at or above which the engine is running.
+	AmbiguousMOP int // AmbiguousMOP is the means of propulsion level at or above which the engine might be running.
+	RPM          int // RPM is the engine revolutions per minute at or above which the engine is running.
+}
+
+// DefaultEngineThresholds are the default engine thresholds.
+var DefaultEngineThresholds = EngineThresholds{
+	ENL:          500,
+	AmbiguousENL: 250,
+	MOP:          500,
+	AmbiguousMOP: 250,
+	RPM:          100,
+}
+
+// An EngineRun is a period during which the engine is, or might be, running.
+// High engine levels are levels at or above the ambiguous thresholds.
+type EngineRun struct {
+	Start      *Fix    // Start is the first fix with high engine levels.
+	End        *Fix    // End is the last fix with high engine levels.
+	EngineOff  *Fix    // EngineOff is the first fix after End, or nil if the track ends with the engine running.
+	StartIndex int     // StartIndex is the index of Start in the fixes.
+	EndIndex   int     // EndIndex is the index of End in the fixes.
+	AltGain    float64 // AltGain is the altitude gained from Start to End in meters.
+	MaxENL     int
+	MaxMOP     int
+	MaxRPM     int
+	Ambiguous  bool // Ambiguous is true if the levels do not clearly show that the engine is running, so the run should be reviewed manually.
+}
+
+// An EngineOption sets an option on the detection of engine runs.
+type EngineOption func(*engineDetector)
+
+type engineDetector struct {
+	manufacturerID           string
+	thresholdsByManufacturer map[string]EngineThresholds
+	minRunDuration           time.Duration
+	maxGap                   time.Duration
+}
+
+// WithEngineThresholds sets the engine thresholds of flight recorders made by
+// the manufacturer with manufacturerID, for example LXV. An empty
+// manufacturerID sets the thresholds of all other flight recorders. The
+// default is DefaultEngineThresholds.
+func WithEngineThresholds(manufacturerID string, thresholds EngineThresholds) EngineOption {
+	return func(d *engineDetector) {
+		d.thresholdsByManufacturer[manufacturerID] = thresholds
+	}
+}
+
+// WithManufacturerID sets the manufacturer ID of the flight recorder, which
+// selects its engine thresholds. EngineRuns sets it from the A record.
+func WithManufacturerID(manufacturerID string) EngineOption {
+	return func(d *engineDetector) {
+		d.manufacturerID = manufacturerID
+	}
+}
+
+// WithMaxEngineGap sets the maximum duration of low levels within an engine
+// run. Longer periods end the run. The default is ten seconds.
+func WithMaxEngineGap(maxGap time.Duration) EngineOption {
+	return func(d *engineDetector) {
+		d.maxGap = maxGap
+	}
+}
+
+// WithMinEngineRunDuration sets the minimum duration of high levels in an
+// engine run. Runs with shorter periods of high levels, for example noise on
+// landing, are reported as ambiguous. The default is ten seconds.
+func WithMinEngineRunDuration(minRunDuration time.Duration) EngineOption {
+	return func(d *engineDetector) {
+		d.minRunDuration = minRunDuration
+	}
+}
+
+// EngineRuns returns the engine runs in igcFile, using the engine thresholds
+// of the manufacturer of its flight recorder.
+func EngineRuns(igcFile *igc.IGC, options ...EngineOption) []*EngineRun {
+	for _, record := range igcFile.Records {
+		if aRecord, ok := record.(*igc.ARecord); ok && aRecord != nil {
+			options = append([]EngineOption{WithManufacturerID(aRecord.ManufacturerID)}, options...)
+			break
+		}
+	}
+	return DetectEngineRuns(igcFile.BRecords, options...)
+}
+
+// DetectEngineRuns returns the engine runs in bRecords. The indexes of the
+// returned engine runs are indexes of the non-nil B records in bRecords.
+func DetectEngineRuns(bRecords []*igc.BRecord, options ...EngineOption) []*EngineRun {
+	return DetectEngineRunsInFixes(Kinematics(bRecords), options...)
+}
+
+// DetectEngineRunsInFixes returns the engine runs in fixes.
+func DetectEngineRunsInFixes(fixes []*Fix, options ...EngineOption) []*EngineRun {
+	d := &engineDetector{
+		thresholdsByManufacturer: map[string]EngineThresholds{
+			"": DefaultEngineThresholds,
+		},
+		minRunDuration: 10 * time.Second,
+		maxGap:         10 * time.Second,
+	}
+	for _, option := range options {
+		option(d)
+	}
+	return d.detect(fixes)
+}
+
+// An engineLevel is the level of the engine additions of a fix.
+type engineLevel int
+
+// Engine levels.
+const (
+	engineLevelOff engineLevel = iota
+	engineLevelAmbiguous
+	engineLevelRunning
+)
+
+// detect returns the engine runs in fixes. A run is a sequence of fixes with
+// ambiguous or running levels separated by gaps no longer than the maximum
+// gap. A run is ambiguous unless its running levels last at least the minimum
+// run duration. Runs with only ambiguous levels that are shorter than the
+// minimum run duration are ignored.
+func (d *engineDetector) detect(fixes []*Fix) []*EngineRun {
+	thresholds, ok := d.thresholdsByManufacturer[d.manufacturerID]
+	if !ok {
+		thresholds = d.thresholdsByManufacturer[""]
+	}
+	pressureAlt := hasPressureAlt(fixes)
+	altitude := func(fix *Fix) float64 {
+		if pressureAlt {
+			return fix.PressureAlt
+		}
+		return fix.GNSSAlt
+	}
+
+	var engineRuns []*EngineRun
+	var current *EngineRun
+	var firstRunning, lastRunning *Fix
+	endRun := func() {
+		if current == nil {
+			return
+		}
+		runningDuration := time.Duration(0)
+		if firstRunning != nil {
+			runningDuration = lastRunning.Time.Sub(firstRunning.Time)
+		}
+		current.Ambiguous = firstRunning == nil || runningDuration < d.minRunDuration
+		if firstRunning != nil || current.End.Time.Sub(current.Start.Time) >= d.minRunDuration {
+			current.AltGain = altitude(current.End) - altitude(current.Start)
+			if current.EndIndex < len(fixes)-1 {
+				current.EngineOff = fixes[current.EndIndex+1]
+			}
+			engineRuns = append(engineRuns, current)
+		}
+		current, firstRunning, lastRunning = nil, nil, nil
+	}
+
+	for i, fix := range fixes {
+		if current != nil && fix.Time.Sub(current.End.Time) > d.maxGap {
+			endRun()
+		}
+		level, enl, mop, rpm := thresholds.level(fix.BRecord)
+		if level == engineLevelOff {
+			continue
+		}
+		if current == nil {
+			current = &EngineRun{
+				Start:      fix,
+				StartIndex: i,
+			}
+		}
+		current.End = fix
+		current.EndIndex = i
+		current.MaxENL = max(current.MaxENL, enl)
+		current.MaxMOP = max(current.MaxMOP, mop)
+		current.MaxRPM = max(current.MaxRPM, rpm)
+		if level == engineLevelRunning {
+			if firstRunning == nil {
+				firstRunning = fix
+			}
+			lastRunning = fix
+		}
+	}
+	endRun()
+	return engineRuns
+}
+
+// Duration returns the duration of r.
+func (r *EngineRun) Duration() time.Duration {
+	return r.End.Time.Sub(r.Start.Time)
+}
+
+// level returns the engine level of bRecord and its ENL, MOP, and RPM
+// additions, which are zero if absent.
+func (t EngineThresholds) level(bRecord *igc.BRecord) (engineLevel, int, int, int) {
+	if bRecord == nil {
+		return engineLevelOff, 0, 0, 0
+	}
+	enl, _ := bRecord.EngineNoiseLevel()
+	mop, _ := bRecord.MeansOfPropulsion()
+	rpm, _ := bRecord.EngineRPM()
+	switch {
+	case t.ENL != 0 && enl >= t.ENL, t.MOP != 0 && mop >= t.MOP, t.RPM != 0 && rpm >= t.RPM:
+		return engineLevelRunning, enl, mop, rpm
+	case t.AmbiguousENL != 0 && enl >= t.AmbiguousENL, t.AmbiguousMOP != 0 && mop >= t.AmbiguousMOP:
+		return engineLevelAmbiguous, enl, mop, rpm
+	default:
+		return engineLevelOff, enl, mop, rpm
+	}
+}
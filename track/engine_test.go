@@ -0,0 +1,235 @@
+package track_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-igc"
+	"github.com/twpayne/go-igc/internal/synthetic"
+	"github.com/twpayne/go-igc/track"
+)
+
+// An engineSegment is a segment of a synthetic track with constant engine
+// levels.
+type engineSegment struct {
+	duration      time.Duration
+	verticalSpeed float64
+	enl           int
+	mop           int
+	rpm           int
+}
+
+func makeEngineBRecords(engineSegments []engineSegment) []*igc.BRecord {
+	layout := []igc.RecordAddition{
+		{StartColumn: 36, FinishColumn: 38, TLC: "ENL"},
+		{StartColumn: 39, FinishColumn: 41, TLC: "MOP"},
+		{StartColumn: 42, FinishColumn: 46, TLC: "RPM"},
+	}
+	segments := make([]synthetic.Segment, 0, len(engineSegments))
+	for _, engineSegment := range engineSegments {
+		segments = append(segments, synthetic.Segment{
+			Duration:      engineSegment.duration,
+			GroundSpeed:   25,
+			VerticalSpeed: engineSegment.verticalSpeed,
+		})
+	}
+	bRecords := makeBRecords(segments)
+	i := 0
+	for _, engineSegment := range engineSegments {
+		for range int(engineSegment.duration / time.Second) {
+			bRecords[i].Additions = igc.NewAdditions(layout, map[string]int{
+				"ENL": engineSegment.enl,
+				"MOP": engineSegment.mop,
+				"RPM": engineSegment.rpm,
+			})
+			i++
+		}
+	}
+	return bRecords
+}
+
+func TestDetectEngineRuns(t *testing.T) {
+	type expectedRun struct {
+		start     time.Duration
+		end       time.Duration
+		altGain   float64
+		ambiguous bool
+	}
+	for _, tc := range []struct {
+		name           string
+		engineSegments []engineSegment
+		options        []track.EngineOption
+		expected       []expectedRun
+	}{
+		{
+			name: "self_launch",
+			engineSegments: []engineSegment{
+				{duration: 2 * time.Minute, enl: 20},
+				{duration: 3 * time.Minute, verticalSpeed: 2, enl: 800},
+				{duration: 20 * time.Minute, verticalSpeed: -1, enl: 20},
+			},
+			expected: []expectedRun{
+				{start: 2 * time.Minute, end: 5*time.Minute - time.Second, altGain: 358},
+			},
+		},
+		{
+			name: "noise_on_landing",
+			engineSegments: []engineSegment{
+				{duration: 20 * time.Minute, verticalSpeed: -1, enl: 20},
+				{duration: 2 * time.Second, enl: 700},
+				{duration: time.Minute, enl: 20},
+			},
+			expected: []expectedRun{
+				{start: 20 * time.Minute, end: 20*time.Minute + time.Second, ambiguous: true},
+			},
+		},
+		{
+			name: "quiet_engine",
+			engineSegments: []engineSegment{
+				{duration: 10 * time.Minute, enl: 20},
+				{duration: 5 * time.Minute, verticalSpeed: 1, enl: 300},
+				{duration: 10 * time.Minute, enl: 20},
+			},
+			expected: []expectedRun{
+				{start: 10 * time.Minute, end: 15*time.Minute - time.Second, altGain: 299, ambiguous: true},
+			},
+		},
+		{
+			name: "short_ambiguous",
+			engineSegments: []engineSegment{
+				{duration: 10 * time.Minute, enl: 20},
+				{duration: 3 * time.Second, enl: 300},
+				{duration: 10 * time.Minute, enl: 20},
+			},
+		},
+		{
+			name: "short_gap",
+			engineSegments: []engineSegment{
+				{duration: 10 * time.Minute, enl: 20},
+				{duration: time.Minute, enl: 800},
+				{duration: 5 * time.Second, enl: 20},
+				{duration: time.Minute, enl: 800},
+				{duration: 10 * time.Minute, enl: 20},
+			},
+			expected: []expectedRun{
+				{start: 10 * time.Minute, end: 12*time.Minute + 4*time.Second},
+			},
+		},
+		{
+			name: "long_gap",
+			engineSegments: []engineSegment{
+				{duration: 10 * time.Minute, enl: 20},
+				{duration: time.Minute, enl: 800},
+				{duration: 5 * time.Minute, enl: 20},
+				{duration: time.Minute, enl: 800},
+				{duration: 10 * time.Minute, enl: 20},
+			},
+			expected: []expectedRun{
+				{start: 10 * time.Minute, end: 11*time.Minute - time.Second},
+				{start: 16 * time.Minute, end: 17*time.Minute - time.Second},
+			},
+		},
+		{
+			name: "mop",
+			engineSegments: []engineSegment{
+				{duration: 10 * time.Minute, enl: 20, mop: 10},
+				{duration: time.Minute, enl: 20, mop: 900},
+				{duration: 10 * time.Minute, enl: 20, mop: 10},
+			},
+			expected: []expectedRun{
+				{start: 10 * time.Minute, end: 11*time.Minute - time.Second},
+			},
+		},
+		{
+			name: "rpm",
+			engineSegments: []engineSegment{
+				{duration: 10 * time.Minute},
+				{duration: time.Minute, rpm: 6000},
+				{duration: 10 * time.Minute},
+			},
+			expected: []expectedRun{
+				{start: 10 * time.Minute, end: 11*time.Minute - time.Second},
+			},
+		},
+		{
+			name: "manufacturer_thresholds",
+			engineSegments: []engineSegment{
+				{duration: 10 * time.Minute, enl: 20},
+				{duration: time.Minute, enl: 300},
+				{duration: 10 * time.Minute, enl: 20},
+			},
+			options: []track.EngineOption{
+				track.WithEngineThresholds("XYZ", track.EngineThresholds{ENL: 200}),
+				track.WithManufacturerID("XYZ"),
+			},
+			expected: []expectedRun{
+				{start: 10 * time.Minute, end: 11*time.Minute - time.Second},
+			},
+		},
+		{
+			name: "other_manufacturer_thresholds",
+			engineSegments: []engineSegment{
+				{duration: 10 * time.Minute, enl: 20},
+				{duration: time.Minute, enl: 300},
+				{duration: 10 * time.Minute, enl: 20},
+			},
+			options: []track.EngineOption{
+				track.WithEngineThresholds("XYZ", track.EngineThresholds{ENL: 200}),
+				track.WithManufacturerID("ABC"),
+			},
+			expected: []expectedRun{
+				{start: 10 * time.Minute, end: 11*time.Minute - time.Second, ambiguous: true},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			bRecords := makeEngineBRecords(tc.engineSegments)
+			engineRuns := track.DetectEngineRuns(bRecords, tc.options...)
+			assert.Equal(t, len(tc.expected), len(engineRuns))
+			for i, engineRun := range engineRuns {
+				expected := tc.expected[i]
+				assert.Equal(t, startTime.Add(expected.start), engineRun.Start.Time)
+				assert.Equal(t, startTime.Add(expected.end), engineRun.End.Time)
+				assert.Equal(t, bRecords[engineRun.StartIndex], engineRun.Start.BRecord)
+				assert.Equal(t, bRecords[engineRun.EndIndex], engineRun.End.BRecord)
+				assert.Equal(t, bRecords[engineRun.EndIndex+1], engineRun.EngineOff.BRecord)
+				assertClose(t, expected.altGain, engineRun.AltGain, 1e-9)
+				assert.Equal(t, expected.ambiguous, engineRun.Ambiguous)
+			}
+		})
+	}
+}
+
+func TestDetectEngineRunsMaxLevels(t *testing.T) {
+	bRecords := makeEngineBRecords([]engineSegment{
+		{duration: time.Minute, enl: 20},
+		{duration: time.Minute, enl: 800, mop: 600, rpm: 5000},
+		{duration: time.Minute, enl: 900, mop: 700, rpm: 6000},
+	})
+	engineRuns := track.DetectEngineRuns(bRecords)
+	assert.Equal(t, 1, len(engineRuns))
+	assert.Equal(t, 900, engineRuns[0].MaxENL)
+	assert.Equal(t, 700, engineRuns[0].MaxMOP)
+	assert.Equal(t, 6000, engineRuns[0].MaxRPM)
+	assert.Equal(t, 2*time.Minute-time.Second, engineRuns[0].Duration())
+	assert.Zero(t, engineRuns[0].EngineOff)
+}
+
+func TestEngineRunsTestdata(t *testing.T) {
+	file, err := os.Open("../testdata/45bvafx1.igc")
+	assert.NoError(t, err)
+	defer file.Close()
+	igcFile, err := igc.Parse(file)
+	assert.NoError(t, err)
+
+	// The engine is not run, but there is noise when launching and landing.
+	engineRuns := track.EngineRuns(igcFile)
+	assert.Equal(t, 2, len(engineRuns))
+	for _, engineRun := range engineRuns {
+		assert.True(t, engineRun.Ambiguous)
+		assert.True(t, engineRun.Duration() < time.Minute)
+	}
+}
@@ -0,0 +1,208 @@
+package track
+
+import (
+	"math"
+	"time"
+
+	"github.com/twpayne/go-igc"
+	"github.com/twpayne/go-igc/geo"
+)
+
+// A Flight is an airborne segment of a track.
+type Flight struct {
+	Takeoff      *Fix // Takeoff is the first airborne fix.
+	Landing      *Fix // Landing is the last airborne fix.
+	TakeoffIndex int  // TakeoffIndex is the index of Takeoff in the fixes.
+	LandingIndex int  // LandingIndex is the index of Landing in the fixes.
+	Landed       bool // Landed is false if the track ends while airborne.
+}
+
+// A FlightOption sets an option on the detection of flights.
+type FlightOption func(*flightDetector)
+
+type flightDetector struct {
+	minGroundSpeed    float64
+	minVerticalSpeed  float64
+	minGroundDuration time.Duration
+	minFlightDuration time.Duration
+	maxGroundAltRange float64
+	window            time.Duration
+}
+
+// WithMaxGroundAltRange sets the maximum range of altitudes in meters of fixes
+// on the ground. A period that does not meet the airborne criteria but whose
+// altitude varies by more, for example when circling slowly, does not end a
+// flight. The default is 15 m.
+func WithMaxGroundAltRange(maxGroundAltRange float64) FlightOption {
+	return func(d *flightDetector) {
+		d.maxGroundAltRange = maxGroundAltRange
+	}
+}
+
+// WithMinFlightDuration sets the minimum duration of a flight. Shorter
+// airborne segments are ignored. The default is one minute.
+func WithMinFlightDuration(minFlightDuration time.Duration) FlightOption {
+	return func(d *flightDetector) {
+		d.minFlightDuration = minFlightDuration
+	}
+}
+
+// WithMinGroundDuration sets the minimum duration on the ground that ends a
+// flight. Shorter periods that do not meet the airborne criteria, for example
+// when soaring into a strong wind, do not end a flight. The default is two
+// minutes.
+func WithMinGroundDuration(minGroundDuration time.Duration) FlightOption {
+	return func(d *flightDetector) {
+		d.minGroundDuration = minGroundDuration
+	}
+}
+
+// WithMinGroundSpeed sets the minimum ground speed in m/s above which a fix is
+// considered airborne. The default is 4 m/s.
+func WithMinGroundSpeed(minGroundSpeed float64) FlightOption {
+	return func(d *flightDetector) {
+		d.minGroundSpeed = minGroundSpeed
+	}
+}
+
+// WithMinVerticalSpeed sets the minimum absolute vertical speed in m/s above
+// which a fix is considered airborne. The default is 1 m/s.
+func WithMinVerticalSpeed(minVerticalSpeed float64) FlightOption {
+	return func(d *flightDetector) {
+		d.minVerticalSpeed = minVerticalSpeed
+	}
+}
+
+// WithSmoothingWindow sets the smoothing window for ground speed and vertical
+// speed used to detect flights. The default is ten seconds.
+func WithSmoothingWindow(window time.Duration) FlightOption {
+	return func(d *flightDetector) {
+		d.window = window
+	}
+}
+
+// DetectFlights returns the flights in bRecords. The indexes of the returned
+// flights are indexes of the non-nil B records in bRecords.
+func DetectFlights(bRecords []*igc.BRecord, options ...FlightOption) []*Flight {
+	d := newFlightDetector(options)
+	fixes := Kinematics(bRecords, WithSpeedWindow(d.window), WithVerticalSpeedWindow(d.window), WithUseAdditions(false))
+	return d.detect(fixes)
+}
+
+// DetectFlightsInFixes returns the flights in fixes, using their existing
+// kinematics.
+func DetectFlightsInFixes(fixes []*Fix, options ...FlightOption) []*Flight {
+	return newFlightDetector(options).detect(fixes)
+}
+
+func newFlightDetector(options []FlightOption) *flightDetector {
+	d := &flightDetector{
+		minGroundSpeed:    4,
+		minVerticalSpeed:  1,
+		minGroundDuration: 2 * time.Minute,
+		minFlightDuration: time.Minute,
+		maxGroundAltRange: 15,
+		window:            10 * time.Second,
+	}
+	for _, option := range options {
+		option(d)
+	}
+	return d
+}
+
+// detect returns the flights in fixes. A fix is airborne if it is valid and
+// its ground speed, the speed along its path, or its absolute vertical speed
+// exceeds the minimum. A flight ends when fixes are not airborne for at least
+// the minimum ground duration and their altitude remains within the maximum
+// ground altitude range.
+func (d *flightDetector) detect(fixes []*Fix) []*Flight {
+	pressureAlt := hasPressureAlt(fixes)
+
+	times := make([]time.Time, len(fixes))
+	for i, fix := range fixes {
+		times[i] = fix.Time
+	}
+	cumulativeDistances := cumulativeDistances(fixes, geo.FAISphere)
+
+	var flights []*Flight
+	var current *Flight
+	groundStarted := false
+	var groundMinAlt, groundMaxAlt float64
+	for i, fix := range fixes {
+		alt, verticalSpeed := fix.GNSSAlt, fix.GNSSVerticalSpeed
+		if pressureAlt {
+			alt, verticalSpeed = fix.PressureAlt, fix.PressureVerticalSpeed
+		}
+		// The path speed, unlike the ground speed, is not reduced by
+		// circling.
+		var pathSpeed float64
+		start, end := span(times, i, d.window)
+		if dt := times[end].Sub(times[start]).Seconds(); dt > 0 {
+			pathSpeed = (cumulativeDistances[end] - cumulativeDistances[start]) / dt
+		}
+		airborne := fix.Valid &&
+			(max(fix.GroundSpeed, pathSpeed) >= d.minGroundSpeed || math.Abs(verticalSpeed) >= d.minVerticalSpeed)
+		if !airborne && current != nil {
+			if !groundStarted {
+				groundStarted = true
+				groundMinAlt, groundMaxAlt = alt, alt
+			}
+			groundMinAlt = min(groundMinAlt, alt)
+			groundMaxAlt = max(groundMaxAlt, alt)
+			if groundMaxAlt-groundMinAlt > d.maxGroundAltRange {
+				airborne = true
+			}
+		}
+		if airborne {
+			groundStarted = false
+		}
+		switch {
+		case !airborne:
+			if current != nil && fix.Time.Sub(current.Landing.Time) >= d.minGroundDuration {
+				current.Landed = true
+				flights = d.appendFlight(flights, current)
+				current = nil
+			}
+		case current == nil:
+			current = &Flight{
+				Takeoff:      fix,
+				Landing:      fix,
+				TakeoffIndex: i,
+				LandingIndex: i,
+			}
+		default:
+			current.Landing = fix
+			current.LandingIndex = i
+		}
+	}
+	if current != nil {
+		// The flight is landed if the track continues on the ground after the
+		// last airborne fix.
+		current.Landed = current.LandingIndex < len(fixes)-1
+		flights = d.appendFlight(flights, current)
+	}
+	return flights
+}
+
+// appendFlight appends flight to flights if it is long enough.
+func (d *flightDetector) appendFlight(flights []*Flight, flight *Flight) []*Flight {
+	if flight.Duration() < d.minFlightDuration {
+		return flights
+	}
+	return append(flights, flight)
+}
+
+// Duration returns the duration of f.
+func (f *Flight) Duration() time.Duration {
+	return f.Landing.Time.Sub(f.Takeoff.Time)
+}
+
+// hasPressureAlt returns whether any of fixes has a pressure altitude.
+func hasPressureAlt(fixes []*Fix) bool {
+	for _, fix := range fixes {
+		if fix.PressureAlt != 0 {
+			return true
+		}
+	}
+	return false
+}
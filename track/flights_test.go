@@ -0,0 +1,83 @@
+package track_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-igc"
+	"github.com/twpayne/go-igc/geo"
+	"github.com/twpayne/go-igc/internal/synthetic"
+	"github.com/twpayne/go-igc/track"
+)
+
+// makeBRecords returns a synthetic track flown from 46°N 7°E with the tracks
+// of segments measured from east.
+func makeBRecords(segments []synthetic.Segment) []*igc.BRecord {
+	segments = slices.Clone(segments)
+	for i := range segments {
+		segments[i].Track += 90
+	}
+	return synthetic.BRecords(startTime, geo.Point{Lat: 46, Lon: 7}, segments)
+}
+
+func TestDetectFlights(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		segments []synthetic.Segment
+		expected [][2]time.Duration
+		landed   []bool
+	}{
+		{
+			name: "single",
+			segments: []synthetic.Segment{
+				{Duration: 10 * time.Minute},
+				{Duration: 30 * time.Minute, GroundSpeed: 10, VerticalSpeed: -1},
+				{Duration: 10 * time.Minute},
+			},
+			expected: [][2]time.Duration{{10 * time.Minute, 40 * time.Minute}},
+			landed:   []bool{true},
+		},
+		{
+			name: "soaring_without_landing",
+			segments: []synthetic.Segment{
+				{Duration: 10 * time.Minute},
+				{Duration: 10 * time.Minute, GroundSpeed: 10},
+				{Duration: time.Minute},
+				{Duration: 10 * time.Minute, GroundSpeed: 10},
+			},
+			expected: [][2]time.Duration{{10 * time.Minute, 31 * time.Minute}},
+			landed:   []bool{false},
+		},
+		{
+			name: "multiple",
+			segments: []synthetic.Segment{
+				{Duration: 10 * time.Minute},
+				{Duration: 10 * time.Minute, GroundSpeed: 10},
+				{Duration: 10 * time.Minute},
+				{Duration: 20 * time.Minute, GroundSpeed: 10},
+				{Duration: 10 * time.Minute},
+				{Duration: 30 * time.Second, GroundSpeed: 10},
+				{Duration: 10 * time.Minute},
+			},
+			expected: [][2]time.Duration{
+				{10 * time.Minute, 20 * time.Minute},
+				{30 * time.Minute, 50 * time.Minute},
+			},
+			landed: []bool{true, true},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			flights := track.DetectFlights(makeBRecords(tc.segments))
+			assert.Equal(t, len(tc.expected), len(flights))
+			for i, flight := range flights {
+				// Allow for the smoothing window.
+				assertClose(t, tc.expected[i][0].Seconds(), flight.Takeoff.Time.Sub(startTime).Seconds(), 10)
+				assertClose(t, tc.expected[i][1].Seconds(), flight.Landing.Time.Sub(startTime).Seconds(), 10)
+				assert.Equal(t, tc.landed[i], flight.Landed)
+			}
+		})
+	}
+}
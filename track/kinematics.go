@@ -0,0 +1,180 @@
+// Package track analyzes the fixes in IGC files.
+package track
+
+import (
+	"time"
+
+	"github.com/twpayne/go-igc"
+	"github.com/twpayne/go-igc/geo"
+)
+
+// A Fix is a B record with derived kinematics.
+//
+// Derived values are calculated over a window of neighboring fixes centered on
+// the fix. Where the window contains no fixes with a different time, for
+// example with a zero window or duplicate timestamps, the nearest fixes with
+// different times are used.
+type Fix struct {
+	Time                  time.Time
+	Lat                   float64
+	Lon                   float64
+	PressureAlt           float64
+	GNSSAlt               float64
+	Valid                 bool    // Valid is true if the B record has a 3D fix.
+	GroundSpeed           float64 // GroundSpeed is the ground speed in m/s.
+	Track                 float64 // Track is the true track in degrees.
+	GNSSVerticalSpeed     float64 // GNSSVerticalSpeed is the vertical speed from the GNSS altitude in m/s.
+	PressureVerticalSpeed float64 // PressureVerticalSpeed is the vertical speed from the pressure altitude in m/s.
+	TurnRate              float64 // TurnRate is the rate of change of Track in degrees per second, positive clockwise.
+	Acceleration          float64 // Acceleration is the rate of change of GroundSpeed in m/s².
+	BRecord               *igc.BRecord
+}
+
+// A KinematicsOption sets an option on the calculation of kinematics.
+type KinematicsOption func(*kinematics)
+
+type kinematics struct {
+	earth               geo.Earth
+	speedWindow         time.Duration
+	verticalSpeedWindow time.Duration
+	turnRateWindow      time.Duration
+	useAdditions        bool
+}
+
+// WithEarth sets the model of the Earth used to calculate distances and
+// bearings. The default is geo.FAISphere.
+func WithEarth(earth geo.Earth) KinematicsOption {
+	return func(k *kinematics) {
+		k.earth = earth
+	}
+}
+
+// WithSpeedWindow sets the smoothing window for ground speed and track. The
+// default is zero.
+func WithSpeedWindow(speedWindow time.Duration) KinematicsOption {
+	return func(k *kinematics) {
+		k.speedWindow = speedWindow
+	}
+}
+
+// WithTurnRateWindow sets the smoothing window for turn rate and
+// acceleration. The default is zero.
+func WithTurnRateWindow(turnRateWindow time.Duration) KinematicsOption {
+	return func(k *kinematics) {
+		k.turnRateWindow = turnRateWindow
+	}
+}
+
+// WithUseAdditions sets whether the GSP and TRT additions are used for ground
+// speed and track when present. The default is true.
+func WithUseAdditions(useAdditions bool) KinematicsOption {
+	return func(k *kinematics) {
+		k.useAdditions = useAdditions
+	}
+}
+
+// WithVerticalSpeedWindow sets the smoothing window for vertical speeds. The
+// default is zero.
+func WithVerticalSpeedWindow(verticalSpeedWindow time.Duration) KinematicsOption {
+	return func(k *kinematics) {
+		k.verticalSpeedWindow = verticalSpeedWindow
+	}
+}
+
+// Kinematics returns the fixes in bRecords with derived kinematics.
+func Kinematics(bRecords []*igc.BRecord, options ...KinematicsOption) []*Fix {
+	k := &kinematics{
+		earth:        geo.FAISphere,
+		useAdditions: true,
+	}
+	for _, option := range options {
+		option(k)
+	}
+
+	fixes := make([]*Fix, 0, len(bRecords))
+	times := make([]time.Time, 0, len(bRecords))
+	for _, bRecord := range bRecords {
+		if bRecord == nil {
+			continue
+		}
+		fixes = append(fixes, &Fix{
+			Time:        bRecord.Time,
+			Lat:         bRecord.Lat,
+			Lon:         bRecord.Lon,
+			PressureAlt: bRecord.AltBarometric,
+			GNSSAlt:     bRecord.AltWGS84,
+			Valid:       bRecord.Validity == igc.Validity3D,
+			BRecord:     bRecord,
+		})
+		times = append(times, bRecord.Time)
+	}
+
+	for i, fix := range fixes {
+		start, end := span(times, i, k.speedWindow)
+		if dt := times[end].Sub(times[start]).Seconds(); dt > 0 {
+			p1 := geo.Point{Lat: fixes[start].Lat, Lon: fixes[start].Lon}
+			p2 := geo.Point{Lat: fixes[end].Lat, Lon: fixes[end].Lon}
+			fix.GroundSpeed = k.earth.Distance(p1, p2) / dt
+			if p1 != p2 {
+				fix.Track = k.earth.InitialBearing(p1, p2)
+			} else if i > 0 {
+				fix.Track = fixes[i-1].Track
+			}
+		}
+		if k.useAdditions {
+			if groundSpeed, ok := fix.BRecord.GroundSpeed(); ok {
+				fix.GroundSpeed = groundSpeed / 3.6
+			}
+			if track, ok := fix.BRecord.TrackTrue(); ok {
+				fix.Track = track
+			}
+		}
+
+		start, end = span(times, i, k.verticalSpeedWindow)
+		if dt := times[end].Sub(times[start]).Seconds(); dt > 0 {
+			fix.GNSSVerticalSpeed = (fixes[end].GNSSAlt - fixes[start].GNSSAlt) / dt
+			fix.PressureVerticalSpeed = (fixes[end].PressureAlt - fixes[start].PressureAlt) / dt
+		}
+	}
+
+	// Accumulate the changes in track between consecutive fixes so that turn
+	// rates are not aliased when the track changes by more than 180° within
+	// the window.
+	unwrappedTracks := make([]float64, len(fixes))
+	for i := 1; i < len(fixes); i++ {
+		unwrappedTracks[i] = unwrappedTracks[i-1] + geo.BearingDifference(fixes[i-1].Track, fixes[i].Track)
+	}
+
+	for i, fix := range fixes {
+		start, end := span(times, i, k.turnRateWindow)
+		if dt := times[end].Sub(times[start]).Seconds(); dt > 0 {
+			fix.TurnRate = (unwrappedTracks[end] - unwrappedTracks[start]) / dt
+			fix.Acceleration = (fixes[end].GroundSpeed - fixes[start].GroundSpeed) / dt
+		}
+	}
+
+	return fixes
+}
+
+// span returns the indexes of the first and last times within window centered
+// on times[i]. If all times in the window are equal then the span is extended
+// to the nearest different times.
+func span(times []time.Time, i int, window time.Duration) (int, int) {
+	halfWindow := window / 2
+	start, end := i, i
+	for start > 0 && times[i].Sub(times[start-1]) <= halfWindow {
+		start--
+	}
+	for end < len(times)-1 && times[end+1].Sub(times[i]) <= halfWindow {
+		end++
+	}
+	if !times[end].After(times[start]) {
+		for start > 0 && !times[start].Before(times[i]) {
+			start--
+		}
+		for end < len(times)-1 && !times[end].After(times[i]) {
+			end++
+		}
+	}
+	return start, end
+}
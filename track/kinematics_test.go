@@ -0,0 +1,90 @@
+package track_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-igc"
+	"github.com/twpayne/go-igc/geo"
+	"github.com/twpayne/go-igc/track"
+)
+
+var startTime = time.Date(2008, time.May, 2, 10, 0, 0, 0, time.UTC)
+
+func TestKinematicsStraight(t *testing.T) {
+	// Fly north at 10 m/s, climbing at 2 m/s, with a duplicate fix.
+	start := geo.Point{Lat: 46, Lon: 7}
+	var bRecords []*igc.BRecord
+	for i := range 10 {
+		p := geo.FAISphere.Destination(start, 0, 10*float64(i))
+		bRecords = append(bRecords, &igc.BRecord{
+			Time:          startTime.Add(time.Duration(i) * time.Second),
+			Lat:           p.Lat,
+			Lon:           p.Lon,
+			Validity:      igc.Validity3D,
+			AltWGS84:      1000 + 2*float64(i),
+			AltBarometric: 900 + 2*float64(i),
+		})
+	}
+	bRecords = append(bRecords[:5], append([]*igc.BRecord{bRecords[4]}, bRecords[5:]...)...)
+
+	fixes := track.Kinematics(bRecords)
+	assert.Equal(t, 11, len(fixes))
+	for _, fix := range fixes {
+		assertClose(t, 10, fix.GroundSpeed, 1e-6)
+		assertClose(t, 0, fix.Track, 1e-6)
+		assertClose(t, 2, fix.GNSSVerticalSpeed, 1e-6)
+		assertClose(t, 2, fix.PressureVerticalSpeed, 1e-6)
+		assertClose(t, 0, fix.TurnRate, 1e-6)
+		assertClose(t, 0, fix.Acceleration, 1e-6)
+		assert.True(t, fix.Valid)
+	}
+}
+
+func TestKinematicsCircle(t *testing.T) {
+	// Circle clockwise with a period of 20 s and a radius of 50 m, recorded at
+	// 4 Hz.
+	center := geo.Point{Lat: 46, Lon: 7}
+	var bRecords []*igc.BRecord
+	for i := range 400 {
+		p := geo.FAISphere.Destination(center, 360*float64(i)/80, 50)
+		bRecords = append(bRecords, &igc.BRecord{
+			Time: startTime.Add(time.Duration(i) * 250 * time.Millisecond),
+			Lat:  p.Lat,
+			Lon:  p.Lon,
+		})
+	}
+
+	fixes := track.Kinematics(bRecords, track.WithSpeedWindow(time.Second), track.WithTurnRateWindow(2*time.Second))
+	expectedGroundSpeed := 2 * math.Pi * 50 / 20
+	for _, fix := range fixes[10 : len(fixes)-10] {
+		assertClose(t, expectedGroundSpeed, fix.GroundSpeed, 0.1)
+		assertClose(t, 18, fix.TurnRate, 0.1)
+	}
+}
+
+func TestKinematicsAdditions(t *testing.T) {
+	igcFile, err := igc.ParseLines([]string{
+		"HFDTE020508",
+		"I023638GSP3941TRT",
+		"B1000004600000N00700000EA0000001000036090",
+		"B1000014600000N00700000EA0000001000036090",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(igcFile.Errs))
+
+	fixes := track.Kinematics(igcFile.BRecords)
+	assertClose(t, 10, fixes[0].GroundSpeed, 1e-9)
+	assertClose(t, 90, fixes[0].Track, 1e-9)
+
+	fixes = track.Kinematics(igcFile.BRecords, track.WithUseAdditions(false))
+	assertClose(t, 0, fixes[0].GroundSpeed, 1e-9)
+}
+
+func assertClose(t *testing.T, expected, actual, tolerance float64) {
+	t.Helper()
+	assert.True(t, math.Abs(expected-actual) <= tolerance, "expected %f, got %f", expected, actual)
+}
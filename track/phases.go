@@ -0,0 +1,346 @@
+package track
+
+import (
+	"time"
+
+	"github.com/twpayne/go-igc"
+	"github.com/twpayne/go-igc/geo"
+)
+
+// A PhaseType is the type of a flight phase.
+type PhaseType string
+
+// Phase types.
+const (
+	PhaseGround        PhaseType = "ground"
+	PhaseCirclingLeft  PhaseType = "circling-left"  // CirclingLeft is circling counterclockwise, for example when thermalling.
+	PhaseCirclingRight PhaseType = "circling-right" // CirclingRight is circling clockwise, for example when thermalling.
+	PhaseGlide         PhaseType = "glide"
+	PhaseStraightClimb PhaseType = "straight-climb" // StraightClimb is climbing without circling, for example when ridge or dynamic soaring.
+)
+
+// A Phase is a time-bounded phase of a track. Consecutive phases share their
+// boundary fix, so the durations and distances of all phases sum to those of
+// the track.
+type Phase struct {
+	Type       PhaseType
+	Start      *Fix
+	End        *Fix
+	StartIndex int // StartIndex is the index of Start in the fixes.
+	EndIndex   int // EndIndex is the index of End in the fixes.
+	PhaseStats
+}
+
+// PhaseStats are statistics of one or more phases.
+type PhaseStats struct {
+	Count      int           // Count is the number of phases.
+	Duration   time.Duration // Duration is the total duration.
+	Distance   float64       // Distance is the distance along the path in meters.
+	AltGain    float64       // AltGain is the net change in altitude in meters.
+	AvgClimb   float64       // AvgClimb is the average vertical speed in m/s.
+	AvgSpeed   float64       // AvgSpeed is the average speed along the path in m/s.
+	GlideRatio float64       // GlideRatio is Distance divided by the altitude lost, or zero if no altitude is lost.
+	TimeShare  float64       // TimeShare is the fraction of the duration of the track.
+}
+
+// A Segmentation is a track split into phases.
+type Segmentation struct {
+	Fixes  []*Fix
+	Phases []*Phase
+	Totals map[PhaseType]*PhaseStats // Totals are the statistics of all phases of each type.
+	Total  PhaseStats                // Total are the statistics of the whole track.
+
+	pressureAlt bool
+}
+
+// A SegmentOption sets an option on the segmentation of a track into phases.
+type SegmentOption func(*segmenter)
+
+type segmenter struct {
+	minTurnRate      float64
+	minClimb         float64
+	minPhaseDuration time.Duration
+	window           time.Duration
+	flightOptions    []FlightOption
+}
+
+// WithFlightOptions sets the options used to detect flights. Fixes outside
+// flights are in ground phases.
+func WithFlightOptions(flightOptions ...FlightOption) SegmentOption {
+	return func(s *segmenter) {
+		s.flightOptions = flightOptions
+	}
+}
+
+// WithMinCirclingTurnRate sets the minimum absolute turn rate in degrees per
+// second of circling. The default is 6°/s.
+func WithMinCirclingTurnRate(minTurnRate float64) SegmentOption {
+	return func(s *segmenter) {
+		s.minTurnRate = minTurnRate
+	}
+}
+
+// WithMinPhaseDuration sets the minimum duration of airborne phases. Shorter
+// phases are merged into their neighbors. The default is 20 seconds.
+func WithMinPhaseDuration(minPhaseDuration time.Duration) SegmentOption {
+	return func(s *segmenter) {
+		s.minPhaseDuration = minPhaseDuration
+	}
+}
+
+// WithMinStraightClimb sets the minimum vertical speed in m/s of straight
+// climbs. Straight flight with a lower vertical speed is gliding. The default
+// is zero.
+func WithMinStraightClimb(minClimb float64) SegmentOption {
+	return func(s *segmenter) {
+		s.minClimb = minClimb
+	}
+}
+
+// WithPhaseSmoothingWindow sets the smoothing window for the ground speed,
+// vertical speed, and turn rate used to segment phases. The default is ten
+// seconds.
+func WithPhaseSmoothingWindow(window time.Duration) SegmentOption {
+	return func(s *segmenter) {
+		s.window = window
+	}
+}
+
+// SegmentPhases splits the fixes in bRecords into phases.
+func SegmentPhases(bRecords []*igc.BRecord, options ...SegmentOption) *Segmentation {
+	s := newSegmenter(options)
+	fixes := Kinematics(bRecords,
+		WithSpeedWindow(s.window),
+		WithTurnRateWindow(s.window),
+		WithVerticalSpeedWindow(s.window),
+		WithUseAdditions(false),
+	)
+	return s.segment(fixes)
+}
+
+// SegmentPhasesInFixes splits fixes into phases, using their existing
+// kinematics.
+func SegmentPhasesInFixes(fixes []*Fix, options ...SegmentOption) *Segmentation {
+	return newSegmenter(options).segment(fixes)
+}
+
+func newSegmenter(options []SegmentOption) *segmenter {
+	s := &segmenter{
+		minTurnRate:      6,
+		minPhaseDuration: 20 * time.Second,
+		window:           10 * time.Second,
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// A run is a run of fixes with the same phase type. It starts at the fix with
+// index start and ends at the start of the next run.
+type run struct {
+	phaseType PhaseType
+	start     int
+}
+
+// segment splits fixes into phases. Each fix is first classified as on the
+// ground, circling, or straight, and short runs are merged into their
+// neighbors. Straight runs are then split into glides and straight climbs in
+// the same way.
+func (s *segmenter) segment(fixes []*Fix) *Segmentation {
+	segmentation := &Segmentation{
+		Fixes:       fixes,
+		Totals:      make(map[PhaseType]*PhaseStats),
+		pressureAlt: hasPressureAlt(fixes),
+	}
+	if len(fixes) < 2 {
+		return segmentation
+	}
+
+	airborne := make([]bool, len(fixes))
+	flightOptions := append([]FlightOption{WithSmoothingWindow(s.window)}, s.flightOptions...)
+	for _, flight := range DetectFlightsInFixes(fixes, flightOptions...) {
+		for i := flight.TakeoffIndex; i <= flight.LandingIndex; i++ {
+			airborne[i] = true
+		}
+	}
+
+	var runs []run
+	for i, fix := range fixes {
+		var phaseType PhaseType
+		switch {
+		case !airborne[i]:
+			phaseType = PhaseGround
+		case fix.TurnRate <= -s.minTurnRate:
+			phaseType = PhaseCirclingLeft
+		case fix.TurnRate >= s.minTurnRate:
+			phaseType = PhaseCirclingRight
+		default:
+			phaseType = PhaseGlide
+		}
+		runs = appendRun(runs, run{phaseType: phaseType, start: i})
+	}
+	runs = s.mergeShortRuns(fixes, runs, len(fixes)-1)
+
+	var phaseRuns []run
+	for j, r := range runs {
+		if r.phaseType != PhaseGlide {
+			phaseRuns = appendRun(phaseRuns, r)
+			continue
+		}
+		end := len(fixes) - 1
+		if j+1 < len(runs) {
+			end = runs[j+1].start
+		}
+		var straightRuns []run
+		for i := r.start; i < end; i++ {
+			phaseType := PhaseGlide
+			if segmentation.verticalSpeed(fixes[i]) >= s.minClimb {
+				phaseType = PhaseStraightClimb
+			}
+			straightRuns = appendRun(straightRuns, run{phaseType: phaseType, start: i})
+		}
+		for _, straightRun := range s.mergeShortRuns(fixes, straightRuns, end) {
+			phaseRuns = appendRun(phaseRuns, straightRun)
+		}
+	}
+
+	cumulativeDistances := cumulativeDistances(fixes, geo.FAISphere)
+	totalDuration := fixes[len(fixes)-1].Time.Sub(fixes[0].Time)
+	for j, r := range phaseRuns {
+		end := len(fixes) - 1
+		if j+1 < len(phaseRuns) {
+			end = phaseRuns[j+1].start
+		}
+		phase := &Phase{
+			Type:       r.phaseType,
+			Start:      fixes[r.start],
+			End:        fixes[end],
+			StartIndex: r.start,
+			EndIndex:   end,
+			PhaseStats: PhaseStats{
+				Count:    1,
+				Duration: fixes[end].Time.Sub(fixes[r.start].Time),
+				Distance: cumulativeDistances[end] - cumulativeDistances[r.start],
+				AltGain:  segmentation.altitude(fixes[end]) - segmentation.altitude(fixes[r.start]),
+			},
+		}
+		phase.PhaseStats.update(totalDuration)
+		segmentation.Phases = append(segmentation.Phases, phase)
+
+		totals, ok := segmentation.Totals[phase.Type]
+		if !ok {
+			totals = &PhaseStats{}
+			segmentation.Totals[phase.Type] = totals
+		}
+		totals.add(&phase.PhaseStats)
+		segmentation.Total.add(&phase.PhaseStats)
+	}
+	for _, totals := range segmentation.Totals {
+		totals.update(totalDuration)
+	}
+	segmentation.Total.update(totalDuration)
+
+	return segmentation
+}
+
+// mergeShortRuns merges airborne runs shorter than the minimum phase duration
+// into their neighbors. end is the index of the fix at which the last run
+// ends.
+func (s *segmenter) mergeShortRuns(fixes []*Fix, runs []run, end int) []run {
+	duration := func(j int) time.Duration {
+		runEnd := end
+		if j+1 < len(runs) {
+			runEnd = runs[j+1].start
+		}
+		return fixes[runEnd].Time.Sub(fixes[runs[j].start].Time)
+	}
+
+	merged := make([]run, 0, len(runs))
+	lastShort := false
+	for j, r := range runs {
+		short := r.phaseType != PhaseGround && duration(j) < s.minPhaseDuration
+		switch {
+		case len(merged) == 0:
+			merged = append(merged, r)
+			lastShort = short
+		case short && merged[len(merged)-1].phaseType != PhaseGround:
+			// Merge a short run into the previous run.
+		case lastShort && r.phaseType != PhaseGround:
+			// Merge the previous short run into this run.
+			merged[len(merged)-1].phaseType = r.phaseType
+			lastShort = false
+		default:
+			merged = appendRun(merged, r)
+			lastShort = short
+		}
+		if n := len(merged); n >= 2 && merged[n-1].phaseType == merged[n-2].phaseType {
+			merged = merged[:n-1]
+		}
+	}
+	return merged
+}
+
+// altitude returns the altitude of fix, using the pressure altitude if s has
+// pressure altitudes.
+func (s *Segmentation) altitude(fix *Fix) float64 {
+	if s.pressureAlt {
+		return fix.PressureAlt
+	}
+	return fix.GNSSAlt
+}
+
+// verticalSpeed returns the vertical speed of fix, using the pressure vertical
+// speed if s has pressure altitudes.
+func (s *Segmentation) verticalSpeed(fix *Fix) float64 {
+	if s.pressureAlt {
+		return fix.PressureVerticalSpeed
+	}
+	return fix.GNSSVerticalSpeed
+}
+
+// add adds other to s.
+func (s *PhaseStats) add(other *PhaseStats) {
+	s.Count += other.Count
+	s.Duration += other.Duration
+	s.Distance += other.Distance
+	s.AltGain += other.AltGain
+}
+
+// update updates the derived statistics of s.
+func (s *PhaseStats) update(totalDuration time.Duration) {
+	if seconds := s.Duration.Seconds(); seconds > 0 {
+		s.AvgClimb = s.AltGain / seconds
+		s.AvgSpeed = s.Distance / seconds
+	}
+	if s.AltGain < 0 {
+		s.GlideRatio = s.Distance / -s.AltGain
+	}
+	if totalDuration > 0 {
+		s.TimeShare = float64(s.Duration) / float64(totalDuration)
+	}
+}
+
+// Circling returns whether t is a circling phase type.
+func (t PhaseType) Circling() bool {
+	return t == PhaseCirclingLeft || t == PhaseCirclingRight
+}
+
+// appendRun appends r to runs, unless it continues the last run.
+func appendRun(runs []run, r run) []run {
+	if len(runs) > 0 && runs[len(runs)-1].phaseType == r.phaseType {
+		return runs
+	}
+	return append(runs, r)
+}
+
+// cumulativeDistances returns the cumulative distance along fixes in meters.
+func cumulativeDistances(fixes []*Fix, earth geo.Earth) []float64 {
+	cumulativeDistances := make([]float64, len(fixes))
+	for i := 1; i < len(fixes); i++ {
+		p1 := geo.Point{Lat: fixes[i-1].Lat, Lon: fixes[i-1].Lon}
+		p2 := geo.Point{Lat: fixes[i].Lat, Lon: fixes[i].Lon}
+		cumulativeDistances[i] = cumulativeDistances[i-1] + earth.Distance(p1, p2)
+	}
+	return cumulativeDistances
+}
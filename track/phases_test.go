@@ -0,0 +1,74 @@
+package track_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-igc/internal/synthetic"
+	"github.com/twpayne/go-igc/track"
+)
+
+func TestSegmentPhases(t *testing.T) {
+	segmentation := track.SegmentPhases(makeBRecords([]synthetic.Segment{
+		{Duration: 5 * time.Minute},
+		{Duration: 3 * time.Minute, GroundSpeed: 10, VerticalSpeed: -1},
+		{Duration: 2 * time.Minute, GroundSpeed: 10, VerticalSpeed: 2, TurnRate: 18},
+		{Duration: 2 * time.Minute, GroundSpeed: 10, VerticalSpeed: 1, TurnRate: -15},
+		{Duration: 2 * time.Minute, GroundSpeed: 10, VerticalSpeed: 0.5},
+		{Duration: 5 * time.Second, GroundSpeed: 10, VerticalSpeed: -1, TurnRate: 20},
+		{Duration: 4 * time.Minute, GroundSpeed: 12, VerticalSpeed: -1.5},
+		{Duration: 5 * time.Minute},
+	}))
+
+	expected := []struct {
+		phaseType track.PhaseType
+		start     time.Duration
+	}{
+		{phaseType: track.PhaseGround, start: 0},
+		{phaseType: track.PhaseGlide, start: 5 * time.Minute},
+		{phaseType: track.PhaseCirclingRight, start: 8 * time.Minute},
+		{phaseType: track.PhaseCirclingLeft, start: 10 * time.Minute},
+		{phaseType: track.PhaseStraightClimb, start: 12 * time.Minute},
+		{phaseType: track.PhaseGlide, start: 14 * time.Minute},
+		{phaseType: track.PhaseGround, start: 18*time.Minute + 5*time.Second},
+	}
+	assert.Equal(t, len(expected), len(segmentation.Phases))
+	for i, phase := range segmentation.Phases {
+		assert.Equal(t, expected[i].phaseType, phase.Type)
+		assertClose(t, expected[i].start.Seconds(), phase.Start.Time.Sub(startTime).Seconds(), 10)
+		if i > 0 {
+			assert.Equal(t, segmentation.Phases[i-1].EndIndex, phase.StartIndex)
+		}
+	}
+
+	circlingRight := segmentation.Phases[2]
+	assertClose(t, 2, circlingRight.AvgClimb, 0.2)
+	assertClose(t, 10, circlingRight.AvgSpeed, 0.5)
+	assert.Equal(t, 0, circlingRight.GlideRatio)
+
+	finalGlide := segmentation.Phases[5]
+	assertClose(t, 8, finalGlide.GlideRatio, 0.5)
+
+	glideTotals := segmentation.Totals[track.PhaseGlide]
+	assert.Equal(t, 2, glideTotals.Count)
+	assertClose(t, 7*60, glideTotals.Duration.Seconds(), 20)
+
+	totalDuration := time.Duration(0)
+	totalTimeShare := 0.0
+	for _, totals := range segmentation.Totals {
+		totalDuration += totals.Duration
+		totalTimeShare += totals.TimeShare
+	}
+	assert.Equal(t, segmentation.Total.Duration, totalDuration)
+	assertClose(t, 1, totalTimeShare, 1e-9)
+	assert.Equal(t, len(segmentation.Phases), segmentation.Total.Count)
+}
+
+func TestPhaseTypeCircling(t *testing.T) {
+	assert.True(t, track.PhaseCirclingLeft.Circling())
+	assert.True(t, track.PhaseCirclingRight.Circling())
+	assert.False(t, track.PhaseGlide.Circling())
+	assert.False(t, track.PhaseGround.Circling())
+}
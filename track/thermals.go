@@ -0,0 +1,141 @@
+package track
+
+import (
+	"math"
+	"time"
+
+	"github.com/twpayne/go-igc/geo"
+)
+
+// A Circle is a single 360° turn.
+type Circle struct {
+	Start      *Fix
+	End        *Fix
+	StartIndex int       // StartIndex is the index of Start in the fixes.
+	EndIndex   int       // EndIndex is the index of End in the fixes.
+	Center     geo.Point // Center is the mean position of the fixes in the circle.
+	Time       time.Time // Time is the time midway between Start and End.
+	Alt        float64   // Alt is the mean altitude of the fixes in the circle.
+}
+
+// A Thermal is a run of consecutive circling phases.
+type Thermal struct {
+	Phases         []*Phase
+	Entry          *Fix
+	Exit           *Fix
+	EntryIndex     int // EntryIndex is the index of Entry in the fixes.
+	ExitIndex      int // ExitIndex is the index of Exit in the fixes.
+	EntryAlt       float64
+	ExitAlt        float64
+	AltGain        float64   // AltGain is the net change in altitude in meters.
+	AvgClimb       float64   // AvgClimb is the average vertical speed in m/s.
+	PeakClimb      float64   // PeakClimb is the maximum smoothed vertical speed in m/s.
+	Turns          float64   // Turns is the total change in track divided by 360°.
+	Circles        []*Circle // Circles are the complete circles in the thermal.
+	Center         geo.Point // Center is the mean of the centers of Circles.
+	DriftSpeed     float64   // DriftSpeed is the speed in m/s of the center from the first to the last circle.
+	DriftDirection float64   // DriftDirection is the direction in degrees towards which the center drifts.
+}
+
+// Thermals returns the thermals in s.
+func (s *Segmentation) Thermals() []*Thermal {
+	var thermals []*Thermal
+	var current *Thermal
+	for _, phase := range s.Phases {
+		if !phase.Type.Circling() {
+			current = nil
+			continue
+		}
+		if current == nil {
+			current = &Thermal{
+				Entry:      phase.Start,
+				EntryIndex: phase.StartIndex,
+			}
+			thermals = append(thermals, current)
+		}
+		current.Phases = append(current.Phases, phase)
+		current.Exit = phase.End
+		current.ExitIndex = phase.EndIndex
+	}
+	for _, thermal := range thermals {
+		s.updateThermal(thermal)
+	}
+	return thermals
+}
+
+// updateThermal updates the statistics and circles of thermal.
+func (s *Segmentation) updateThermal(thermal *Thermal) {
+	thermal.EntryAlt = s.altitude(thermal.Entry)
+	thermal.ExitAlt = s.altitude(thermal.Exit)
+	thermal.AltGain = thermal.ExitAlt - thermal.EntryAlt
+	if seconds := thermal.Exit.Time.Sub(thermal.Entry.Time).Seconds(); seconds > 0 {
+		thermal.AvgClimb = thermal.AltGain / seconds
+	}
+	thermal.PeakClimb = math.Inf(-1)
+	for i := thermal.EntryIndex; i <= thermal.ExitIndex; i++ {
+		thermal.PeakClimb = max(thermal.PeakClimb, s.verticalSpeed(s.Fixes[i]))
+	}
+
+	var totalTurn, circleTurn float64
+	circleStart := thermal.EntryIndex
+	for i := thermal.EntryIndex + 1; i <= thermal.ExitIndex; i++ {
+		turn := math.Abs(geo.BearingDifference(s.Fixes[i-1].Track, s.Fixes[i].Track))
+		totalTurn += turn
+		circleTurn += turn
+		if circleTurn >= 360 {
+			thermal.Circles = append(thermal.Circles, s.newCircle(circleStart, i))
+			circleStart = i
+			circleTurn = 0
+		}
+	}
+	thermal.Turns = totalTurn / 360
+
+	if len(thermal.Circles) == 0 {
+		return
+	}
+	var sumLat, sumLon float64
+	for _, circle := range thermal.Circles {
+		sumLat += circle.Center.Lat
+		sumLon += circle.Center.Lon
+	}
+	thermal.Center = geo.Point{
+		Lat: sumLat / float64(len(thermal.Circles)),
+		Lon: sumLon / float64(len(thermal.Circles)),
+	}
+	first, last := thermal.Circles[0], thermal.Circles[len(thermal.Circles)-1]
+	thermal.DriftSpeed, thermal.DriftDirection = drift(first, last)
+}
+
+// newCircle returns a new Circle from the fixes in s with indexes from start to
+// end.
+func (s *Segmentation) newCircle(start, end int) *Circle {
+	var sumLat, sumLon, sumAlt float64
+	for i := start; i < end; i++ {
+		fix := s.Fixes[i]
+		sumLat += fix.Lat
+		sumLon += fix.Lon
+		sumAlt += s.altitude(fix)
+	}
+	n := float64(end - start)
+	startFix, endFix := s.Fixes[start], s.Fixes[end]
+	return &Circle{
+		Start:      startFix,
+		End:        endFix,
+		StartIndex: start,
+		EndIndex:   end,
+		Center:     geo.Point{Lat: sumLat / n, Lon: sumLon / n},
+		Time:       startFix.Time.Add(endFix.Time.Sub(startFix.Time) / 2),
+		Alt:        sumAlt / n,
+	}
+}
+
+// drift returns the speed in m/s and direction in degrees of the movement of
+// the center of circle1 to the center of circle2.
+func drift(circle1, circle2 *Circle) (float64, float64) {
+	seconds := circle2.Time.Sub(circle1.Time).Seconds()
+	if seconds <= 0 || circle1.Center == circle2.Center {
+		return 0, 0
+	}
+	distance := geo.FAISphere.Distance(circle1.Center, circle2.Center)
+	return distance / seconds, geo.FAISphere.InitialBearing(circle1.Center, circle2.Center)
+}
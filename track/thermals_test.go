@@ -0,0 +1,51 @@
+package track_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-igc/internal/synthetic"
+	"github.com/twpayne/go-igc/track"
+)
+
+func TestThermals(t *testing.T) {
+	// Two thermals in a 3 m/s wind from the west, separated by a glide.
+	segmentation := track.SegmentPhases(makeBRecords([]synthetic.Segment{
+		{Duration: 5 * time.Minute},
+		{Duration: 3 * time.Minute, GroundSpeed: 10, VerticalSpeed: -1},
+		{Duration: 4 * time.Minute, GroundSpeed: 10, VerticalSpeed: 2, TurnRate: 15, DriftSpeed: 3, DriftTrack: 90},
+		{Duration: 3 * time.Minute, GroundSpeed: 10, VerticalSpeed: -1},
+		{Duration: 2 * time.Minute, GroundSpeed: 10, VerticalSpeed: 1, TurnRate: -12, DriftSpeed: 3, DriftTrack: 90},
+		{Duration: time.Minute, GroundSpeed: 10, VerticalSpeed: 1, TurnRate: 12, DriftSpeed: 3, DriftTrack: 90},
+		{Duration: 3 * time.Minute, GroundSpeed: 10, VerticalSpeed: -1},
+		{Duration: 5 * time.Minute},
+	}))
+
+	thermals := segmentation.Thermals()
+	assert.Equal(t, 2, len(thermals))
+
+	thermal := thermals[0]
+	assert.Equal(t, 1, len(thermal.Phases))
+	assertClose(t, (8 * time.Minute).Seconds(), thermal.Entry.Time.Sub(startTime).Seconds(), 10)
+	assertClose(t, (12 * time.Minute).Seconds(), thermal.Exit.Time.Sub(startTime).Seconds(), 10)
+	assertClose(t, 2, thermal.AvgClimb, 0.2)
+	assertClose(t, 2, thermal.PeakClimb, 0.2)
+	assertClose(t, 480, thermal.AltGain, 30)
+	assertClose(t, 10, thermal.Turns, 1)
+	assert.True(t, len(thermal.Circles) >= 9)
+	assertClose(t, 3, thermal.DriftSpeed, 0.3)
+	assertClose(t, 90, thermal.DriftDirection, 5)
+	for _, circle := range thermal.Circles {
+		assert.True(t, circle.Alt > thermal.EntryAlt && circle.Alt < thermal.ExitAlt)
+	}
+
+	// The change of direction does not split the second thermal.
+	thermal = thermals[1]
+	assert.Equal(t, 2, len(thermal.Phases))
+	assert.Equal(t, track.PhaseCirclingLeft, thermal.Phases[0].Type)
+	assert.Equal(t, track.PhaseCirclingRight, thermal.Phases[1].Type)
+	assertClose(t, 1, thermal.AvgClimb, 0.2)
+	assertClose(t, 3, thermal.DriftSpeed, 0.5)
+}
@@ -0,0 +1,173 @@
+package track
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/twpayne/go-igc"
+	"github.com/twpayne/go-igc/geo"
+)
+
+// A WindSource is the source of a wind estimate.
+type WindSource string
+
+// Wind sources.
+const (
+	WindSourceCirclingDrift WindSource = "circling-drift" // CirclingDrift is the drift of the centers of consecutive circles.
+	WindSourceKRecord       WindSource = "k-record"       // KRecord is the WDI and WSP, or WVE, K record additions.
+)
+
+// A WindEstimate is an estimate of the wind at a time and altitude.
+type WindEstimate struct {
+	Time      time.Time
+	Alt       float64
+	Speed     float64 // Speed is the wind speed in m/s.
+	Direction float64 // Direction is the direction from which the wind blows in degrees.
+	Source    WindSource
+}
+
+// A WindLayer is the mean wind in a range of altitudes.
+type WindLayer struct {
+	MinAlt    float64
+	MaxAlt    float64
+	Speed     float64 // Speed is the speed of the vector mean of the estimates in m/s.
+	Direction float64 // Direction is the direction from which the vector mean of the estimates blows in degrees.
+	Count     int     // Count is the number of estimates.
+}
+
+// CirclingWind returns wind estimates from the drift of the centers of
+// consecutive circles in thermals, assuming that thermals drift with the
+// wind.
+func CirclingWind(thermals []*Thermal) []*WindEstimate {
+	var windEstimates []*WindEstimate
+	for _, thermal := range thermals {
+		for i := 1; i < len(thermal.Circles); i++ {
+			circle1, circle2 := thermal.Circles[i-1], thermal.Circles[i]
+			speed, direction := drift(circle1, circle2)
+			windEstimates = append(windEstimates, &WindEstimate{
+				Time:      circle1.Time.Add(circle2.Time.Sub(circle1.Time) / 2),
+				Alt:       (circle1.Alt + circle2.Alt) / 2,
+				Speed:     speed,
+				Direction: normalizeTrack(direction + 180),
+				Source:    WindSourceCirclingDrift,
+			})
+		}
+	}
+	return windEstimates
+}
+
+// KRecordWind returns wind estimates from the wind additions of kRecords. The
+// altitude of each estimate is interpolated from fixes, using pressure
+// altitudes if fixes have them.
+func KRecordWind(kRecords []*igc.KRecord, fixes []*Fix) []*WindEstimate {
+	pressureAlt := hasPressureAlt(fixes)
+	var windEstimates []*WindEstimate
+	for _, kRecord := range kRecords {
+		if kRecord == nil {
+			continue
+		}
+		direction, ok := kRecord.WindDirection()
+		if !ok {
+			continue
+		}
+		speed, ok := kRecord.WindSpeed()
+		if !ok {
+			continue
+		}
+		windEstimates = append(windEstimates, &WindEstimate{
+			Time:      kRecord.Time,
+			Alt:       interpolateAlt(fixes, kRecord.Time, pressureAlt),
+			Speed:     speed / 3.6,
+			Direction: normalizeTrack(direction),
+			Source:    WindSourceKRecord,
+		})
+	}
+	return windEstimates
+}
+
+// Wind returns wind estimates for igcFile from its K records and from the
+// drift of its thermals, sorted by time.
+func Wind(igcFile *igc.IGC, options ...SegmentOption) []*WindEstimate {
+	segmentation := SegmentPhases(igcFile.BRecords, options...)
+	windEstimates := KRecordWind(igcFile.KRecords, segmentation.Fixes)
+	windEstimates = append(windEstimates, CirclingWind(segmentation.Thermals())...)
+	slices.SortStableFunc(windEstimates, func(a, b *WindEstimate) int {
+		return a.Time.Compare(b.Time)
+	})
+	return windEstimates
+}
+
+// WindProfile returns the vector mean of windEstimates in layers of
+// layerHeight meters, ordered by altitude. Layers without estimates are
+// omitted.
+func WindProfile(windEstimates []*WindEstimate, layerHeight float64) []*WindLayer {
+	type sum struct {
+		u, v  float64
+		count int
+	}
+	sumsByLayer := make(map[int]*sum)
+	for _, windEstimate := range windEstimates {
+		layer := int(math.Floor(windEstimate.Alt / layerHeight))
+		s, ok := sumsByLayer[layer]
+		if !ok {
+			s = &sum{}
+			sumsByLayer[layer] = s
+		}
+		direction := geo.Radians(windEstimate.Direction)
+		s.u += windEstimate.Speed * math.Sin(direction)
+		s.v += windEstimate.Speed * math.Cos(direction)
+		s.count++
+	}
+
+	windLayers := make([]*WindLayer, 0, len(sumsByLayer))
+	for _, layer := range slices.Sorted(maps.Keys(sumsByLayer)) {
+		s := sumsByLayer[layer]
+		u, v := s.u/float64(s.count), s.v/float64(s.count)
+		windLayers = append(windLayers, &WindLayer{
+			MinAlt:    float64(layer) * layerHeight,
+			MaxAlt:    float64(layer+1) * layerHeight,
+			Speed:     math.Hypot(u, v),
+			Direction: normalizeTrack(geo.Degrees(math.Atan2(u, v))),
+			Count:     s.count,
+		})
+	}
+	return windLayers
+}
+
+// interpolateAlt returns the altitude at t interpolated linearly between
+// fixes.
+func interpolateAlt(fixes []*Fix, t time.Time, pressureAlt bool) float64 {
+	if len(fixes) == 0 {
+		return 0
+	}
+	altitude := func(fix *Fix) float64 {
+		if pressureAlt {
+			return fix.PressureAlt
+		}
+		return fix.GNSSAlt
+	}
+	i := sort.Search(len(fixes), func(i int) bool {
+		return !fixes[i].Time.Before(t)
+	})
+	switch {
+	case i == 0:
+		return altitude(fixes[0])
+	case i == len(fixes):
+		return altitude(fixes[len(fixes)-1])
+	}
+	fix1, fix2 := fixes[i-1], fixes[i]
+	dt := fix2.Time.Sub(fix1.Time)
+	if dt <= 0 {
+		return altitude(fix2)
+	}
+	f := float64(t.Sub(fix1.Time)) / float64(dt)
+	return altitude(fix1) + f*(altitude(fix2)-altitude(fix1))
+}
+
+// normalizeTrack normalizes track to the range [0, 360).
+func normalizeTrack(track float64) float64 {
+	return geo.BearingDifference(180, track) + 180
+}
@@ -0,0 +1,74 @@
+package track_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-igc"
+	"github.com/twpayne/go-igc/internal/synthetic"
+	"github.com/twpayne/go-igc/track"
+)
+
+func TestCirclingWind(t *testing.T) {
+	segmentation := track.SegmentPhases(makeBRecords([]synthetic.Segment{
+		{Duration: 5 * time.Minute},
+		{Duration: 3 * time.Minute, GroundSpeed: 10, VerticalSpeed: -1},
+		{Duration: 4 * time.Minute, GroundSpeed: 10, VerticalSpeed: 2, TurnRate: 15, DriftSpeed: 4, DriftTrack: 45},
+		{Duration: 3 * time.Minute, GroundSpeed: 10, VerticalSpeed: -1},
+		{Duration: 5 * time.Minute},
+	}))
+
+	windEstimates := track.CirclingWind(segmentation.Thermals())
+	assert.True(t, len(windEstimates) >= 8)
+	for _, windEstimate := range windEstimates {
+		assert.Equal(t, track.WindSourceCirclingDrift, windEstimate.Source)
+		assertClose(t, 4, windEstimate.Speed, 0.5)
+		assertClose(t, 225, windEstimate.Direction, 10)
+	}
+
+	windLayers := track.WindProfile(windEstimates, 200)
+	assert.Equal(t, 3, len(windLayers))
+	count := 0
+	for i, windLayer := range windLayers {
+		if i > 0 {
+			assert.Equal(t, windLayers[i-1].MaxAlt, windLayer.MinAlt)
+		}
+		assertClose(t, 4, windLayer.Speed, 0.5)
+		assertClose(t, 225, windLayer.Direction, 10)
+		count += windLayer.Count
+	}
+	assert.Equal(t, len(windEstimates), count)
+}
+
+func TestKRecordWind(t *testing.T) {
+	igcFile, err := igc.ParseLines([]string{
+		"HFDTE020508",
+		"J020810WDI1113WSP",
+		"B1000004600000N00700000EA0100001000",
+		"K100030350036",
+		"B1001004600000N00700000EA0110001100",
+		"K100200010018",
+		"K10030035",
+	})
+	assert.NoError(t, err)
+
+	fixes := track.Kinematics(igcFile.BRecords)
+	windEstimates := track.KRecordWind(igcFile.KRecords, fixes)
+	assert.Equal(t, 2, len(windEstimates))
+
+	assert.Equal(t, track.WindSourceKRecord, windEstimates[0].Source)
+	assertClose(t, 1050, windEstimates[0].Alt, 1e-9)
+	assertClose(t, 10, windEstimates[0].Speed, 1e-9)
+	assertClose(t, 350, windEstimates[0].Direction, 1e-9)
+
+	assertClose(t, 1100, windEstimates[1].Alt, 1e-9)
+	assertClose(t, 5, windEstimates[1].Speed, 1e-9)
+	assertClose(t, 10, windEstimates[1].Direction, 1e-9)
+
+	windLayers := track.WindProfile(windEstimates, 1000)
+	assert.Equal(t, 1, len(windLayers))
+	assert.Equal(t, 2, windLayers[0].Count)
+	assertClose(t, 356.6, windLayers[0].Direction, 0.1)
+}